@@ -8,5 +8,10 @@ var (
 	ErrNotYourTurn       = errors.New("it's not your turn")
 	ErrNoActiveGames     = errors.New("no active games")
 	ErrCellOccupied      = errors.New("cell is already occupied")
+	ErrWrongSubBoard     = errors.New("move is not allowed on this sub-board")
 	ErrGameAlreadyExists = errors.New("game already exists")
+	ErrGameFull          = errors.New("game is full")
+	ErrGameNotFound      = errors.New("game not found")
+	ErrPlayerNotFound    = errors.New("player not found")
+	ErrGameChanged       = errors.New("game changed since it was read")
 )
@@ -11,6 +11,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/rocketscienceinc/tictactoe-backend/internal/auth"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/repository"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/repository/storage"
@@ -47,11 +48,27 @@ func RunApp(logger *slog.Logger, conf *config.Config) error {
 	}()
 
 	playerRepo := repository.NewPlayerRepository(redisStorage.Connection)
+
+	// players stored before public IDs existed are migrated lazily as well, a failed run only delays it
+	migrated, err := playerRepo.MigratePublicIDs(ctx)
+	if err != nil {
+		log.Error("could not migrate player public IDs", "error", err)
+	} else if migrated > 0 {
+		log.Info("migrated player public IDs", "players", migrated)
+	}
+
 	gameRepo := repository.NewGameRepository(log, redisStorage.Connection)
 
-	gameUseCase := usecase.NewGameUseCase(playerRepo, gameRepo)
+	archiveRepo := repository.NewArchiveRepository(redisStorage.Connection, conf.Archive)
+
+	gameUseCase := usecase.NewGameUseCase(playerRepo, gameRepo, archiveRepo)
+
+	tokens, err := auth.NewTokenManager(conf.Auth)
+	if err != nil {
+		return fmt.Errorf("could not create token manager: %w", err)
+	}
 
-	wsHandler := websocket.New(ctx, log, gameUseCase)
+	wsHandler := websocket.New(ctx, log, conf.Websocket, gameUseCase, tokens)
 
 	mux := http.NewServeMux()
 
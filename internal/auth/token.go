@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+// minSecretLength is the shortest secret accepted for HMAC-SHA256, shorter keys are guessable.
+const minSecretLength = 32
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrWeakSecret   = errors.New("auth secret must be at least 32 bytes long")
+	ErrNoSecret     = errors.New("auth secret is not set, set AUTH_SECRET")
+)
+
+// tokenHeader is the encoded JWT header of every token, HS256 is the only algorithm the server issues or accepts.
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenManager issues and verifies HS256 JWTs that prove the identity of a player.
+type TokenManager struct {
+	secrets [][]byte // the first secret signs new tokens, every secret verifies them
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+func NewTokenManager(conf config.Auth) (*TokenManager, error) {
+	// config.yml ships without a secret, the server must not start with a key everyone knows
+	if conf.Secret == "" {
+		return nil, ErrNoSecret
+	}
+
+	secrets := make([][]byte, 0, 1+len(conf.PreviousSecrets))
+
+	for _, secret := range append([]string{conf.Secret}, conf.PreviousSecrets...) {
+		if len(secret) < minSecretLength {
+			return nil, ErrWeakSecret
+		}
+		secrets = append(secrets, []byte(secret))
+	}
+
+	return &TokenManager{
+		secrets: secrets,
+		ttl:     conf.TokenTTL,
+		now:     time.Now,
+	}, nil
+}
+
+// Issue - issues a token for the player, it expires after the configured TTL.
+func (that *TokenManager) Issue(playerID string) (string, error) {
+	now := that.now()
+
+	body, err := json.Marshal(claims{
+		Subject:   playerID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(that.ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal claims: %w", err)
+	}
+
+	signingInput := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sign(that.secrets[0], signingInput)), nil
+}
+
+// Verify - checks the signature and the expiry of the token and returns the ID of the player it was issued to.
+func (that *TokenManager) Verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return "", fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	if !that.validSignature(parts[0]+"."+parts[1], signature) {
+		return "", fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	var tokenClaims claims
+	if err = json.Unmarshal(body, &tokenClaims); err != nil || tokenClaims.Subject == "" {
+		return "", fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	if !that.now().Before(time.Unix(tokenClaims.ExpiresAt, 0)) {
+		return "", ErrTokenExpired
+	}
+
+	return tokenClaims.Subject, nil
+}
+
+func (that *TokenManager) validSignature(signingInput string, signature []byte) bool {
+	for _, secret := range that.secrets {
+		if hmac.Equal(signature, sign(secret, signingInput)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sign(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+const (
+	testSecret    = "0123456789abcdef0123456789abcdef"
+	testOldSecret = "fedcba9876543210fedcba9876543210"
+)
+
+func newTestManager(t *testing.T, conf config.Auth) *TokenManager {
+	t.Helper()
+
+	manager, err := NewTokenManager(conf)
+	require.NoError(t, err)
+
+	return manager
+}
+
+func TestTokenManager(t *testing.T) {
+	t.Run("Verifies the token it issued", func(t *testing.T) {
+		// Given: a token issued for a player
+		manager := newTestManager(t, config.Auth{Secret: testSecret, TokenTTL: time.Hour})
+		token, err := manager.Issue("player-1")
+		require.NoError(t, err)
+
+		// When: verifying the token
+		playerID, err := manager.Verify(token)
+
+		// Then: the player ID should be returned
+		require.NoError(t, err)
+		assert.Equal(t, "player-1", playerID)
+	})
+
+	t.Run("Rejects an expired token", func(t *testing.T) {
+		// Given: a token issued two hours ago with a one hour TTL
+		manager := newTestManager(t, config.Auth{Secret: testSecret, TokenTTL: time.Hour})
+		manager.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+		token, err := manager.Issue("player-1")
+		require.NoError(t, err)
+		manager.now = time.Now
+
+		// When: verifying the token
+		_, err = manager.Verify(token)
+
+		// Then: ErrTokenExpired should be returned
+		require.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	t.Run("Rejects a token with changed claims", func(t *testing.T) {
+		// Given: a token whose claims are replaced by the claims of another token
+		manager := newTestManager(t, config.Auth{Secret: testSecret, TokenTTL: time.Hour})
+		token, err := manager.Issue("player-1")
+		require.NoError(t, err)
+		otherToken, err := manager.Issue("player-2")
+		require.NoError(t, err)
+
+		parts := strings.Split(token, ".")
+		parts[1] = strings.Split(otherToken, ".")[1]
+
+		// When: verifying the forged token
+		_, err = manager.Verify(strings.Join(parts, "."))
+
+		// Then: ErrInvalidToken should be returned
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Rejects a token signed with another secret", func(t *testing.T) {
+		// Given: a token signed with a secret the manager does not know
+		issuer := newTestManager(t, config.Auth{Secret: testOldSecret, TokenTTL: time.Hour})
+		token, err := issuer.Issue("player-1")
+		require.NoError(t, err)
+
+		manager := newTestManager(t, config.Auth{Secret: testSecret, TokenTTL: time.Hour})
+
+		// When: verifying the token
+		_, err = manager.Verify(token)
+
+		// Then: ErrInvalidToken should be returned
+		require.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Accepts a token signed with a previous secret", func(t *testing.T) {
+		// Given: a token signed before the secret was rotated
+		issuer := newTestManager(t, config.Auth{Secret: testOldSecret, TokenTTL: time.Hour})
+		token, err := issuer.Issue("player-1")
+		require.NoError(t, err)
+
+		manager := newTestManager(t, config.Auth{Secret: testSecret, PreviousSecrets: []string{testOldSecret}, TokenTTL: time.Hour})
+
+		// When: verifying the token after the rotation
+		playerID, err := manager.Verify(token)
+
+		// Then: the player ID should be returned
+		require.NoError(t, err)
+		assert.Equal(t, "player-1", playerID)
+	})
+
+	t.Run("Refuses a short secret", func(t *testing.T) {
+		// When: creating a manager with a short secret
+		_, err := NewTokenManager(config.Auth{Secret: "short"})
+
+		// Then: ErrWeakSecret should be returned
+		require.ErrorIs(t, err, ErrWeakSecret)
+	})
+
+	t.Run("Refuses a short previous secret", func(t *testing.T) {
+		// When: creating a manager with a strong secret but a short previous one
+		_, err := NewTokenManager(config.Auth{Secret: testSecret, PreviousSecrets: []string{"short"}})
+
+		// Then: ErrWeakSecret should be returned
+		require.ErrorIs(t, err, ErrWeakSecret)
+	})
+
+	t.Run("Refuses a missing secret", func(t *testing.T) {
+		// When: creating a manager without a secret
+		_, err := NewTokenManager(config.Auth{})
+
+		// Then: ErrNoSecret should be returned
+		require.ErrorIs(t, err, ErrNoSecret)
+	})
+}
+
+func TestNewTokenManager_ShippedConfig(t *testing.T) {
+	t.Run("Refuses to start without AUTH_SECRET", func(t *testing.T) {
+		// Given: the shipped config loaded without AUTH_SECRET in the environment
+		t.Setenv("AUTH_SECRET", "")
+		os.Unsetenv("AUTH_SECRET")
+		conf := config.MustLoad("../../config.yml")
+
+		// When: creating a manager from it
+		_, err := NewTokenManager(conf.Auth)
+
+		// Then: ErrNoSecret should be returned
+		require.ErrorIs(t, err, ErrNoSecret)
+	})
+
+	t.Run("Starts with AUTH_SECRET", func(t *testing.T) {
+		// Given: the shipped config loaded with AUTH_SECRET in the environment
+		t.Setenv("AUTH_SECRET", testSecret)
+		conf := config.MustLoad("../../config.yml")
+
+		// When: creating a manager from it
+		_, err := NewTokenManager(conf.Auth)
+
+		// Then: the manager should be created
+		require.NoError(t, err)
+	})
+}
@@ -2,14 +2,18 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	LogLevel string `yaml:"log-level" env-default:"info"`
-	HTTPPort string `yaml:"http-port" env-default:"9090"`
-	Redis    Redis  `yaml:"redis"`
+	LogLevel  string    `yaml:"log-level" env-default:"info"`
+	HTTPPort  string    `yaml:"http-port" env-default:"9090"`
+	Redis     Redis     `yaml:"redis"`
+	Websocket Websocket `yaml:"websocket"`
+	Auth      Auth      `yaml:"auth"`
+	Archive   Archive   `yaml:"archive"`
 }
 
 type Redis struct {
@@ -17,6 +21,87 @@ type Redis struct {
 	Port string `yaml:"port" env-default:"6379"`
 }
 
+type Websocket struct {
+	// MaxFrameSize - the largest payload in bytes a single frame may declare.
+	MaxFrameSize int64 `yaml:"max-frame-size" env-default:"16384"`
+	// MaxMessageSize - the largest message in bytes, including all of its fragments, the server accepts.
+	MaxMessageSize int64 `yaml:"max-message-size" env-default:"65536"`
+	// SendQueueSize - how many outbound frames may wait for the writer of a single connection.
+	SendQueueSize int `yaml:"send-queue-size" env-default:"64"`
+	// WriteTimeout - how long writing a single frame to the socket may take.
+	WriteTimeout time.Duration `yaml:"write-timeout" env-default:"10s"`
+	// PingInterval - how long a connection may stay silent before the server pings the peer.
+	PingInterval time.Duration `yaml:"ping-interval" env-default:"30s"`
+	// PongWait - how long the server waits for the peer to answer a ping or to finish a started frame.
+	PongWait time.Duration `yaml:"pong-wait" env-default:"10s"`
+	// SlowConsumerPolicy - what to do when the send queue is full: "disconnect" or "drop".
+	SlowConsumerPolicy string `yaml:"slow-consumer-policy" env-default:"disconnect"`
+	// AllowedOrigins - origins browsers may open sockets from, "*" allows any. Empty means same-origin only.
+	AllowedOrigins []string `yaml:"allowed-origins"`
+	// Compression - permessage-deflate settings.
+	Compression Compression `yaml:"compression"`
+	// RateLimit - limits of how many messages clients may send.
+	RateLimit RateLimit `yaml:"rate-limit"`
+	// MaxSpectators - how many connections may watch a single game, zero lets nobody watch.
+	MaxSpectators int `yaml:"max-spectators" env-default:"50"`
+}
+
+type Compression struct {
+	// Enabled - whether the server accepts the permessage-deflate extension.
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// Level - the flate compression level, from 1 (best speed) to 9 (best compression).
+	Level int `yaml:"level" env-default:"1"`
+	// MinSize - messages shorter than this many bytes are sent uncompressed.
+	MinSize int `yaml:"min-size" env-default:"256"`
+	// ServerNoContextTakeover - reset the server compressor after every message, trading ratio for memory.
+	ServerNoContextTakeover bool `yaml:"server-no-context-takeover" env-default:"false"`
+	// ClientNoContextTakeover - ask clients to reset their compressor after every message.
+	ClientNoContextTakeover bool `yaml:"client-no-context-takeover" env-default:"false"`
+}
+
+type RateLimit struct {
+	// Enabled - whether messages are rate limited at all.
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// SessionRate and SessionBurst - the token bucket of all messages of a single connection, the rate is per second.
+	SessionRate  float64 `yaml:"session-rate" env-default:"10"`
+	SessionBurst int     `yaml:"session-burst" env-default:"20"`
+	// IPRate and IPBurst - the token bucket of all messages of all connections from a single remote IP.
+	IPRate  float64 `yaml:"ip-rate" env-default:"30"`
+	IPBurst int     `yaml:"ip-burst" env-default:"60"`
+	// Actions - token buckets of single actions, every connection has its own.
+	Actions map[string]Bucket `yaml:"actions"`
+	// MaxViolations - how many limited messages within ViolationWindow make the server close the connection.
+	MaxViolations   int           `yaml:"max-violations" env-default:"20"`
+	ViolationWindow time.Duration `yaml:"violation-window" env-default:"10s"`
+	// TrustedProxies - addresses or CIDR ranges of the reverse proxies whose X-Forwarded-For and X-Real-IP are believed.
+	// Empty trusts nobody, the limits are counted by the address of the peer.
+	TrustedProxies []string `yaml:"trusted-proxies" env:"RATE_LIMIT_TRUSTED_PROXIES"`
+}
+
+type Bucket struct {
+	// Rate - how many tokens are added per second.
+	Rate float64 `yaml:"rate"`
+	// Burst - how many tokens the bucket holds.
+	Burst int `yaml:"burst"`
+}
+
+type Auth struct {
+	// Secret - the key player tokens are signed with, at least 32 bytes long.
+	Secret string `yaml:"secret" env:"AUTH_SECRET"`
+	// PreviousSecrets - retired keys whose tokens are still accepted while the secret is being rotated.
+	PreviousSecrets []string `yaml:"previous-secrets" env:"AUTH_PREVIOUS_SECRETS"`
+	// TokenTTL - how long an issued token stays valid, every connect issues a fresh one.
+	TokenTTL time.Duration `yaml:"token-ttl" env-default:"720h"`
+}
+
+type Archive struct {
+	// TTL - how long a finished game is kept in the archive, zero keeps it forever.
+	TTL time.Duration `yaml:"ttl" env-default:"2160h"`
+	// MaxGamesPerPlayer - how many of the latest games the history of a player keeps, zero keeps all of them.
+	// A game dropped from the histories of all its players is deleted from the archive.
+	MaxGamesPerPlayer int `yaml:"max-games-per-player" env-default:"200"`
+}
+
 // MustLoad - load all configurations in config.yml file.
 func MustLoad(path string) *Config {
 	config := &Config{}
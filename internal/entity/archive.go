@@ -0,0 +1,131 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// StatusAbandoned - the status of an archived game a player left before it was decided.
+const StatusAbandoned = "abandoned"
+
+// ArchivedGame - a game that has come to an end, as the history of its players shows it.
+// Players keep only their public IDs and marks, the private ones never leave the server.
+type ArchivedGame struct {
+	ID    string   `json:"id"`
+	Board []string `json:"board"`
+	GameSettings
+	SubBoards  []string  `json:"sub_boards,omitempty"`
+	Winner     string    `json:"winner"`
+	Status     string    `json:"status"`
+	Players    []*Player `json:"players"`
+	Type       string    `json:"type"`
+	Difficulty string    `json:"difficulty,omitempty"`
+	Strength   int       `json:"strength,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// DurationMs - the time from the start of the game to its end in milliseconds.
+	DurationMs int64  `json:"duration_ms"`
+	Moves      []Move `json:"moves"`
+	// FinishReason - FinishReasonTimeout for a game lost on time, empty for a game decided on the board.
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// HasPlayer - whether the player with the public ID played in the game.
+func (that *ArchivedGame) HasPlayer(publicID string) bool {
+	if publicID == "" {
+		return false
+	}
+
+	for _, player := range that.Players {
+		if player.PublicID == publicID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsArchivable - whether the game is worth keeping in the archive: it was decided or at least one move was made.
+func (that *Game) IsArchivable() bool {
+	return that.IsFinished() || len(that.Moves) > 0
+}
+
+// Archive - returns the record of the game that ended at the time.
+// Games stored before the start time was kept are measured from their first move.
+func (that *Game) Archive(finishedAt time.Time) *ArchivedGame {
+	archived := &ArchivedGame{
+		ID:           that.ID,
+		Board:        slices.Clone(that.Board),
+		GameSettings: that.GameSettings,
+		SubBoards:    slices.Clone(that.SubBoards),
+		Winner:       that.Winner,
+		Status:       that.Status,
+		Players:      make([]*Player, 0, len(that.Players)),
+		Type:         that.Type,
+		Difficulty:   that.Difficulty,
+		Strength:     that.Strength,
+		StartedAt:    finishedAt,
+		FinishedAt:   finishedAt,
+		Moves:        slices.Clone(that.Moves),
+		FinishReason: that.FinishReason,
+	}
+
+	if !that.IsFinished() {
+		archived.Status = StatusAbandoned
+	}
+
+	switch {
+	case that.StartedAt != nil:
+		archived.StartedAt = *that.StartedAt
+	case len(that.Moves) > 0:
+		archived.StartedAt = that.Moves[0].PlayedAt
+	}
+	archived.DurationMs = finishedAt.Sub(archived.StartedAt).Milliseconds()
+
+	for _, player := range that.Players {
+		archived.Players = append(archived.Players, &Player{PublicID: player.PublicID, Mark: player.Mark})
+	}
+
+	return archived
+}
+
+// Positions - replays the moves of the game by the rules of its variant and returns the position before the first
+// move followed by the position after every move. Every position holds the moves made up to it.
+// The last position of a game lost on time shows the result, no move on the board decided it.
+func (that *ArchivedGame) Positions() ([]*Game, error) {
+	game := NewGameWithSettings(that.ID, that.Type, that.GameSettings)
+	game.Status = StatusOngoing
+	game.Players = that.Players
+
+	positions := make([]*Game, 0, len(that.Moves)+1)
+	positions = append(positions, game.position(nil))
+
+	for i, move := range that.Moves {
+		if err := game.MakeMove(move.Mark, move.Cell, move.Piece); err != nil {
+			return nil, fmt.Errorf("failed to replay move %d: %w", move.Seq, err)
+		}
+
+		positions = append(positions, game.position(that.Moves[:i+1]))
+	}
+
+	if that.FinishReason == FinishReasonTimeout {
+		last := positions[len(positions)-1]
+		last.Winner = that.Winner
+		last.Status = StatusFinished
+		last.FinishReason = FinishReasonTimeout
+		last.Turn = ""
+		last.ActiveBoard = nil
+	}
+
+	return positions, nil
+}
+
+// position - returns a copy of the game as it stands after the moves.
+func (that *Game) position(moves []Move) *Game {
+	position := that.simulationCopy()
+	position.Players = that.Players
+	position.Moves = slices.Clone(moves)
+
+	return position
+}
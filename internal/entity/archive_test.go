@@ -0,0 +1,159 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGame_Archive(t *testing.T) {
+	t.Run("Finished game keeps its result, moves and public players", func(t *testing.T) {
+		// Given: a game won by X that started a minute before its end
+		game := NewGame("archive-finished", PrivateType)
+		game.Players = []*Player{
+			{ID: "player1", PublicID: "pub1", Mark: PlayerX, GameID: game.ID},
+			{ID: "player2", PublicID: "pub2", Mark: PlayerO, GameID: game.ID},
+		}
+		game.Start()
+		for _, cell := range []int{0, 3, 1, 4, 2} {
+			require.NoError(t, game.MakeTurn(game.Turn, cell))
+		}
+		finishedAt := game.StartedAt.Add(time.Minute)
+
+		// When: archiving the game
+		archived := game.Archive(finishedAt)
+
+		// Then: the record should hold the final state without the private IDs
+		require.True(t, game.IsArchivable())
+		assert.Equal(t, StatusFinished, archived.Status)
+		assert.Equal(t, PlayerX, archived.Winner)
+		assert.Equal(t, game.Board, archived.Board)
+		assert.Len(t, archived.Moves, 5)
+		assert.Equal(t, []*Player{{PublicID: "pub1", Mark: PlayerX}, {PublicID: "pub2", Mark: PlayerO}}, archived.Players)
+		assert.Equal(t, *game.StartedAt, archived.StartedAt)
+		assert.Equal(t, time.Minute.Milliseconds(), archived.DurationMs)
+	})
+
+	t.Run("Left game is abandoned and measured from its first move", func(t *testing.T) {
+		// Given: a game stored without a start time that a player left after a move
+		game := NewGame("archive-abandoned", PrivateType)
+		game.Status = StatusOngoing
+		require.NoError(t, game.MakeTurn(PlayerX, 4))
+		finishedAt := game.Moves[0].PlayedAt.Add(time.Second)
+
+		// When: archiving the game
+		archived := game.Archive(finishedAt)
+
+		// Then: the game should be abandoned and last from the first move
+		assert.Equal(t, StatusAbandoned, archived.Status)
+		assert.Empty(t, archived.Winner)
+		assert.Equal(t, time.Second.Milliseconds(), archived.DurationMs)
+	})
+
+	t.Run("Game without moves is not archived", func(t *testing.T) {
+		// Given: a game that has just started
+		game := NewGame("archive-empty", PrivateType)
+		game.Start()
+
+		// Then: there should be nothing to keep
+		assert.False(t, game.IsArchivable())
+	})
+}
+
+func TestArchivedGame_Positions(t *testing.T) {
+	t.Run("Replays every move of the game", func(t *testing.T) {
+		// Given: an archived ultimate game
+		game := NewGameWithSettings("replay-ultimate", PrivateType, GameSettings{Variant: UltimateVariant})
+		game.Start()
+		for _, cell := range []int{4*9 + 2, 2*9 + 4, 4*9 + 4} {
+			require.NoError(t, game.MakeTurn(game.Turn, cell))
+		}
+		archived := game.Archive(time.Now())
+
+		// When: rebuilding the positions
+		positions, err := archived.Positions()
+
+		// Then: there should be the empty board and a position after every move, the last one as the game ended
+		require.NoError(t, err)
+		require.Len(t, positions, 4)
+		assert.Equal(t, NewGameWithSettings("", PrivateType, GameSettings{Variant: UltimateVariant}).Board, positions[0].Board)
+		assert.Empty(t, positions[0].Moves)
+		assert.Equal(t, PlayerX, positions[1].Board[4*9+2])
+		assert.Len(t, positions[2].Moves, 2)
+		assert.Equal(t, game.Board, positions[3].Board)
+		assert.Equal(t, *game.ActiveBoard, *positions[3].ActiveBoard)
+		assert.Equal(t, game.Turn, positions[3].Turn)
+	})
+
+	t.Run("Replays the pieces of a wild game", func(t *testing.T) {
+		// Given: an archived wild game X won with a line of O
+		game := NewGameWithSettings("replay-wild", PrivateType, GameSettings{Variant: WildVariant})
+		game.Start()
+		require.NoError(t, game.MakeMove(PlayerX, 0, PlayerO))
+		require.NoError(t, game.MakeMove(PlayerO, 1, PlayerX))
+		require.NoError(t, game.MakeMove(PlayerX, 4, PlayerO))
+		require.NoError(t, game.MakeMove(PlayerO, 2, PlayerX))
+		require.NoError(t, game.MakeMove(PlayerX, 8, PlayerO))
+		require.Equal(t, PlayerX, game.Winner)
+		archived := game.Archive(time.Now())
+
+		// When: rebuilding the positions
+		positions, err := archived.Positions()
+
+		// Then: the last position should be the result of the game
+		require.NoError(t, err)
+		last := positions[len(positions)-1]
+		assert.Equal(t, game.Board, last.Board)
+		assert.Equal(t, game.Winner, last.Winner)
+		assert.Equal(t, StatusFinished, last.Status)
+	})
+
+	t.Run("Ends a game lost on time with its result", func(t *testing.T) {
+		// Given: an archived timed game O lost on time after a move each
+		game := NewGameWithSettings("replay-timeout", PrivateType, GameSettings{TimeControl: TimeControl{MoveLimitMs: 10000}})
+		game.Start()
+		require.NoError(t, game.MakeTurn(PlayerX, 4))
+		require.NoError(t, game.MakeTurn(PlayerO, 0))
+		require.NoError(t, game.MakeTurn(PlayerX, 8))
+		game.TimeOut()
+		archived := game.Archive(time.Now())
+
+		// When: rebuilding the positions
+		positions, err := archived.Positions()
+
+		// Then: the position after the last move should show X winning on time
+		require.NoError(t, err)
+		require.Len(t, positions, 4)
+		assert.Equal(t, StatusOngoing, positions[2].Status)
+		last := positions[3]
+		assert.Equal(t, PlayerX, last.Winner)
+		assert.Equal(t, StatusFinished, last.Status)
+		assert.Equal(t, FinishReasonTimeout, last.FinishReason)
+		assert.Equal(t, FinishReasonTimeout, archived.FinishReason)
+	})
+
+	t.Run("Fails on a move the rules do not allow", func(t *testing.T) {
+		// Given: an archived game with the same cell taken twice
+		archived := &ArchivedGame{
+			ID:    "replay-broken",
+			Board: make([]string, 9),
+			Moves: []Move{{Seq: 1, Mark: PlayerX, Cell: 4}, {Seq: 2, Mark: PlayerO, Cell: 4}},
+		}
+
+		// When: rebuilding the positions
+		_, err := archived.Positions()
+
+		// Then: the broken move should be reported
+		require.Error(t, err)
+	})
+}
+
+func TestArchivedGame_HasPlayer(t *testing.T) {
+	archived := &ArchivedGame{Players: []*Player{{PublicID: "pub1", Mark: PlayerX}, {Mark: PlayerO}}}
+
+	assert.True(t, archived.HasPlayer("pub1"), "player of the game")
+	assert.False(t, archived.HasPlayer("pub2"), "player of another game")
+	assert.False(t, archived.HasPlayer(""), "player without a public ID")
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const (
+	MinStrength = 1
+	MaxStrength = 10
+)
+
+var ErrInvalidStrength = errors.New("invalid bot strength")
+
+// BotEngine - picks the move of the bot: the cell and the piece put into it.
+type BotEngine interface {
+	SelectMove(game *Game, mark string, available []int) (int, string)
+}
+
+// ValidateStrength - checks the strength asked for a bot, zero stands for a named difficulty.
+func ValidateStrength(strength int) error {
+	if strength != 0 && (strength < MinStrength || strength > MaxStrength) {
+		return fmt.Errorf("%w: %d is out of %d..%d", ErrInvalidStrength, strength, MinStrength, MaxStrength)
+	}
+
+	return nil
+}
+
+// rulesEngine - the bot of the named difficulties, every variant plays it by its own rules.
+type rulesEngine struct{}
+
+func (rulesEngine) SelectMove(game *Game, mark string, available []int) (int, string) {
+	return game.rules().BotMove(game, mark, available)
+}
+
+// BotEngine - returns the engine the bot of the game plays with: MCTS when a strength is set,
+// the named difficulty otherwise.
+func (that *Game) BotEngine() BotEngine {
+	if that.Strength > 0 {
+		return NewMCTSEngine(that.Strength)
+	}
+
+	return rulesEngine{}
+}
+
+// legalPieces - returns the pieces the rules let the player put.
+func (that *Game) legalPieces(mark string) []string {
+	rules := that.rules()
+
+	pieces := []string{}
+	for _, piece := range []string{mark, opponentOf(mark)} {
+		if got, err := rules.Piece(mark, piece); err == nil && got == piece {
+			pieces = append(pieces, piece)
+		}
+	}
+
+	return pieces
+}
+
+// simulationCopy - returns a copy of the game moves can be tried on without changing the game.
+// It is the bare position: the board and the turn without the players, the moves or the clock.
+func (that *Game) simulationCopy() *Game {
+	game := &Game{
+		ID:           that.ID,
+		Board:        slices.Clone(that.Board),
+		GameSettings: that.GameSettings,
+		SubBoards:    slices.Clone(that.SubBoards),
+		Winner:       that.Winner,
+		Status:       that.Status,
+		Turn:         that.Turn,
+		Type:         that.Type,
+	}
+
+	if that.ActiveBoard != nil {
+		activeBoard := *that.ActiveBoard
+		game.ActiveBoard = &activeBoard
+	}
+
+	return game
+}
@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// FinishReasonTimeout - the player to move ran out of time and lost the game.
+	FinishReasonTimeout = "timeout"
+
+	MinMoveLimit = time.Second
+	MaxMoveLimit = 10 * time.Minute
+	MinBank      = 10 * time.Second
+	MaxBank      = time.Hour
+	MaxIncrement = time.Minute
+)
+
+// TimeControl - how long the players may think: MoveLimitMs for a single move, BankMs for the whole game,
+// and IncrementMs added to the bank of the player after every move of theirs. Zero values leave the limit out,
+// games without any of them are untimed.
+type TimeControl struct {
+	MoveLimitMs int64 `json:"move_limit_ms,omitempty"`
+	BankMs      int64 `json:"bank_ms,omitempty"`
+	IncrementMs int64 `json:"increment_ms,omitempty"`
+}
+
+// Clock - the clocks of a timed game, the server keeps them and the clients only show them.
+// Bank holds the time left in the bank of every mark as the current turn began, Deadline is when the player
+// to move runs out of time, nil once the game is over.
+type Clock struct {
+	Bank          map[string]int64 `json:"bank_ms,omitempty"`
+	TurnStartedAt time.Time        `json:"turn_started_at"`
+	Deadline      *time.Time       `json:"deadline,omitempty"`
+}
+
+// IsTimed - reports whether the time control limits the players at all.
+func (that TimeControl) IsTimed() bool {
+	return that.MoveLimitMs > 0 || that.BankMs > 0
+}
+
+// Validate - checks that the limits are in range, an increment is only given to a bank.
+func (that TimeControl) Validate() error {
+	moveLimit := time.Duration(that.MoveLimitMs) * time.Millisecond
+	bank := time.Duration(that.BankMs) * time.Millisecond
+	increment := time.Duration(that.IncrementMs) * time.Millisecond
+
+	if that.MoveLimitMs != 0 && (moveLimit < MinMoveLimit || moveLimit > MaxMoveLimit) {
+		return fmt.Errorf("%w: move limit %s is out of %s..%s", ErrInvalidSettings, moveLimit, MinMoveLimit, MaxMoveLimit)
+	}
+
+	if that.BankMs != 0 && (bank < MinBank || bank > MaxBank) {
+		return fmt.Errorf("%w: bank %s is out of %s..%s", ErrInvalidSettings, bank, MinBank, MaxBank)
+	}
+
+	if that.IncrementMs < 0 || increment > MaxIncrement {
+		return fmt.Errorf("%w: increment %s is out of 0s..%s", ErrInvalidSettings, increment, MaxIncrement)
+	}
+
+	if that.IncrementMs > 0 && that.BankMs == 0 {
+		return fmt.Errorf("%w: increment needs a bank", ErrInvalidSettings)
+	}
+
+	return nil
+}
+
+// IsTimedOut - reports whether the player to move has run out of time by now.
+func (that *Game) IsTimedOut(now time.Time) bool {
+	return that.IsOngoing() && that.Clock != nil && that.Clock.Deadline != nil && !now.Before(*that.Clock.Deadline)
+}
+
+// TimeOut - finishes the game as a loss of the player to move, who has run out of time.
+func (that *Game) TimeOut() {
+	if clock := that.Clock; clock != nil && clock.Deadline != nil {
+		if clock.Bank != nil {
+			spent := clock.Deadline.Sub(clock.TurnStartedAt).Milliseconds()
+			clock.Bank[that.Turn] = max(clock.Bank[that.Turn]-spent, 0)
+		}
+		clock.Deadline = nil
+	}
+
+	that.Winner = opponentOf(that.Turn)
+	that.Status = StatusFinished
+	that.FinishReason = FinishReasonTimeout
+	that.Turn = ""
+	that.ActiveBoard = nil
+}
+
+// startClock - starts the clock of the first turn, untimed games get no clock.
+func (that *Game) startClock(now time.Time) {
+	if !that.TimeControl.IsTimed() {
+		return
+	}
+
+	that.Clock = &Clock{TurnStartedAt: now}
+	if that.BankMs > 0 {
+		that.Clock.Bank = map[string]int64{PlayerX: that.BankMs, PlayerO: that.BankMs}
+	}
+
+	that.setDeadline()
+}
+
+// punchClock - charges the move the player with the mark made at the time to their bank and starts the next turn.
+func (that *Game) punchClock(playerMark string, at time.Time) {
+	clock := that.Clock
+	if clock == nil {
+		return
+	}
+
+	if clock.Bank != nil {
+		spent := at.Sub(clock.TurnStartedAt).Milliseconds()
+		clock.Bank[playerMark] = max(clock.Bank[playerMark]-spent, 0) + that.IncrementMs
+	}
+
+	clock.TurnStartedAt = at
+	that.setDeadline()
+}
+
+// setDeadline - sets when the player to move runs out of time: at the end of the move limit or of their bank,
+// whichever comes first. A finished game has no deadline.
+func (that *Game) setDeadline() {
+	clock := that.Clock
+	if that.IsFinished() {
+		clock.Deadline = nil
+		return
+	}
+
+	limit := that.MoveLimitMs
+	if bank, ok := clock.Bank[that.Turn]; ok && (limit == 0 || bank < limit) {
+		limit = bank
+	}
+
+	deadline := clock.TurnStartedAt.Add(time.Duration(limit) * time.Millisecond)
+	clock.Deadline = &deadline
+}
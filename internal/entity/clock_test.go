@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGame_Clock(t *testing.T) {
+	t.Run("Untimed game runs no clock", func(t *testing.T) {
+		// Given: a classic game without a time control
+		game := NewGame("clock-untimed", PrivateType)
+
+		// When: the game starts
+		game.Start()
+
+		// Then: there should be no clock and no way to run out of time
+		assert.Nil(t, game.Clock)
+		assert.False(t, game.IsTimedOut(time.Now().Add(time.Hour)))
+	})
+
+	t.Run("Charges the move to the bank and adds the increment", func(t *testing.T) {
+		// Given: a game with a minute in the bank and two seconds of increment, X has thought for ten seconds
+		game := NewGameWithSettings("clock-bank", PrivateType, GameSettings{TimeControl: TimeControl{BankMs: 60000, IncrementMs: 2000}})
+		game.Start()
+		require.NotNil(t, game.Clock)
+		game.Clock.TurnStartedAt = game.Clock.TurnStartedAt.Add(-10 * time.Second)
+
+		// When: X makes a move
+		require.NoError(t, game.MakeTurn(PlayerX, 4))
+
+		// Then: X should have the minute less ten seconds plus the increment, and O the whole bank till the deadline
+		assert.InDelta(t, 52000, game.Clock.Bank[PlayerX], 100)
+		assert.Equal(t, int64(60000), game.Clock.Bank[PlayerO])
+		assert.Equal(t, game.Moves[0].PlayedAt, game.Clock.TurnStartedAt)
+		assert.Equal(t, game.Clock.TurnStartedAt.Add(time.Minute), *game.Clock.Deadline)
+	})
+
+	t.Run("A played move is neither recorded nor timed", func(t *testing.T) {
+		// Given: a timed game X has thought in for ten seconds
+		game := NewGameWithSettings("clock-play", PrivateType, GameSettings{TimeControl: TimeControl{BankMs: 60000}})
+		game.Start()
+		game.Clock.TurnStartedAt = game.Clock.TurnStartedAt.Add(-10 * time.Second)
+		bank := maps.Clone(game.Clock.Bank)
+		turnStartedAt, deadline := game.Clock.TurnStartedAt, *game.Clock.Deadline
+
+		// When: the move is only played on the board, as the search of the bot does
+		piece, err := game.playMove(PlayerX, 4, "")
+
+		// Then: the board and the turn should change, the moves and the clock should not
+		require.NoError(t, err)
+		assert.Equal(t, PlayerX, piece)
+		assert.Equal(t, PlayerX, game.Board[4])
+		assert.Equal(t, PlayerO, game.Turn)
+		assert.Empty(t, game.Moves)
+		assert.Equal(t, bank, game.Clock.Bank)
+		assert.Equal(t, turnStartedAt, game.Clock.TurnStartedAt)
+		assert.Equal(t, deadline, *game.Clock.Deadline)
+	})
+
+	t.Run("The move limit comes before a longer bank", func(t *testing.T) {
+		// Given: a game with thirty seconds a move out of a five minute bank
+		game := NewGameWithSettings("clock-limit", PrivateType, GameSettings{TimeControl: TimeControl{MoveLimitMs: 30000, BankMs: 300000}})
+
+		// When: the game starts
+		game.Start()
+
+		// Then: X should run out of time at the end of the move limit
+		assert.Equal(t, game.StartedAt.Add(30*time.Second), *game.Clock.Deadline)
+		assert.False(t, game.IsTimedOut(game.StartedAt.Add(29*time.Second)))
+		assert.True(t, game.IsTimedOut(game.StartedAt.Add(30*time.Second)))
+	})
+
+	t.Run("Running out of time loses the game", func(t *testing.T) {
+		// Given: a game X has run out of the bank in
+		game := NewGameWithSettings("clock-flag", PrivateType, GameSettings{TimeControl: TimeControl{BankMs: 10000}})
+		game.Start()
+		require.True(t, game.IsTimedOut(game.Clock.Deadline.Add(time.Millisecond)))
+
+		// When: the game is timed out
+		game.TimeOut()
+
+		// Then: O should win on time and the clock should stop with the bank of X empty
+		assert.Equal(t, PlayerO, game.Winner)
+		assert.Equal(t, StatusFinished, game.Status)
+		assert.Equal(t, FinishReasonTimeout, game.FinishReason)
+		assert.Empty(t, game.Turn)
+		assert.Zero(t, game.Clock.Bank[PlayerX])
+		assert.Nil(t, game.Clock.Deadline)
+		assert.False(t, game.IsTimedOut(time.Now().Add(time.Hour)))
+	})
+
+	t.Run("Clock stops when the game is decided on the board", func(t *testing.T) {
+		// Given: a timed game X is about to win
+		game := NewGameWithSettings("clock-finished", PrivateType, GameSettings{TimeControl: TimeControl{MoveLimitMs: 10000}})
+		game.Start()
+		for _, cell := range []int{0, 3, 1, 4} {
+			require.NoError(t, game.MakeTurn(game.Turn, cell))
+		}
+
+		// When: X completes the line
+		require.NoError(t, game.MakeTurn(PlayerX, 2))
+
+		// Then: nobody should be on the clock any longer
+		assert.True(t, game.IsFinished())
+		assert.Nil(t, game.Clock.Deadline)
+		assert.Empty(t, game.FinishReason)
+	})
+}
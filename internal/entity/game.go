@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
 )
@@ -18,6 +19,9 @@ const (
 	PlayerTie = "-"
 
 	EmptyCell = ""
+
+	// offBoard is what markAt returns for cells outside the board, it never equals a mark or an empty cell.
+	offBoard = "#"
 )
 
 const (
@@ -25,6 +29,16 @@ const (
 	PrivateType = "private"
 	WithBotType = "bot"
 
+	// The variants are the rules a game of any type is played by, see Rules.
+	ClassicVariant  = ""
+	UltimateVariant = "ultimate"
+	// MisereVariant - making the line loses.
+	MisereVariant = "misere"
+	// WildVariant - either player may put X or O, making a line of either wins.
+	WildVariant = "wild"
+	// NotaktoVariant - both players put X, making the line loses.
+	NotaktoVariant = "notakto"
+
 	EasyDifficulty       = "easy"
 	HardDifficulty       = "hard"
 	InvincibleDifficulty = "invincible"
@@ -35,45 +49,110 @@ var (
 	ErrUnknownGameStatus = errors.New("unknown game status")
 	ErrBotNotFound       = errors.New("bot not found")
 	ErrNoAvailableMoves  = errors.New("no available moves")
-
-	WinCombos = [][3]int{
-		{0, 1, 2},
-		{3, 4, 5},
-		{6, 7, 8},
-		{0, 3, 6},
-		{1, 4, 7},
-		{2, 5, 8},
-		{0, 4, 8},
-		{2, 4, 6},
-	}
 )
 
+// lineDirections are the row and column steps of the lines marks can be put in a row along:
+// horizontal, vertical, diagonal and anti-diagonal.
+var lineDirections = [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+// Game - the board is stored row by row, cell r*Cols+c is the cell in row r and column c.
+// Ultimate games also keep the result of every sub-board and the sub-board the next move must be made on,
+// nil when the player may choose any undecided one. Games with a bot play it by the difficulty,
+// or by the strength of the MCTS engine when one is set. Every move made is kept in Moves in order.
+// Timed games run a Clock from the start, FinishReason tells a game lost on time from one decided on the board.
 type Game struct {
-	ID         string    `json:"id"`
-	Board      [9]string `json:"board"`
-	Winner     string    `json:"winner"`
-	Status     string    `json:"status"`
-	Turn       string    `json:"player_turn"`
-	Players    []*Player `json:"players,omitempty"`
-	Type       string    `json:"type,omitempty"`
-	Difficulty string    `json:"difficulty,omitempty"`
+	ID    string   `json:"id"`
+	Board []string `json:"board"`
+	GameSettings
+	SubBoards   []string  `json:"sub_boards,omitempty"`
+	ActiveBoard *int      `json:"active_board,omitempty"`
+	Winner      string    `json:"winner"`
+	Status      string    `json:"status"`
+	Turn        string    `json:"player_turn"`
+	Players     []*Player `json:"players,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Difficulty  string    `json:"difficulty,omitempty"`
+	Strength    int       `json:"strength,omitempty"`
+	Moves       []Move    `json:"moves,omitempty"`
+	// StartedAt - when the second player joined, the archive measures the duration of the game from it.
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	Clock        *Clock     `json:"clock,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"`
 }
 
+// NewGame - creates a classic 3x3 game.
 func NewGame(id, gameType string) *Game {
-	return &Game{
-		ID:     id,
-		Board:  [9]string{EmptyCell, EmptyCell, EmptyCell, EmptyCell, EmptyCell, EmptyCell, EmptyCell, EmptyCell, EmptyCell},
-		Turn:   PlayerX,
-		Status: StatusWaiting,
-		Type:   gameType,
+	return NewGameWithSettings(id, gameType, DefaultGameSettings())
+}
+
+// NewGameWithSettings - creates a game with the board of the settings, the settings must be valid.
+// Classic games keep the settings empty, so they are sent and stored in the same shape as before boards could grow.
+func NewGameWithSettings(id, gameType string, settings GameSettings) *Game {
+	settings = settings.WithDefaults()
+
+	board := make([]string, settings.Cells())
+	if settings.IsClassic() {
+		settings = GameSettings{}
 	}
+
+	game := &Game{
+		ID:           id,
+		Board:        board,
+		GameSettings: settings,
+		Turn:         PlayerX,
+		Status:       StatusWaiting,
+		Type:         gameType,
+	}
+
+	if game.IsUltimate() {
+		game.SubBoards = make([]string, ultimateSubBoards)
+	}
+
+	return game
+}
+
+// GameTerms - what a game was set up with: the board, the clock and the strength of the bot.
+type GameTerms struct {
+	GameSettings
+	Difficulty string `json:"difficulty,omitempty"`
+	Strength   int    `json:"strength,omitempty"`
 }
 
+// Terms - returns the terms of the game, a rematch starts a new game on them.
+func (that *Game) Terms() GameTerms {
+	return GameTerms{
+		GameSettings: that.GameSettings,
+		Difficulty:   that.Difficulty,
+		Strength:     that.Strength,
+	}
+}
+
+// Settings - returns the settings of the game, games stored without settings are classic games.
+func (that *Game) Settings() GameSettings {
+	return that.GameSettings.WithDefaults()
+}
+
+// DetermineGameResult - returns the result by the rules of the game, the last move is taken to be made
+// by the player whose turn it is not.
 func (that *Game) DetermineGameResult() string {
-	for _, combo := range WinCombos {
-		a, b, c := that.Board[combo[0]], that.Board[combo[1]], that.Board[combo[2]]
-		if a != EmptyCell && a == b && b == c {
-			return a
+	return that.rules().Result(that, opponentOf(that.Turn))
+}
+
+// lineResult - returns the mark that has WinLength in a row, PlayerTie when the board is full,
+// or an empty string while the game goes on.
+func (that *Game) lineResult() string {
+	settings := that.Settings()
+
+	for cell, mark := range that.Board {
+		if mark == EmptyCell {
+			continue
+		}
+
+		// every line is found from its first cell, so only the forward direction has to be followed
+		for _, direction := range lineDirections {
+			if that.countInDirection(settings, cell, mark, direction[0], direction[1]) >= settings.WinLength-1 {
+				return mark
+			}
 		}
 	}
 
@@ -105,20 +184,53 @@ func (that *Game) UpdateGameState() {
 	}
 }
 
+// MakeTurn - makes the move of the player with the piece of their own mark.
 func (that *Game) MakeTurn(playerMark string, cell int) error {
+	return that.MakeMove(playerMark, cell, "")
+}
+
+// MakeMove - makes the move of the player with the piece, an empty piece is the piece the rules give the player.
+// The move is recorded and the clock of the player is punched.
+func (that *Game) MakeMove(playerMark string, cell int, piece string) error {
+	piece, err := that.playMove(playerMark, cell, piece)
+	if err != nil {
+		return err
+	}
+
+	move := that.recordMove(playerMark, cell, piece)
+	that.punchClock(playerMark, move.PlayedAt)
+
+	return nil
+}
+
+// playMove - puts the piece on the board and passes the turn, and returns the piece put.
+// Nothing is recorded and no clock runs, so the search of the bot plays its imagined moves with it.
+func (that *Game) playMove(playerMark string, cell int, piece string) (string, error) {
 	if cell < 0 || cell >= len(that.Board) {
-		return fmt.Errorf("%w: cell %d", ErrInvalidCell, cell)
+		return "", fmt.Errorf("%w: cell %d", ErrInvalidCell, cell)
 	}
 
 	if that.Turn != playerMark {
-		return apperror.ErrNotYourTurn
+		return "", apperror.ErrNotYourTurn
 	}
 
 	if that.Board[cell] != EmptyCell {
-		return apperror.ErrCellOccupied
+		return "", apperror.ErrCellOccupied
+	}
+
+	rules := that.rules()
+
+	piece, err := rules.Piece(playerMark, piece)
+	if err != nil {
+		return "", err
+	}
+
+	if err = rules.CheckMove(that, cell); err != nil {
+		return "", err
 	}
 
-	that.Board[cell] = playerMark
+	that.Board[cell] = piece
+	rules.Played(that, cell)
 
 	// It's simple logic for a game changing move
 	if that.Turn == PlayerX {
@@ -129,7 +241,20 @@ func (that *Game) MakeTurn(playerMark string, cell int) error {
 
 	that.UpdateGameState()
 
-	return nil
+	if that.IsFinished() {
+		that.ActiveBoard = nil
+	}
+
+	return piece, nil
+}
+
+// Start - puts the game in play once both players are in it, the clock of a timed game starts running.
+func (that *Game) Start() {
+	startedAt := time.Now().UTC()
+
+	that.Status = StatusOngoing
+	that.StartedAt = &startedAt
+	that.startClock(startedAt)
 }
 
 func (that *Game) IsFinished() bool {
@@ -173,23 +298,33 @@ func (that *Game) GetRandomMarks() (string, string) {
 }
 
 func (that *Game) BotMakeTurn() error {
+	chosenCell, piece, err := that.SelectBotMove()
+	if err != nil {
+		return err
+	}
+
+	if err = that.MakeMove(that.GetBotPlayer().Mark, chosenCell, piece); err != nil {
+		return fmt.Errorf("bot failed to make turn: %w", err)
+	}
+
+	return nil
+}
+
+// SelectBotMove - returns the cell and the piece the bot of the game would play, the game is left as it is.
+func (that *Game) SelectBotMove() (int, string, error) {
 	botPlayer := that.GetBotPlayer()
 	if botPlayer == nil {
-		return ErrBotNotFound
+		return 0, "", ErrBotNotFound
 	}
 
 	availableCells := that.getAvailableCells()
 	if len(availableCells) == 0 {
-		return ErrNoAvailableMoves
+		return 0, "", ErrNoAvailableMoves
 	}
 
-	chosenCell := that.selectBotMove(botPlayer.Mark, availableCells)
+	chosenCell, piece := that.BotEngine().SelectMove(that, botPlayer.Mark, availableCells)
 
-	if err := that.MakeTurn(botPlayer.Mark, chosenCell); err != nil {
-		return fmt.Errorf("bot failed to make turn: %w", err)
-	}
-
-	return nil
+	return chosenCell, piece, nil
 }
 
 func (that *Game) GetBotPlayer() *Player {
@@ -202,22 +337,27 @@ func (that *Game) GetBotPlayer() *Player {
 }
 
 func (that *Game) getAvailableCells() []int {
+	rules := that.rules()
+
 	availableCells := []int{}
 	for i, cell := range that.Board {
-		if cell == EmptyCell {
+		if cell == EmptyCell && rules.CheckMove(that, i) == nil {
 			availableCells = append(availableCells, i)
 		}
 	}
 	return availableCells
 }
 
-func (that *Game) selectBotMove(mark string, available []int) int {
-	difficulty := that.Difficulty
-	if difficulty == "" {
-		difficulty = EasyDifficulty
+// botDifficulty - returns the difficulty of the bot, games created without one are played by the easy bot.
+func (that *Game) botDifficulty() string {
+	if that.Difficulty == "" {
+		return EasyDifficulty
 	}
+	return that.Difficulty
+}
 
-	switch difficulty {
+func (that *Game) selectBotMove(mark string, available []int) int {
+	switch that.botDifficulty() {
 	case EasyDifficulty:
 		return that.easyStrategy(available)
 	case HardDifficulty:
@@ -239,25 +379,7 @@ func (that *Game) selectBotMove(mark string, available []int) int {
 			return winMove
 		}
 
-		oppMark := PlayerO
-		if mark == PlayerO {
-			oppMark = PlayerX
-		}
-		if blockMove := that.findWinningMove(oppMark); blockMove != -1 {
-			return blockMove
-		}
-
-		if that.Board[4] == EmptyCell {
-			return 4 // center
-		}
-
-		for _, corner := range []int{0, 2, 6, 8} {
-			if that.Board[corner] == EmptyCell {
-				return corner
-			}
-		}
-
-		return that.easyStrategy(available)
+		return that.minimaxMove(mark, available)
 	default:
 		return that.easyStrategy(available)
 	}
@@ -267,18 +389,111 @@ func (that *Game) easyStrategy(available []int) int {
 	return available[rand.Intn(len(available))] //nolint:gosec // it`s ok
 }
 
-func (that *Game) findWinningMove(mark string) int {
-	for _, combo := range WinCombos {
-		a, b, c := that.Board[combo[0]], that.Board[combo[1]], that.Board[combo[2]]
-		if a == mark && b == mark && c == EmptyCell {
-			return combo[2]
-		}
-		if a == mark && c == mark && b == EmptyCell {
-			return combo[1]
+// positionalScore - values the empty cell by how much it extends the longest own lines and cuts the longest lines
+// of the opponent. Lines that can no longer grow to WinLength are worth nothing.
+func (that *Game) positionalScore(settings GameSettings, cell int, mark, oppMark string) int {
+	score := 0
+	for _, direction := range lineDirections {
+		score += that.lineScore(settings, cell, mark, direction)
+		score += that.lineScore(settings, cell, oppMark, direction) * 9 / 10
+	}
+
+	return score
+}
+
+// lineScore - values the line of the mark through the empty cell along the direction, longer lines are worth
+// an order of magnitude more.
+func (that *Game) lineScore(settings GameSettings, cell int, mark string, direction [2]int) int {
+	row, col := cell/settings.Cols, cell%settings.Cols
+
+	marks := 1 + that.countInDirection(settings, cell, mark, direction[0], direction[1]) +
+		that.countInDirection(settings, cell, mark, -direction[0], -direction[1])
+
+	// the room counts the cells the line could still take: own marks and empty cells
+	room := 1
+	for _, sign := range []int{1, -1} {
+		r, c := row+sign*direction[0], col+sign*direction[1]
+		for at := that.markAt(settings, r, c); (at == mark || at == EmptyCell) && room < settings.WinLength; at = that.markAt(settings, r, c) {
+			room++
+			r, c = r+sign*direction[0], c+sign*direction[1]
 		}
-		if b == mark && c == mark && a == EmptyCell {
-			return combo[0]
+	}
+
+	if room < settings.WinLength {
+		return 0
+	}
+
+	score := 1
+	for range marks - 1 {
+		score *= 10
+	}
+
+	return score
+}
+
+// centerDistance - the doubled taxicab distance from the cell to the center of the board.
+func centerDistance(settings GameSettings, cell int) int {
+	row, col := cell/settings.Cols, cell%settings.Cols
+	return abs(2*row-(settings.Rows-1)) + abs(2*col-(settings.Cols-1))
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// findWinningMove - returns the first empty cell that completes a line of the mark, -1 when there is none.
+func (that *Game) findWinningMove(mark string) int {
+	for _, cell := range that.getAvailableCells() {
+		if that.completesLine(cell, mark) {
+			return cell
 		}
 	}
 	return -1
 }
+
+// completesLine - reports whether putting the mark into the cell makes WinLength marks in a row.
+func (that *Game) completesLine(cell int, mark string) bool {
+	settings := that.Settings()
+
+	for _, direction := range lineDirections {
+		forward := that.countInDirection(settings, cell, mark, direction[0], direction[1])
+		backward := that.countInDirection(settings, cell, mark, -direction[0], -direction[1])
+
+		if 1+forward+backward >= settings.WinLength {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countInDirection - counts the marks in a row next to the cell, walking from it by the row and column steps.
+// The cell itself is not counted.
+func (that *Game) countInDirection(settings GameSettings, cell int, mark string, rowStep, colStep int) int {
+	count := 0
+
+	row, col := cell/settings.Cols+rowStep, cell%settings.Cols+colStep
+	for that.markAt(settings, row, col) == mark {
+		count++
+		row, col = row+rowStep, col+colStep
+	}
+
+	return count
+}
+
+// markAt - returns the mark in the row and column, cells off the board hold nothing that can be matched.
+func (that *Game) markAt(settings GameSettings, row, col int) string {
+	if row < 0 || row >= settings.Rows || col < 0 || col >= settings.Cols {
+		return offBoard
+	}
+
+	cell := row*settings.Cols + col
+	if cell >= len(that.Board) {
+		return offBoard
+	}
+
+	return that.Board[cell]
+}
@@ -95,7 +95,7 @@ func TestGame_DetermineGameResult(t *testing.T) {
 	t.Run("Returns PlayerX when Player X wins", func(t *testing.T) {
 		// Given: a game where Player X has a winning combination
 		game := &Game{
-			Board: [9]string{
+			Board: []string{
 				PlayerX, PlayerX, PlayerX,
 				EmptyCell, EmptyCell, EmptyCell,
 				EmptyCell, EmptyCell, EmptyCell,
@@ -112,7 +112,7 @@ func TestGame_DetermineGameResult(t *testing.T) {
 	t.Run("Returns PlayerO when Player O wins", func(t *testing.T) {
 		// Given: a game where Player O has a winning combination
 		game := &Game{
-			Board: [9]string{
+			Board: []string{
 				PlayerO, PlayerO, PlayerO,
 				EmptyCell, EmptyCell, EmptyCell,
 				EmptyCell, EmptyCell, EmptyCell,
@@ -129,7 +129,7 @@ func TestGame_DetermineGameResult(t *testing.T) {
 	t.Run("Returns PlayerTie when the game is a tie", func(t *testing.T) {
 		// Given: a game that ended in a tie
 		game := &Game{
-			Board: [9]string{
+			Board: []string{
 				PlayerX, PlayerO, PlayerX,
 				PlayerO, PlayerX, PlayerO,
 				PlayerO, PlayerX, PlayerO,
@@ -146,7 +146,7 @@ func TestGame_DetermineGameResult(t *testing.T) {
 	t.Run("Returns EmptyCell when the game is ongoing", func(t *testing.T) {
 		// Given: a game that is still ongoing
 		game := &Game{
-			Board: [9]string{
+			Board: []string{
 				PlayerX, PlayerO, EmptyCell,
 				EmptyCell, PlayerX, EmptyCell,
 				EmptyCell, EmptyCell, PlayerO,
@@ -165,7 +165,7 @@ func TestGame_UpdateGameState(t *testing.T) {
 	t.Run("Updates game state when Player X wins", func(t *testing.T) {
 		// Given: a game where Player X has a winning combination
 		game := &Game{
-			Board: [9]string{
+			Board: []string{
 				PlayerX, PlayerX, PlayerX,
 				EmptyCell, EmptyCell, EmptyCell,
 				EmptyCell, EmptyCell, EmptyCell,
@@ -186,7 +186,7 @@ func TestGame_UpdateGameState(t *testing.T) {
 	t.Run("Updates game state when the game is a tie", func(t *testing.T) {
 		// Given: a game that ended in a tie
 		game := &Game{
-			Board: [9]string{
+			Board: []string{
 				PlayerX, PlayerO, PlayerX,
 				PlayerO, PlayerX, PlayerO,
 				PlayerO, PlayerX, PlayerO,
@@ -207,7 +207,7 @@ func TestGame_UpdateGameState(t *testing.T) {
 	t.Run("Game remains ongoing when there is no winner or tie", func(t *testing.T) {
 		// Given: a game that is still ongoing
 		game := &Game{
-			Board: [9]string{
+			Board: []string{
 				PlayerX, PlayerO, EmptyCell,
 				EmptyCell, PlayerX, EmptyCell,
 				EmptyCell, EmptyCell, PlayerO,
@@ -237,14 +237,16 @@ func TestGame_MakeTurn(t *testing.T) {
 		require.NoError(t, err)
 
 		// Then: The game state should reflect the turn and player turn should switch
+		require.Len(t, game.Moves, 1)
 		expectedGame := &Game{
 			ID:      "123",
-			Board:   [9]string{PlayerX, "", "", "", "", "", "", "", ""},
+			Board:   []string{PlayerX, "", "", "", "", "", "", "", ""},
 			Turn:    PlayerO,
 			Winner:  "",
 			Status:  StatusOngoing,
 			Players: nil,
 			Type:    PrivateType,
+			Moves:   []Move{{Seq: 1, Mark: PlayerX, Cell: 0, PlayedAt: game.Moves[0].PlayedAt}},
 		}
 
 		require.Equal(t, expectedGame, game)
@@ -264,14 +266,16 @@ func TestGame_MakeTurn(t *testing.T) {
 		require.ErrorIs(t, err, apperror.ErrCellOccupied)
 
 		// And: The game state should remain unchanged
+		require.Len(t, game.Moves, 1)
 		expectedGame := &Game{
 			ID:      "123",
-			Board:   [9]string{PlayerX, "", "", "", "", "", "", "", ""},
+			Board:   []string{PlayerX, "", "", "", "", "", "", "", ""},
 			Turn:    PlayerO,
 			Winner:  "",
 			Status:  StatusOngoing,
 			Players: nil,
 			Type:    PrivateType,
+			Moves:   []Move{{Seq: 1, Mark: PlayerX, Cell: 0, PlayedAt: game.Moves[0].PlayedAt}},
 		}
 
 		require.Equal(t, expectedGame, game)
@@ -291,7 +295,7 @@ func TestGame_MakeTurn(t *testing.T) {
 		// And: The game state should remain unchanged
 		expectedGame := &Game{
 			ID:      "123",
-			Board:   [9]string{"", "", "", "", "", "", "", "", ""},
+			Board:   []string{"", "", "", "", "", "", "", "", ""},
 			Turn:    PlayerX,
 			Winner:  "",
 			Status:  StatusOngoing,
@@ -373,7 +377,7 @@ func TestGame_BotMakeTurn_HardDifficulty(t *testing.T) {
 		addBotPlayer(game)
 
 		// Set up board where bot can win by placing at cell 5
-		game.Board = [9]string{
+		game.Board = []string{
 			PlayerX, PlayerX, EmptyCell,
 			PlayerO, PlayerO, EmptyCell,
 			EmptyCell, EmptyCell, EmptyCell,
@@ -402,7 +406,7 @@ func TestGame_BotMakeTurn_HardDifficulty(t *testing.T) {
 		addBotPlayer(game)
 
 		// Set up board where player can win by placing at cell 2
-		game.Board = [9]string{
+		game.Board = []string{
 			PlayerX, PlayerX, EmptyCell,
 			PlayerO, EmptyCell, EmptyCell,
 			EmptyCell, EmptyCell, EmptyCell,
@@ -432,7 +436,7 @@ func TestGame_BotMakeTurn_InvincibleDifficulty(t *testing.T) {
 		addBotPlayer(game)
 
 		// Set up board with center available
-		game.Board = [9]string{
+		game.Board = []string{
 			PlayerX, EmptyCell, EmptyCell,
 			EmptyCell, EmptyCell, EmptyCell,
 			EmptyCell, EmptyCell, EmptyCell,
@@ -459,10 +463,10 @@ func TestGame_BotMakeTurn_InvincibleDifficulty(t *testing.T) {
 		// Add bot player with mark PlayerO
 		addBotPlayer(game)
 
-		// Set up board with center occupied by bot
-		game.Board = [9]string{
-			PlayerX, EmptyCell, EmptyCell,
-			EmptyCell, PlayerO, EmptyCell,
+		// Set up board with center occupied by player, only a corner keeps the draw
+		game.Board = []string{
+			EmptyCell, EmptyCell, EmptyCell,
+			EmptyCell, PlayerX, EmptyCell,
 			EmptyCell, EmptyCell, EmptyCell,
 		}
 		game.Turn = PlayerO
@@ -496,7 +500,7 @@ func TestGame_BotMakeTurn_InvincibleDifficulty(t *testing.T) {
 		addBotPlayer(game)
 
 		// Set up board where player is trying to create a fork
-		game.Board = [9]string{
+		game.Board = []string{
 			PlayerX, EmptyCell, EmptyCell,
 			EmptyCell, PlayerO, EmptyCell,
 			EmptyCell, EmptyCell, PlayerX,
@@ -507,8 +511,12 @@ func TestGame_BotMakeTurn_InvincibleDifficulty(t *testing.T) {
 		err := game.BotMakeTurn()
 		require.NoError(t, err)
 
-		// Verify bot took cell 2 to block the fork
-		assert.Equal(t, PlayerO, game.Board[2], "Bot should have placed at cell 2 to prevent fork")
+		// Verify bot took an edge: a corner forces the player to block in the other corner, which makes the fork
+		edgeTaken := false
+		for _, cell := range []int{1, 3, 5, 7} {
+			edgeTaken = edgeTaken || game.Board[cell] == PlayerO
+		}
+		assert.True(t, edgeTaken, "Bot should have taken an edge cell to prevent fork")
 		assert.Equal(t, StatusOngoing, game.Status, "Game should continue after bot's move")
 	})
 
@@ -524,7 +532,7 @@ func TestGame_BotMakeTurn_InvincibleDifficulty(t *testing.T) {
 		addBotPlayer(game)
 
 		// Set up board where bot can win by placing at cell 2
-		game.Board = [9]string{
+		game.Board = []string{
 			PlayerO, PlayerO, EmptyCell,
 			PlayerX, PlayerX, EmptyCell,
 			EmptyCell, EmptyCell, EmptyCell,
@@ -555,7 +563,7 @@ func TestGame_BotMakeTurn_EasyDifficulty_FullBoard(t *testing.T) {
 		addBotPlayer(game)
 
 		// Set up board with one empty cell at position 8
-		game.Board = [9]string{
+		game.Board = []string{
 			PlayerX, PlayerO, PlayerX,
 			PlayerO, PlayerX, PlayerO,
 			PlayerO, PlayerX, EmptyCell,
@@ -0,0 +1,160 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// mctsExploration - the exploration constant of UCT, √2 balances trying new moves against the best known ones.
+	mctsExploration = math.Sqrt2
+	// mctsBasePlayouts - the playouts of strength 1, every next strength doubles them.
+	mctsBasePlayouts = 25
+	// mctsTimePerStrength - the time budget grows by that much with every strength.
+	mctsTimePerStrength = 100 * time.Millisecond
+)
+
+// MCTSEngine - a Monte Carlo tree search bot, it plays random games from the position and picks the move
+// that has won the most of them. The search stops at whichever budget runs out first. The engine knows nothing
+// about the variant but what the rules answer, so it plays every variant on every board.
+type MCTSEngine struct {
+	Playouts   int
+	TimeBudget time.Duration
+}
+
+// NewMCTSEngine - returns the engine of the strength from MinStrength to MaxStrength: from 25 playouts in 100ms
+// to 12800 playouts in a second.
+func NewMCTSEngine(strength int) MCTSEngine {
+	strength = min(max(strength, MinStrength), MaxStrength)
+
+	return MCTSEngine{
+		Playouts:   mctsBasePlayouts << (strength - 1),
+		TimeBudget: time.Duration(strength) * mctsTimePerStrength,
+	}
+}
+
+type mctsMove struct {
+	cell  int
+	piece string
+}
+
+// mctsNode - a position of the search tree, the score counts the playouts won by the mover of the move that led
+// to it, ties count a half.
+type mctsNode struct {
+	parent   *mctsNode
+	move     mctsMove
+	mover    string
+	children []*mctsNode
+	untried  []mctsMove
+	visits   int
+	score    float64
+}
+
+func (that MCTSEngine) SelectMove(game *Game, mark string, available []int) (int, string) {
+	root := &mctsNode{untried: game.legalMoves(mark, available)}
+
+	deadline := time.Now().Add(that.TimeBudget)
+	for playout := 0; playout < that.Playouts && time.Now().Before(deadline); playout++ {
+		if err := that.playout(root, game.simulationCopy()); err != nil {
+			break
+		}
+	}
+
+	var best *mctsNode
+	for _, child := range root.children {
+		if best == nil || child.visits > best.visits {
+			best = child
+		}
+	}
+
+	if best == nil {
+		move := root.untried[rand.Intn(len(root.untried))] //nolint:gosec // it`s ok
+		return move.cell, move.piece
+	}
+
+	return best.move.cell, best.move.piece
+}
+
+// playout - walks the tree down to a position with untried moves, tries one of them, plays the game to the end
+// at random and counts the result in every position on the way.
+func (that MCTSEngine) playout(root *mctsNode, state *Game) error {
+	node := root
+	for len(node.untried) == 0 && len(node.children) > 0 {
+		node = node.bestChild()
+		if _, err := state.playMove(state.Turn, node.move.cell, node.move.piece); err != nil {
+			return fmt.Errorf("failed to replay move: %w", err)
+		}
+	}
+
+	if len(node.untried) > 0 {
+		i := rand.Intn(len(node.untried)) //nolint:gosec // it`s ok
+		move := node.untried[i]
+		node.untried[i] = node.untried[len(node.untried)-1]
+		node.untried = node.untried[:len(node.untried)-1]
+
+		child := &mctsNode{parent: node, move: move, mover: state.Turn}
+		if _, err := state.playMove(state.Turn, move.cell, move.piece); err != nil {
+			return fmt.Errorf("failed to expand move: %w", err)
+		}
+		if !state.IsFinished() {
+			child.untried = state.legalMoves(state.Turn, state.getAvailableCells())
+		}
+
+		node.children = append(node.children, child)
+		node = child
+	}
+
+	for !state.IsFinished() {
+		moves := state.legalMoves(state.Turn, state.getAvailableCells())
+		move := moves[rand.Intn(len(moves))] //nolint:gosec // it`s ok
+		if _, err := state.playMove(state.Turn, move.cell, move.piece); err != nil {
+			return fmt.Errorf("failed to simulate move: %w", err)
+		}
+	}
+
+	for ; node != nil; node = node.parent {
+		node.visits++
+		switch state.Winner {
+		case node.mover:
+			node.score++
+		case PlayerTie:
+			node.score += 0.5
+		}
+	}
+
+	return nil
+}
+
+// bestChild - returns the child with the highest upper confidence bound.
+func (that *mctsNode) bestChild() *mctsNode {
+	logVisits := math.Log(float64(that.visits))
+
+	var best *mctsNode
+	bestValue := 0.0
+	for _, child := range that.children {
+		visits := float64(child.visits)
+		value := child.score/visits + mctsExploration*math.Sqrt(logVisits/visits)
+
+		if best == nil || value > bestValue {
+			best, bestValue = child, value
+		}
+	}
+
+	return best
+}
+
+// legalMoves - returns every cell of the available ones with every piece the player may put into it.
+func (that *Game) legalMoves(mark string, available []int) []mctsMove {
+	pieces := that.legalPieces(mark)
+
+	moves := make([]mctsMove, 0, len(available)*len(pieces))
+	for _, cell := range available {
+		for _, piece := range pieces {
+			moves = append(moves, mctsMove{cell: cell, piece: piece})
+		}
+	}
+
+	return moves
+}
@@ -0,0 +1,178 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStrengthGame(id string, strength int, settings GameSettings) *Game {
+	game := NewGameWithSettings(id, WithBotType, settings)
+	game.Strength = strength
+	game.Status = StatusOngoing
+	game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+	addBotPlayer(game)
+
+	return game
+}
+
+func TestNewMCTSEngine(t *testing.T) {
+	// When: creating the engines of every strength
+	previous := NewMCTSEngine(MinStrength)
+
+	// Then: every next strength should search more and longer, out of range strengths should be clamped
+	for strength := MinStrength + 1; strength <= MaxStrength; strength++ {
+		engine := NewMCTSEngine(strength)
+		assert.Greater(t, engine.Playouts, previous.Playouts)
+		assert.Greater(t, engine.TimeBudget, previous.TimeBudget)
+		previous = engine
+	}
+
+	assert.Equal(t, NewMCTSEngine(MaxStrength), NewMCTSEngine(MaxStrength+5))
+	assert.Equal(t, NewMCTSEngine(MinStrength), NewMCTSEngine(-1))
+}
+
+func TestValidateStrength(t *testing.T) {
+	assert.NoError(t, ValidateStrength(0))
+	assert.NoError(t, ValidateStrength(MinStrength))
+	assert.NoError(t, ValidateStrength(MaxStrength))
+	assert.ErrorIs(t, ValidateStrength(MaxStrength+1), ErrInvalidStrength)
+	assert.ErrorIs(t, ValidateStrength(-1), ErrInvalidStrength)
+}
+
+func TestGame_BotEngine(t *testing.T) {
+	// Given: a game with a named difficulty and a game with a strength
+	named := newStrengthGame("engine-named", 0, DefaultGameSettings())
+	named.Difficulty = HardDifficulty
+	strong := newStrengthGame("engine-strength", 7, DefaultGameSettings())
+
+	// Then: the named difficulty should be played by the rules and the strength by MCTS
+	assert.Equal(t, rulesEngine{}, named.BotEngine())
+	assert.Equal(t, NewMCTSEngine(7), strong.BotEngine())
+}
+
+func TestMCTSEngine_SelectMove(t *testing.T) {
+	t.Run("Strong bot wins when it can", func(t *testing.T) {
+		// Given: the bot can complete its row
+		game := newStrengthGame("mcts-win", MaxStrength, DefaultGameSettings())
+		game.Board = []string{
+			PlayerO, PlayerO, EmptyCell,
+			PlayerX, PlayerX, EmptyCell,
+			PlayerX, EmptyCell, EmptyCell,
+		}
+		game.Turn = PlayerO
+
+		// When: the bot makes a move
+		require.NoError(t, game.BotMakeTurn())
+
+		// Then: the bot should win
+		assert.Equal(t, PlayerO, game.Winner)
+	})
+
+	t.Run("Strong bot blocks the player", func(t *testing.T) {
+		// Given: the player threatens the diagonal
+		game := newStrengthGame("mcts-block", MaxStrength, DefaultGameSettings())
+		game.Board = []string{
+			PlayerX, PlayerO, EmptyCell,
+			EmptyCell, PlayerX, EmptyCell,
+			EmptyCell, EmptyCell, EmptyCell,
+		}
+		game.Turn = PlayerO
+
+		// When: the bot makes a move
+		require.NoError(t, game.BotMakeTurn())
+
+		// Then: the bot should take the end of the diagonal
+		assert.Equal(t, PlayerO, game.Board[8])
+	})
+
+	t.Run("Search leaves the game as it was", func(t *testing.T) {
+		// Given: an ultimate game in progress
+		game := newStrengthGame("mcts-untouched", 3, GameSettings{Variant: UltimateVariant})
+		require.NoError(t, game.MakeTurn(PlayerX, 4*9+2))
+		board := append([]string(nil), game.Board...)
+		subBoards := append([]string(nil), game.SubBoards...)
+
+		// When: the engine searches a move
+		cell, piece := game.BotEngine().SelectMove(game, PlayerO, game.getAvailableCells())
+
+		// Then: the move should be legal and the game untouched
+		assert.Equal(t, 2, cell/9)
+		assert.Equal(t, PlayerO, piece)
+		assert.Equal(t, board, game.Board)
+		assert.Equal(t, subBoards, game.SubBoards)
+		assert.Equal(t, 2, *game.ActiveBoard)
+		assert.Equal(t, PlayerO, game.Turn)
+	})
+
+	t.Run("Search neither records moves nor runs the clock", func(t *testing.T) {
+		// Given: a timed game against a strong bot, the player has made the first move
+		game := newStrengthGame("mcts-timed", MaxStrength, GameSettings{TimeControl: TimeControl{BankMs: 60000}})
+		game.Start()
+		require.NoError(t, game.MakeTurn(PlayerX, 4))
+		moves := append([]Move(nil), game.Moves...)
+		bank := game.Clock.Bank[PlayerO]
+		deadline := *game.Clock.Deadline
+
+		// When: the bot picks its move
+		cell, piece, err := game.SelectBotMove()
+
+		// Then: the move should be legal and the game untouched, its moves and its clock included
+		require.NoError(t, err)
+		assert.Equal(t, EmptyCell, game.Board[cell])
+		assert.Equal(t, PlayerO, piece)
+		assert.Equal(t, moves, game.Moves)
+		assert.Equal(t, bank, game.Clock.Bank[PlayerO])
+		assert.Equal(t, deadline, *game.Clock.Deadline)
+		assert.Equal(t, PlayerO, game.Turn)
+	})
+
+	t.Run("Search keeps to the time budget on 19x19", func(t *testing.T) {
+		// Given: an engine with many playouts but a short time budget on the largest board
+		game := newStrengthGame("mcts-budget", 0, GameSettings{Rows: 19, Cols: 19})
+		engine := MCTSEngine{Playouts: 1 << 20, TimeBudget: 50 * time.Millisecond}
+
+		// When: the engine searches a move
+		start := time.Now()
+		cell, _ := engine.SelectMove(game, PlayerX, game.getAvailableCells())
+
+		// Then: the move should come soon after the budget ran out
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, EmptyCell, game.Board[cell])
+	})
+}
+
+func TestGame_BotMakeTurn_Strength(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings GameSettings
+	}{
+		{name: "classic", settings: DefaultGameSettings()},
+		{name: "7x7", settings: GameSettings{Rows: 7, Cols: 7, WinLength: 4}},
+		{name: "ultimate", settings: GameSettings{Variant: UltimateVariant}},
+		{name: "misère", settings: GameSettings{Variant: MisereVariant}},
+		{name: "wild", settings: GameSettings{Variant: WildVariant}},
+		{name: "notakto", settings: GameSettings{Variant: NotaktoVariant}},
+	}
+
+	for _, tt := range tests {
+		t.Run("Bot plays a whole game by the rules of "+tt.name, func(t *testing.T) {
+			// Given: a game against the weakest MCTS bot
+			game := newStrengthGame("mcts-"+tt.name, MinStrength, tt.settings)
+
+			// When: the player always takes the first legal cell and the bot answers
+			for !game.IsFinished() {
+				require.NoError(t, game.MakeTurn(PlayerX, game.getAvailableCells()[0]))
+				if game.IsFinished() {
+					break
+				}
+				require.NoError(t, game.BotMakeTurn())
+			}
+
+			// Then: the game should come to an end
+			assert.NotEmpty(t, game.Winner)
+		})
+	}
+}
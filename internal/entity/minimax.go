@@ -0,0 +1,311 @@
+package entity
+
+import (
+	"bytes"
+	"slices"
+)
+
+const (
+	// winScore - the score of a won position. The cells left empty are added to it, so quicker wins score higher
+	// and slower losses score less low. Evaluations of unfinished positions stay far below it.
+	winScore = 1 << 50
+	// exactSearchCells - positions with at most that many empty cells are solved to the end.
+	exactSearchCells = 12
+	// searchDepth - how many moves ahead larger positions are searched before they are evaluated.
+	searchDepth = 4
+	// searchWidth - how many of the most promising cells are searched in every larger position.
+	searchWidth = 8
+	// searchRadius - cells further than that from every mark are not searched in larger positions.
+	searchRadius = 2
+	// maxWindowExponent - caps the worth of a window, so long win lengths do not overflow the evaluation.
+	maxWindowExponent = 8
+)
+
+// Bounds of the scores kept in the transposition table, alpha-beta cut-offs only bound the real score.
+const (
+	boundExact = iota
+	boundLower
+	boundUpper
+)
+
+type searchEntry struct {
+	score int
+	depth int
+	bound int
+}
+
+// searcher - a negamax search with alpha-beta pruning over the board of the game. The board is changed while
+// searching and restored before every return. Positions are cached by their canonical form among the symmetries
+// of the board, so mirrored and rotated positions are searched once.
+type searcher struct {
+	game       *Game
+	settings   GameSettings
+	exact      bool
+	symmetries [][]int
+	table      map[string]searchEntry
+	key        []byte
+	candidate  []byte
+}
+
+// minimaxMove - the move of the invincible bot: positions with few empty cells are solved to the end,
+// larger ones are searched searchDepth moves ahead among the most promising cells and evaluated there.
+func (that *Game) minimaxMove(mark string, available []int) int {
+	settings := that.Settings()
+	empties := len(available)
+
+	search := &searcher{
+		game:       that,
+		settings:   settings,
+		exact:      empties <= exactSearchCells,
+		symmetries: boardSymmetries(settings),
+		table:      make(map[string]searchEntry),
+		key:        make([]byte, len(that.Board)+1),
+		candidate:  make([]byte, len(that.Board)+1),
+	}
+
+	depth := searchDepth
+	if search.exact {
+		depth = empties
+	}
+
+	moves := search.moves(mark)
+
+	best, bestScore, alpha := moves[0], -winScore*2, -winScore*2
+	for _, cell := range moves {
+		score := search.score(cell, mark, empties, depth, alpha, winScore*2)
+		if score > bestScore {
+			best, bestScore = cell, score
+		}
+		alpha = max(alpha, score)
+	}
+
+	return best
+}
+
+// score - returns the score of the move of the mark into the cell for the mark.
+func (that *searcher) score(cell int, mark string, empties, depth, alpha, beta int) int {
+	if that.game.completesLine(cell, mark) {
+		return winScore + empties - 1
+	}
+
+	if empties == 1 {
+		return 0
+	}
+
+	that.game.Board[cell] = mark
+	score := -that.negamax(opponentOf(mark), empties-1, depth-1, -beta, -alpha)
+	that.game.Board[cell] = EmptyCell
+
+	return score
+}
+
+// negamax - returns the score of the position for the mark to move, the position is not finished.
+func (that *searcher) negamax(mark string, empties, depth, alpha, beta int) int {
+	if depth == 0 {
+		return that.evaluate(mark)
+	}
+
+	key := that.canonicalKey(mark)
+	if entry, ok := that.table[key]; ok && entry.depth >= depth {
+		switch {
+		case entry.bound == boundExact,
+			entry.bound == boundLower && entry.score >= beta,
+			entry.bound == boundUpper && entry.score <= alpha:
+			return entry.score
+		}
+	}
+
+	alphaOrig := alpha
+
+	best := -winScore * 2
+	for _, cell := range that.moves(mark) {
+		score := that.score(cell, mark, empties, depth, alpha, beta)
+		best = max(best, score)
+		alpha = max(alpha, score)
+
+		if alpha >= beta {
+			break
+		}
+	}
+
+	bound := boundExact
+	switch {
+	case best <= alphaOrig:
+		bound = boundUpper
+	case best >= beta:
+		bound = boundLower
+	}
+	that.table[key] = searchEntry{score: best, depth: depth, bound: bound}
+
+	return best
+}
+
+// moves - returns the cells to search, the most promising first. Larger positions keep only searchWidth cells,
+// and only the cells near marks while there are any.
+func (that *searcher) moves(mark string) []int {
+	oppMark := opponentOf(mark)
+
+	type move struct {
+		cell     int
+		score    int
+		distance int
+	}
+
+	moves := []move{}
+	for _, near := range []bool{!that.exact, false} {
+		for cell, at := range that.game.Board {
+			if at != EmptyCell || (near && !that.nearMark(cell)) {
+				continue
+			}
+
+			moves = append(moves, move{
+				cell:     cell,
+				score:    that.game.positionalScore(that.settings, cell, mark, oppMark),
+				distance: centerDistance(that.settings, cell),
+			})
+		}
+
+		if len(moves) > 0 {
+			break
+		}
+	}
+
+	slices.SortStableFunc(moves, func(a, b move) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return a.distance - b.distance
+	})
+
+	if !that.exact && len(moves) > searchWidth {
+		moves = moves[:searchWidth]
+	}
+
+	cells := make([]int, len(moves))
+	for i, move := range moves {
+		cells[i] = move.cell
+	}
+
+	return cells
+}
+
+// nearMark - reports whether there is a mark within searchRadius of the cell.
+func (that *searcher) nearMark(cell int) bool {
+	row, col := cell/that.settings.Cols, cell%that.settings.Cols
+
+	for r := row - searchRadius; r <= row+searchRadius; r++ {
+		for c := col - searchRadius; c <= col+searchRadius; c++ {
+			switch that.game.markAt(that.settings, r, c) {
+			case PlayerX, PlayerO:
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// evaluate - values the unfinished position for the mark: every window of WinLength cells in a row that holds
+// the marks of one player only is worth an order of magnitude more for every mark in it.
+func (that *searcher) evaluate(mark string) int {
+	settings := that.settings
+	steps := settings.WinLength - 1
+
+	score := 0
+	for cell := range that.game.Board {
+		row, col := cell/settings.Cols, cell%settings.Cols
+
+		for _, direction := range lineDirections {
+			if that.game.markAt(settings, row+steps*direction[0], col+steps*direction[1]) == offBoard {
+				continue
+			}
+
+			own, opp := 0, 0
+			for i := range settings.WinLength {
+				switch that.game.markAt(settings, row+i*direction[0], col+i*direction[1]) {
+				case mark:
+					own++
+				case EmptyCell:
+				default:
+					opp++
+				}
+			}
+
+			switch {
+			case opp == 0 && own > 0:
+				score += windowWorth(own)
+			case own == 0 && opp > 0:
+				score -= windowWorth(opp)
+			}
+		}
+	}
+
+	return score
+}
+
+func windowWorth(marks int) int {
+	worth := 1
+	for range min(marks-1, maxWindowExponent) {
+		worth *= 10
+	}
+	return worth
+}
+
+// canonicalKey - returns the key of the position with the mark to move, the same for all its symmetric images.
+func (that *searcher) canonicalKey(mark string) string {
+	board := that.game.Board
+
+	for i, symmetry := range that.symmetries {
+		that.candidate[0] = mark[0]
+		for cell, source := range symmetry {
+			that.candidate[cell+1] = cellByte(board[source])
+		}
+
+		if i == 0 || bytes.Compare(that.candidate, that.key) < 0 {
+			copy(that.key, that.candidate)
+		}
+	}
+
+	return string(that.key)
+}
+
+func cellByte(mark string) byte {
+	if mark == EmptyCell {
+		return '.'
+	}
+	return mark[0]
+}
+
+// boardSymmetries - returns the symmetries of the board as the cell every cell is taken from: the eight rotations
+// and reflections of a square, the four reflections of a rectangle.
+func boardSymmetries(settings GameSettings) [][]int {
+	rows, cols := settings.Rows, settings.Cols
+
+	transforms := []func(r, c int) (int, int){
+		func(r, c int) (int, int) { return r, c },
+		func(r, c int) (int, int) { return r, cols - 1 - c },
+		func(r, c int) (int, int) { return rows - 1 - r, c },
+		func(r, c int) (int, int) { return rows - 1 - r, cols - 1 - c },
+	}
+
+	if rows == cols {
+		transforms = append(transforms,
+			func(r, c int) (int, int) { return c, r },
+			func(r, c int) (int, int) { return c, cols - 1 - r },
+			func(r, c int) (int, int) { return rows - 1 - c, r },
+			func(r, c int) (int, int) { return rows - 1 - c, cols - 1 - r },
+		)
+	}
+
+	symmetries := make([][]int, 0, len(transforms))
+	for _, transform := range transforms {
+		symmetry := make([]int, rows*cols)
+		for cell := range symmetry {
+			r, c := transform(cell/cols, cell%cols)
+			symmetry[cell] = r*cols + c
+		}
+		symmetries = append(symmetries, symmetry)
+	}
+
+	return symmetries
+}
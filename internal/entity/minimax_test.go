@@ -0,0 +1,241 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceValue - a plain minimax without pruning or caching, the score of the position for the mark to move:
+// a win is worth more the more cells are left empty, a loss the other way round, a tie nothing.
+func referenceValue(game *Game, mark string) int {
+	best, moved := 0, false
+	for _, cell := range game.getAvailableCells() {
+		value := referenceMoveValue(game, cell, mark)
+		if !moved || value > best {
+			best, moved = value, true
+		}
+	}
+
+	return best
+}
+
+func referenceMoveValue(game *Game, cell int, mark string) int {
+	empties := len(game.getAvailableCells()) - 1
+
+	if game.completesLine(cell, mark) {
+		return 10 + empties
+	}
+
+	if empties == 0 {
+		return 0
+	}
+
+	game.Board[cell] = mark
+	defer func() { game.Board[cell] = EmptyCell }()
+
+	return -referenceValue(game, opponentOf(mark))
+}
+
+// walkPositions - calls the visit for every unfinished position reachable from the board, with the mark to move.
+func walkPositions(game *Game, mark string, seen map[string]bool, visit func(mark string)) {
+	key := mark + boardKey(game.Board)
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+
+	visit(mark)
+
+	for _, cell := range game.getAvailableCells() {
+		if game.completesLine(cell, mark) || len(game.getAvailableCells()) == 1 {
+			continue
+		}
+
+		game.Board[cell] = mark
+		walkPositions(game, opponentOf(mark), seen, visit)
+		game.Board[cell] = EmptyCell
+	}
+}
+
+func boardKey(board []string) string {
+	key := make([]byte, len(board))
+	for i, mark := range board {
+		key[i] = cellByte(mark)
+	}
+	return string(key)
+}
+
+func newInvincibleGame(id string) *Game {
+	game := NewGame(id, WithBotType)
+	game.Difficulty = InvincibleDifficulty
+	game.Status = StatusOngoing
+
+	return game
+}
+
+func TestGame_MinimaxMove_Perfect(t *testing.T) {
+	// Given: every unfinished position of the classic game with either player to move
+	game := newInvincibleGame("minimax-perfect")
+
+	positions := 0
+	walkPositions(game, PlayerX, map[string]bool{}, func(mark string) {
+		positions++
+		board := boardKey(game.Board)
+
+		// When: the bot picks a move
+		cell := game.selectBotMove(mark, game.getAvailableCells())
+
+		// Then: the move should be worth as much as the best move: the quickest win, a draw, or the slowest loss
+		require.Equal(t, EmptyCell, game.Board[cell], "position %s", board)
+		require.Equal(t, referenceValue(game, mark), referenceMoveValue(game, cell, mark), "position %s, move %d", board, cell)
+		require.Equal(t, board, boardKey(game.Board), "the search should restore the board")
+	})
+
+	assert.Equal(t, 4520, positions)
+}
+
+func TestGame_BotMakeTurn_InvincibleNeverLoses(t *testing.T) {
+	for _, botMark := range []string{PlayerX, PlayerO} {
+		t.Run("Bot plays "+botMark, func(t *testing.T) {
+			// Given: a classic game against the invincible bot
+			game := newInvincibleGame("minimax-never-loses-" + botMark)
+			game.Players = append(game.Players,
+				&Player{ID: "player1", Mark: opponentOf(botMark), GameID: game.ID},
+				NewBotPlayer(game.ID, botMark),
+			)
+
+			// When: the player tries every move against every answer of the bot
+			results := map[string]int{}
+			var play func(game *Game)
+			play = func(game *Game) {
+				if game.IsFinished() {
+					results[game.Winner]++
+					return
+				}
+
+				if game.Turn == botMark {
+					require.NoError(t, game.BotMakeTurn())
+					play(game)
+					return
+				}
+
+				for _, cell := range game.getAvailableCells() {
+					next := *game
+					next.Board = append([]string(nil), game.Board...)
+					require.NoError(t, next.MakeTurn(game.Turn, cell))
+					play(&next)
+				}
+			}
+			play(game)
+
+			// Then: the player should never win
+			assert.Zero(t, results[opponentOf(botMark)])
+			assert.Positive(t, results[PlayerTie])
+		})
+	}
+}
+
+func TestGame_MinimaxMove_QuickestWin(t *testing.T) {
+	// Given: the bot can win now at cell 2, or set up a win later
+	game := newInvincibleGame("minimax-quickest")
+	game.Board = []string{
+		PlayerO, PlayerO, EmptyCell,
+		PlayerX, PlayerX, EmptyCell,
+		PlayerX, EmptyCell, EmptyCell,
+	}
+
+	// When: searching the move of O
+	cell := game.minimaxMove(PlayerO, game.getAvailableCells())
+
+	// Then: the bot should win at once
+	assert.Equal(t, 2, cell)
+}
+
+func TestGame_MinimaxMove_SlowestLoss(t *testing.T) {
+	// Given: X threatens the diagonal and wins with a fork after it is blocked
+	game := newInvincibleGame("minimax-slowest")
+	game.Board = []string{
+		PlayerX, PlayerO, EmptyCell,
+		EmptyCell, PlayerX, EmptyCell,
+		EmptyCell, EmptyCell, EmptyCell,
+	}
+
+	// When: searching the move of O
+	cell := game.minimaxMove(PlayerO, game.getAvailableCells())
+
+	// Then: the bot should block the diagonal instead of losing at once
+	assert.Equal(t, 8, cell)
+}
+
+func TestSearcher_CanonicalKey(t *testing.T) {
+	// Given: a position and its rotation by a quarter turn
+	game := newInvincibleGame("minimax-symmetry")
+	search := &searcher{
+		game:       game,
+		settings:   game.Settings(),
+		symmetries: boardSymmetries(game.Settings()),
+		key:        make([]byte, len(game.Board)+1),
+		candidate:  make([]byte, len(game.Board)+1),
+	}
+
+	game.Board = []string{
+		PlayerX, PlayerO, EmptyCell,
+		EmptyCell, EmptyCell, EmptyCell,
+		EmptyCell, EmptyCell, EmptyCell,
+	}
+	key := search.canonicalKey(PlayerX)
+
+	game.Board = []string{
+		EmptyCell, EmptyCell, PlayerX,
+		EmptyCell, EmptyCell, PlayerO,
+		EmptyCell, EmptyCell, EmptyCell,
+	}
+
+	// When: building the key of the rotated position
+	rotated := search.canonicalKey(PlayerX)
+
+	// Then: both should share the key, and the mark to move should be part of it
+	assert.Len(t, search.symmetries, 8)
+	assert.Equal(t, key, rotated)
+	assert.NotEqual(t, key, search.canonicalKey(PlayerO))
+	assert.Len(t, boardSymmetries(GameSettings{Rows: 3, Cols: 5, WinLength: 3}), 4)
+}
+
+func TestGame_MinimaxMove_LargeBoards(t *testing.T) {
+	t.Run("Bot makes a double threat on 15x15", func(t *testing.T) {
+		// Given: a gomoku game where the bot has two open rows of three crossing at an empty cell
+		game := NewGameWithSettings("minimax-gomoku", WithBotType, GameSettings{Rows: 15, Cols: 15, WinLength: 5})
+		game.Status = StatusOngoing
+		for _, cell := range []int{7*15 + 4, 7*15 + 5, 7*15 + 6, 4*15 + 7, 5*15 + 7, 6*15 + 7} {
+			game.Board[cell] = PlayerO
+		}
+		for _, cell := range []int{0, 14, 14 * 15, 14*15 + 14, 1, 2} {
+			game.Board[cell] = PlayerX
+		}
+
+		// When: searching the move of O
+		cell := game.minimaxMove(PlayerO, game.getAvailableCells())
+
+		// Then: the bot should take the crossing, which makes two open fours
+		assert.Equal(t, 7*15+7, cell)
+	})
+
+	t.Run("Bot blocks an open three on 19x19", func(t *testing.T) {
+		// Given: the player has an open row of three in the middle of an otherwise quiet board
+		game := NewGameWithSettings("minimax-block", WithBotType, GameSettings{Rows: 19, Cols: 19, WinLength: 5})
+		game.Status = StatusOngoing
+		for _, cell := range []int{9*19 + 8, 9*19 + 9, 9*19 + 10} {
+			game.Board[cell] = PlayerX
+		}
+		game.Board[8*19+9] = PlayerO
+		game.Board[10*19+9] = PlayerO
+
+		// When: searching the move of O
+		cell := game.minimaxMove(PlayerO, game.getAvailableCells())
+
+		// Then: the bot should close one end of the row
+		assert.Contains(t, []int{9*19 + 7, 9*19 + 11, 9*19 + 6, 9*19 + 12}, cell)
+	})
+}
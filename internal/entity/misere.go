@@ -0,0 +1,124 @@
+package entity
+
+// misereRules - the player who makes WinLength in a row of their own mark loses.
+type misereRules struct{}
+
+func (misereRules) Piece(playerMark, piece string) (string, error) {
+	return ownPiece(playerMark, piece)
+}
+
+func (misereRules) CheckMove(*Game, int) error {
+	return nil
+}
+
+func (misereRules) Played(*Game, int) {}
+
+func (misereRules) Result(game *Game, _ string) string {
+	switch mark := game.lineResult(); mark {
+	case PlayerX, PlayerO:
+		return opponentOf(mark)
+	default:
+		return mark
+	}
+}
+
+func (misereRules) BotMove(game *Game, mark string, available []int) (int, string) {
+	if game.botDifficulty() == InvincibleDifficulty {
+		if cell, ok := game.mirrorMove(mark, opponentOf(mark)); ok {
+			return cell, mark
+		}
+	}
+
+	return game.avoidLineMove(mark, opponentOf(mark), available), mark
+}
+
+// mirrorMove - the drawing strategy of the first player of misère on boards with a center cell: take the center,
+// then answer every move with the cell point symmetric to it. Every line of the bot would be the mirror image
+// of a line the opponent made before. Returns false when the board is not in the shape the strategy leaves.
+func (that *Game) mirrorMove(piece, oppPiece string) (int, bool) {
+	settings := that.Settings()
+	if settings.Rows%2 == 0 || settings.Cols%2 == 0 {
+		return 0, false
+	}
+
+	last := len(that.Board) - 1
+	center := last / 2
+
+	if that.Board[center] == EmptyCell {
+		for _, mark := range that.Board {
+			if mark != EmptyCell {
+				return 0, false
+			}
+		}
+
+		return center, true
+	}
+
+	if that.Board[center] != piece {
+		return 0, false
+	}
+
+	move := -1
+	for cell, mark := range that.Board {
+		mirrored := that.Board[last-cell]
+
+		switch {
+		case cell == center, mark == EmptyCell:
+		case mark == oppPiece && mirrored == EmptyCell:
+			if move != -1 {
+				return 0, false
+			}
+			move = last - cell
+		case mark == oppPiece && mirrored == piece, mark == piece && mirrored == oppPiece:
+		default:
+			return 0, false
+		}
+	}
+
+	return move, move != -1
+}
+
+// avoidLineMove - the move of the bot in the variants where making a line loses. The hard bot does not make a line
+// while it has another cell, the invincible bot also leaves the opponent as few cells that make no line as it can.
+func (that *Game) avoidLineMove(piece, oppPiece string, available []int) int {
+	difficulty := that.botDifficulty()
+	if difficulty != HardDifficulty && difficulty != InvincibleDifficulty {
+		return that.easyStrategy(available)
+	}
+
+	safe := that.cellsWithoutLine(piece, available)
+	if len(safe) == 0 {
+		return that.easyStrategy(available)
+	}
+
+	if difficulty == HardDifficulty {
+		return that.easyStrategy(safe)
+	}
+
+	settings := that.Settings()
+
+	best, bestReplies, bestDistance := safe[0], -1, 0
+	for _, cell := range safe {
+		that.Board[cell] = piece
+		replies := len(that.cellsWithoutLine(oppPiece, that.getAvailableCells()))
+		that.Board[cell] = EmptyCell
+
+		distance := centerDistance(settings, cell)
+		if bestReplies == -1 || replies < bestReplies || (replies == bestReplies && distance < bestDistance) {
+			best, bestReplies, bestDistance = cell, replies, distance
+		}
+	}
+
+	return best
+}
+
+// cellsWithoutLine - returns the cells the piece can be put into without making a line.
+func (that *Game) cellsWithoutLine(piece string, available []int) []int {
+	cells := []int{}
+	for _, cell := range available {
+		if !that.completesLine(cell, piece) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
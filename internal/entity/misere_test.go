@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVariantGame(id, variant string) *Game {
+	game := NewGameWithSettings(id, PrivateType, GameSettings{Variant: variant})
+	game.Status = StatusOngoing
+
+	return game
+}
+
+func TestGame_MakeTurn_Misere(t *testing.T) {
+	t.Run("Three in a row loses", func(t *testing.T) {
+		// Given: X has two in a row on a misère board
+		game := newVariantGame("misere-lose", MisereVariant)
+		copy(game.Board, []string{PlayerX, PlayerX, EmptyCell, PlayerO, PlayerO, EmptyCell, EmptyCell, EmptyCell, EmptyCell})
+
+		// When: X completes the row
+		err := game.MakeTurn(PlayerX, 2)
+
+		// Then: O should win the game
+		require.NoError(t, err)
+		assert.Equal(t, PlayerO, game.Winner)
+		assert.Equal(t, StatusFinished, game.Status)
+	})
+
+	t.Run("A full board without a line is a tie", func(t *testing.T) {
+		// Given: a misère board one move away from full with no line on it
+		game := newVariantGame("misere-tie", MisereVariant)
+		copy(game.Board, []string{PlayerX, PlayerO, PlayerX, PlayerX, PlayerO, PlayerO, PlayerO, PlayerX, EmptyCell})
+
+		// When: X fills the last cell
+		err := game.MakeTurn(PlayerX, 8)
+
+		// Then: the game should end in a tie
+		require.NoError(t, err)
+		assert.Equal(t, PlayerTie, game.Winner)
+	})
+
+	t.Run("Players can not play the mark of the opponent", func(t *testing.T) {
+		// Given: a new misère game
+		game := newVariantGame("misere-piece", MisereVariant)
+
+		// When: X tries to put an O
+		err := game.MakeMove(PlayerX, 0, PlayerO)
+
+		// Then: the move should be rejected
+		require.ErrorIs(t, err, ErrInvalidPiece)
+		assert.Equal(t, EmptyCell, game.Board[0])
+	})
+}
+
+func TestGame_BotMakeTurn_Misere(t *testing.T) {
+	t.Run("Hard bot does not make a line while it has another cell", func(t *testing.T) {
+		for range 20 {
+			// Given: the bot has two in a row and other cells to play
+			game := newVariantGame("misere-hard", MisereVariant)
+			game.Difficulty = HardDifficulty
+			game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+			addBotPlayer(game)
+			copy(game.Board, []string{PlayerO, PlayerO, EmptyCell, PlayerX, EmptyCell, PlayerX, EmptyCell, EmptyCell, EmptyCell})
+			game.Turn = PlayerO
+
+			// When: the bot makes a move
+			err := game.BotMakeTurn()
+
+			// Then: the bot should not complete its row
+			require.NoError(t, err)
+			assert.Equal(t, EmptyCell, game.Board[2])
+		}
+	})
+
+	t.Run("Invincible bot moving first never loses", func(t *testing.T) {
+		for range 20 {
+			// Given: a misère game where the bot plays X
+			game := newVariantGame("misere-invincible", MisereVariant)
+			game.Difficulty = InvincibleDifficulty
+			game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerO, GameID: game.ID})
+			game.Players = append(game.Players, NewBotPlayer(game.ID, PlayerX))
+
+			// When: the bot plays against random moves
+			for !game.IsFinished() {
+				require.NoError(t, game.BotMakeTurn())
+				if game.IsFinished() {
+					break
+				}
+
+				available := game.getAvailableCells()
+				require.NoError(t, game.MakeTurn(PlayerO, available[rand.Intn(len(available))])) //nolint:gosec // it`s ok
+			}
+
+			// Then: the bot should have opened in the center and never lost
+			assert.Equal(t, PlayerX, game.Board[4])
+			assert.NotEqual(t, PlayerO, game.Winner)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// Move - a move made in the game, the moves of a game are numbered from 1 in the order they were made.
+type Move struct {
+	Seq  int    `json:"seq"`
+	Mark string `json:"mark"`
+	// Piece - the mark put on the board when it is not the mark of the player, as in wild and notakto games.
+	Piece string `json:"piece,omitempty"`
+	Cell  int    `json:"cell"`
+	// PlayerID - the public ID of the player who made the move, the private one never leaves the server.
+	PlayerID string    `json:"player_id,omitempty"`
+	PlayedAt time.Time `json:"played_at"`
+}
+
+// recordMove - appends the move of the player with the mark to the history of the game and returns it.
+func (that *Game) recordMove(playerMark string, cell int, piece string) Move {
+	move := Move{
+		Seq:      len(that.Moves) + 1,
+		Mark:     playerMark,
+		Cell:     cell,
+		PlayedAt: time.Now().UTC(),
+	}
+
+	if piece != playerMark {
+		move.Piece = piece
+	}
+
+	if player := that.playerByMark(playerMark); player != nil {
+		move.PlayerID = player.PublicID
+	}
+
+	that.Moves = append(that.Moves, move)
+
+	return move
+}
+
+func (that *Game) playerByMark(mark string) *Player {
+	for _, player := range that.Players {
+		if player.Mark == mark {
+			return player
+		}
+	}
+	return nil
+}
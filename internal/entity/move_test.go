@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGame_MakeTurn_RecordsMoves(t *testing.T) {
+	t.Run("Moves are recorded in order with the public ID of the player", func(t *testing.T) {
+		// Given: a game between two players
+		game := NewGame("history-order", PrivateType)
+		game.Status = StatusOngoing
+		game.Players = []*Player{
+			{ID: "private-x", PublicID: "public-x", Mark: PlayerX, GameID: game.ID},
+			{ID: "private-o", PublicID: "public-o", Mark: PlayerO, GameID: game.ID},
+		}
+		before := time.Now().UTC()
+
+		// When: both players move
+		require.NoError(t, game.MakeTurn(PlayerX, 4))
+		require.NoError(t, game.MakeTurn(PlayerO, 0))
+
+		// Then: the history should hold both moves in order
+		require.Len(t, game.Moves, 2)
+		assert.Equal(t, Move{Seq: 1, Mark: PlayerX, Cell: 4, PlayerID: "public-x", PlayedAt: game.Moves[0].PlayedAt}, game.Moves[0])
+		assert.Equal(t, Move{Seq: 2, Mark: PlayerO, Cell: 0, PlayerID: "public-o", PlayedAt: game.Moves[1].PlayedAt}, game.Moves[1])
+		assert.False(t, game.Moves[0].PlayedAt.Before(before))
+		assert.False(t, game.Moves[1].PlayedAt.Before(game.Moves[0].PlayedAt))
+	})
+
+	t.Run("Rejected moves are not recorded", func(t *testing.T) {
+		// Given: a game where X has moved
+		game := NewGame("history-rejected", PrivateType)
+		require.NoError(t, game.MakeTurn(PlayerX, 4))
+
+		// When: X moves out of turn and O moves into the occupied cell
+		require.Error(t, game.MakeTurn(PlayerX, 0))
+		require.Error(t, game.MakeTurn(PlayerO, 4))
+
+		// Then: only the first move should be in the history
+		assert.Len(t, game.Moves, 1)
+	})
+
+	t.Run("The piece is recorded when it is not the mark of the player", func(t *testing.T) {
+		// Given: a wild game
+		game := newVariantGame("history-wild", WildVariant)
+
+		// When: X puts an O and O puts its own mark
+		require.NoError(t, game.MakeMove(PlayerX, 0, PlayerO))
+		require.NoError(t, game.MakeTurn(PlayerO, 1))
+
+		// Then: only the first move should name its piece
+		assert.Equal(t, PlayerO, game.Moves[0].Piece)
+		assert.Empty(t, game.Moves[1].Piece)
+	})
+}
+
+func TestGame_BotMakeTurn_RecordsMoves(t *testing.T) {
+	// Given: a game against the bot after the move of the player
+	game := NewGame("history-bot", WithBotType)
+	game.Status = StatusOngoing
+	game.Players = append(game.Players, &Player{ID: "player1", PublicID: "public-1", Mark: PlayerX, GameID: game.ID})
+	addBotPlayer(game)
+	require.NoError(t, game.MakeTurn(PlayerX, 4))
+
+	// When: the bot answers
+	require.NoError(t, game.BotMakeTurn())
+
+	// Then: the move of the bot should be recorded under its public ID
+	require.Len(t, game.Moves, 2)
+	assert.Equal(t, 2, game.Moves[1].Seq)
+	assert.Equal(t, PlayerO, game.Moves[1].Mark)
+	assert.Equal(t, "bot:"+game.ID, game.Moves[1].PlayerID)
+	assert.Equal(t, PlayerO, game.Board[game.Moves[1].Cell])
+}
+
+func TestGame_MovesJSON(t *testing.T) {
+	// Given: a game with a move
+	game := NewGame("history-json", PrivateType)
+	game.Players = []*Player{{ID: "private-x", PublicID: "public-x", Mark: PlayerX}}
+	require.NoError(t, game.MakeTurn(PlayerX, 8))
+
+	// When: the game goes through JSON, as it does in storage and in responses
+	data, err := json.Marshal(game)
+	require.NoError(t, err)
+
+	var restored Game
+	require.NoError(t, json.Unmarshal(data, &restored))
+
+	// Then: the history should survive and carry no private ID
+	assert.Equal(t, game.Moves[0].Cell, restored.Moves[0].Cell)
+	assert.True(t, game.Moves[0].PlayedAt.Equal(restored.Moves[0].PlayedAt))
+	move, err := json.Marshal(game.Moves[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(move), `{"seq":1,"mark":"X","cell":8,"player_id":"public-x","played_at":`)
+	assert.NotContains(t, string(move), "private-x")
+}
@@ -0,0 +1,33 @@
+package entity
+
+import "fmt"
+
+// notaktoRules - both players put X, the player who makes WinLength in a row loses.
+type notaktoRules struct{}
+
+func (notaktoRules) Piece(_, piece string) (string, error) {
+	if piece != "" && piece != PlayerX {
+		return "", fmt.Errorf("%w: only X is played in notakto", ErrInvalidPiece)
+	}
+
+	return PlayerX, nil
+}
+
+func (notaktoRules) CheckMove(*Game, int) error {
+	return nil
+}
+
+func (notaktoRules) Played(*Game, int) {}
+
+func (notaktoRules) Result(game *Game, mover string) string {
+	switch mark := game.lineResult(); mark {
+	case PlayerX:
+		return opponentOf(mover)
+	default:
+		return mark
+	}
+}
+
+func (notaktoRules) BotMove(game *Game, _ string, available []int) (int, string) {
+	return game.avoidLineMove(PlayerX, PlayerX, available), PlayerX
+}
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGame_MakeTurn_Notakto(t *testing.T) {
+	t.Run("Both players put X", func(t *testing.T) {
+		// Given: a notakto game where X has moved
+		game := newVariantGame("notakto-pieces", NotaktoVariant)
+		require.NoError(t, game.MakeTurn(PlayerX, 0))
+
+		// When: O moves
+		err := game.MakeTurn(PlayerO, 4)
+
+		// Then: O should have put an X
+		require.NoError(t, err)
+		assert.Equal(t, PlayerX, game.Board[4])
+		assert.Equal(t, PlayerX, game.Turn)
+	})
+
+	t.Run("O can not be played", func(t *testing.T) {
+		// Given: a new notakto game
+		game := newVariantGame("notakto-o", NotaktoVariant)
+
+		// When: X asks for an O
+		err := game.MakeMove(PlayerX, 0, PlayerO)
+
+		// Then: the move should be rejected
+		require.ErrorIs(t, err, ErrInvalidPiece)
+	})
+
+	t.Run("The player who makes the line loses", func(t *testing.T) {
+		// Given: two Xs in a row and O to move
+		game := newVariantGame("notakto-lose", NotaktoVariant)
+		game.Board[0] = PlayerX
+		game.Board[1] = PlayerX
+		game.Turn = PlayerO
+
+		// When: O completes the row
+		err := game.MakeTurn(PlayerO, 2)
+
+		// Then: X should win the game
+		require.NoError(t, err)
+		assert.Equal(t, PlayerX, game.Winner)
+		assert.Equal(t, StatusFinished, game.Status)
+	})
+}
+
+func TestGame_BotMakeTurn_Notakto(t *testing.T) {
+	for _, difficulty := range []string{HardDifficulty, InvincibleDifficulty} {
+		t.Run("Bot does not make a line while it has another cell on "+difficulty, func(t *testing.T) {
+			for range 20 {
+				// Given: two Xs in a row and other cells to play
+				game := newVariantGame("notakto-"+difficulty, NotaktoVariant)
+				game.Difficulty = difficulty
+				game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+				addBotPlayer(game)
+				game.Board[0] = PlayerX
+				game.Board[1] = PlayerX
+				game.Turn = PlayerO
+
+				// When: the bot makes a move
+				err := game.BotMakeTurn()
+
+				// Then: the bot should have put an X somewhere but the end of the row
+				require.NoError(t, err)
+				assert.Equal(t, EmptyCell, game.Board[2])
+				assert.Equal(t, StatusOngoing, game.Status)
+			}
+		})
+	}
+}
@@ -3,17 +3,24 @@ package entity
 import "strings"
 
 type Player struct {
-	ID             string `json:"id,omitempty"`
+	// ID - the private identifier of the player, it keys the player in storage and must never reach other players.
+	ID string `json:"id,omitempty"`
+	// PublicID - the handle of the player that is safe to show to opponents and spectators.
+	PublicID string `json:"public_id,omitempty"`
+
 	Mark           string `json:"mark,omitempty"`
 	GameID         string `json:"game_id,omitempty"`
 	LastOpponentID string `json:"last_opponent_id,omitempty"`
+	// LastGame - the terms of the last game the player finished, a rematch is played on them.
+	LastGame *GameTerms `json:"last_game,omitempty"`
 }
 
 func NewBotPlayer(gameID string, mark string) *Player {
 	return &Player{
-		ID:     "bot:" + gameID,
-		Mark:   mark,
-		GameID: gameID,
+		ID:       "bot:" + gameID,
+		PublicID: "bot:" + gameID,
+		Mark:     mark,
+		GameID:   gameID,
 	}
 }
 
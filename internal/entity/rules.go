@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPiece = errors.New("invalid piece")
+
+// Rules - the rules a game is played by, selected by the variant of its settings when the game is created.
+// The game checks that the cell is on the board and empty and that it is the turn of the player,
+// everything else about a move is up to the rules.
+type Rules interface {
+	// Piece - returns the mark the move of the player puts on the board, the piece the player asked for may be empty.
+	Piece(playerMark, piece string) (string, error)
+	// CheckMove - checks the rules that restrict the cells a move can be made on.
+	CheckMove(game *Game, cell int) error
+	// Played - updates the state the rules keep after a piece was put into the cell.
+	Played(game *Game, cell int)
+	// Result - returns the winner, PlayerTie, or an empty string while the game goes on.
+	// The mover is the mark of the player who made the last move.
+	Result(game *Game, mover string) string
+	// BotMove - returns the cell and the piece the bot plays, the available cells are never empty.
+	BotMove(game *Game, mark string, available []int) (int, string)
+}
+
+// variantRules - the rules of every variant a game can be created with.
+var variantRules = map[string]Rules{
+	ClassicVariant:  classicRules{},
+	UltimateVariant: ultimateRules{},
+	MisereVariant:   misereRules{},
+	WildVariant:     wildRules{},
+	NotaktoVariant:  notaktoRules{},
+}
+
+// rules - returns the rules of the game, games stored before the variants existed are classic games.
+func (that *Game) rules() Rules {
+	if rules, ok := variantRules[that.Variant]; ok {
+		return rules
+	}
+
+	return classicRules{}
+}
+
+// classicRules - three in a row wins, on boards of any size the win length in a row wins.
+type classicRules struct{}
+
+func (classicRules) Piece(playerMark, piece string) (string, error) {
+	return ownPiece(playerMark, piece)
+}
+
+func (classicRules) CheckMove(*Game, int) error {
+	return nil
+}
+
+func (classicRules) Played(*Game, int) {}
+
+func (classicRules) Result(game *Game, _ string) string {
+	return game.lineResult()
+}
+
+func (classicRules) BotMove(game *Game, mark string, available []int) (int, string) {
+	return game.selectBotMove(mark, available), mark
+}
+
+// ownPiece - the piece of the variants where every player puts their own mark.
+func ownPiece(playerMark, piece string) (string, error) {
+	if piece != "" && piece != playerMark {
+		return "", fmt.Errorf("%w: %s can not be played by %s", ErrInvalidPiece, piece, playerMark)
+	}
+
+	return playerMark, nil
+}
+
+func opponentOf(mark string) string {
+	if mark == PlayerO {
+		return PlayerX
+	}
+	return PlayerO
+}
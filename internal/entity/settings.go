@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ClassicBoardSize = 3
+
+	MinBoardSize = 3
+	MaxBoardSize = 19
+
+	MinWinLength = 3
+	// maxDefaultWinLength is the win length of boards of 5x5 and more when none was asked for, as in gomoku.
+	maxDefaultWinLength = 5
+)
+
+var ErrInvalidSettings = errors.New("invalid game settings")
+
+// GameSettings - the rules variant, the dimensions of the board and how many marks in a row win, an m,n,k-game.
+// Zero values stand for the defaults, so games stored before the settings existed are classic 3x3 games.
+// Ultimate games are always played on nine 3x3 sub-boards, their dimensions describe a single sub-board.
+// The time control is a part of the settings, so public games pair up players who asked for the same one.
+type GameSettings struct {
+	Variant   string `json:"variant,omitempty"`
+	Rows      int    `json:"rows,omitempty"`
+	Cols      int    `json:"cols,omitempty"`
+	WinLength int    `json:"win_length,omitempty"`
+	TimeControl
+}
+
+// DefaultGameSettings - the classic 3x3 board with three in a row.
+func DefaultGameSettings() GameSettings {
+	return GameSettings{Rows: ClassicBoardSize, Cols: ClassicBoardSize, WinLength: ClassicBoardSize}
+}
+
+// WithDefaults - fills the missing values: a 3x3 board, and a win length of the shorter side, but at most five.
+func (that GameSettings) WithDefaults() GameSettings {
+	if that.Rows == 0 {
+		that.Rows = ClassicBoardSize
+	}
+
+	if that.Cols == 0 {
+		that.Cols = ClassicBoardSize
+	}
+
+	if that.WinLength == 0 {
+		that.WinLength = min(that.Rows, that.Cols, maxDefaultWinLength)
+	}
+
+	return that
+}
+
+// Validate - checks that the board fits the limits, that the win length can be reached on it
+// and that the time control is in range.
+func (that GameSettings) Validate() error {
+	if _, ok := variantRules[that.Variant]; !ok {
+		return fmt.Errorf("%w: unknown variant %q", ErrInvalidSettings, that.Variant)
+	}
+
+	if that.Variant == UltimateVariant &&
+		(that.Rows != ClassicBoardSize || that.Cols != ClassicBoardSize || that.WinLength != ClassicBoardSize) {
+		return fmt.Errorf("%w: ultimate games are played on 3x3 sub-boards", ErrInvalidSettings)
+	}
+
+	if that.Rows < MinBoardSize || that.Rows > MaxBoardSize || that.Cols < MinBoardSize || that.Cols > MaxBoardSize {
+		return fmt.Errorf("%w: board %dx%d is out of %d..%d", ErrInvalidSettings, that.Rows, that.Cols, MinBoardSize, MaxBoardSize)
+	}
+
+	if that.WinLength < MinWinLength || that.WinLength > max(that.Rows, that.Cols) {
+		return fmt.Errorf("%w: win length %d does not fit board %dx%d", ErrInvalidSettings, that.WinLength, that.Rows, that.Cols)
+	}
+
+	return that.TimeControl.Validate()
+}
+
+// IsClassic - reports whether the settings describe the classic 3x3 game.
+func (that GameSettings) IsClassic() bool {
+	return that.WithDefaults() == DefaultGameSettings()
+}
+
+// Cells - returns the number of cells of the board, an ultimate board has the cells of all of its sub-boards.
+func (that GameSettings) Cells() int {
+	if that.Variant == UltimateVariant {
+		return ultimateSubBoards * ultimateSubBoardCells
+	}
+
+	return that.Rows * that.Cols
+}
@@ -0,0 +1,217 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameSettings_WithDefaults(t *testing.T) {
+	t.Run("Empty settings are the classic game", func(t *testing.T) {
+		// When: filling empty settings
+		settings := GameSettings{}.WithDefaults()
+
+		// Then: the classic 3x3 game should be returned
+		assert.Equal(t, DefaultGameSettings(), settings)
+		assert.True(t, settings.IsClassic())
+	})
+
+	t.Run("Win length defaults to the shorter side up to five", func(t *testing.T) {
+		// When: filling settings without a win length
+		small := GameSettings{Rows: 4, Cols: 4}.WithDefaults()
+		large := GameSettings{Rows: 15, Cols: 15}.WithDefaults()
+
+		// Then: 4x4 should need four in a row, and 15x15 five
+		assert.Equal(t, 4, small.WinLength)
+		assert.Equal(t, 5, large.WinLength)
+	})
+}
+
+func TestGameSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings GameSettings
+		valid    bool
+	}{
+		{name: "classic", settings: GameSettings{Rows: 3, Cols: 3, WinLength: 3}, valid: true},
+		{name: "gomoku", settings: GameSettings{Rows: 15, Cols: 15, WinLength: 5}, valid: true},
+		{name: "rectangular", settings: GameSettings{Rows: 3, Cols: 5, WinLength: 4}, valid: true},
+		{name: "too small", settings: GameSettings{Rows: 2, Cols: 3, WinLength: 3}},
+		{name: "too large", settings: GameSettings{Rows: 20, Cols: 20, WinLength: 5}},
+		{name: "win length too short", settings: GameSettings{Rows: 4, Cols: 4, WinLength: 2}},
+		{name: "win length longer than the board", settings: GameSettings{Rows: 4, Cols: 4, WinLength: 5}},
+		{name: "ultimate", settings: GameSettings{Variant: UltimateVariant, Rows: 3, Cols: 3, WinLength: 3}, valid: true},
+		{name: "ultimate on a large board", settings: GameSettings{Variant: UltimateVariant, Rows: 5, Cols: 5, WinLength: 4}},
+		{name: "misère on a large board", settings: GameSettings{Variant: MisereVariant, Rows: 5, Cols: 5, WinLength: 4}, valid: true},
+		{name: "wild", settings: GameSettings{Variant: WildVariant, Rows: 3, Cols: 3, WinLength: 3}, valid: true},
+		{name: "notakto", settings: GameSettings{Variant: NotaktoVariant, Rows: 3, Cols: 3, WinLength: 3}, valid: true},
+		{name: "unknown variant", settings: GameSettings{Variant: "cubic", Rows: 3, Cols: 3, WinLength: 3}},
+		{name: "timed", settings: GameSettings{Rows: 3, Cols: 3, WinLength: 3, TimeControl: TimeControl{MoveLimitMs: 30000, BankMs: 300000, IncrementMs: 2000}}, valid: true},
+		{name: "move limit too short", settings: GameSettings{Rows: 3, Cols: 3, WinLength: 3, TimeControl: TimeControl{MoveLimitMs: 500}}},
+		{name: "bank too long", settings: GameSettings{Rows: 3, Cols: 3, WinLength: 3, TimeControl: TimeControl{BankMs: 2 * 3600000}}},
+		{name: "increment without a bank", settings: GameSettings{Rows: 3, Cols: 3, WinLength: 3, TimeControl: TimeControl{MoveLimitMs: 30000, IncrementMs: 2000}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: validating the settings
+			err := tt.settings.Validate()
+
+			// Then: only valid settings should pass
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidSettings)
+			}
+		})
+	}
+}
+
+func TestNewGameWithSettings(t *testing.T) {
+	t.Run("Classic game keeps the 3x3 JSON shape", func(t *testing.T) {
+		// Given: a classic game
+		game := NewGameWithSettings("123", PrivateType, DefaultGameSettings())
+
+		// When: marshaling it
+		data, err := json.Marshal(game)
+		require.NoError(t, err)
+
+		// Then: the board should have nine cells and no settings should be sent
+		assert.Len(t, game.Board, 9)
+		assert.NotContains(t, string(data), "rows")
+		assert.NotContains(t, string(data), "win_length")
+	})
+
+	t.Run("Larger game sends its settings", func(t *testing.T) {
+		// Given: a 15x15 game
+		game := NewGameWithSettings("123", PrivateType, GameSettings{Rows: 15, Cols: 15})
+
+		// When: marshaling it
+		data, err := json.Marshal(game)
+		require.NoError(t, err)
+
+		// Then: the board and the settings should match
+		assert.Len(t, game.Board, 225)
+		assert.Contains(t, string(data), `"rows":15,"cols":15,"win_length":5`)
+	})
+
+	t.Run("Game stored before settings existed is classic", func(t *testing.T) {
+		// Given: a game stored with a fixed nine cell board
+		var game Game
+		err := json.Unmarshal([]byte(`{"id":"123","board":["X","X","X","","","","","",""],"status":"ongoing"}`), &game)
+		require.NoError(t, err)
+
+		// When: determining the result
+		result := game.DetermineGameResult()
+
+		// Then: it should be played as a classic game
+		assert.Equal(t, DefaultGameSettings(), game.Settings())
+		assert.Equal(t, PlayerX, result)
+	})
+}
+
+func TestGame_DetermineGameResult_LargeBoards(t *testing.T) {
+	t.Run("Four in a row wins on 4x4", func(t *testing.T) {
+		// Given: a 4x4 game with a full column of X
+		game := NewGameWithSettings("123", PrivateType, GameSettings{Rows: 4, Cols: 4})
+		for _, cell := range []int{1, 5, 9, 13} {
+			game.Board[cell] = PlayerX
+		}
+
+		// When: determining the result
+		result := game.DetermineGameResult()
+
+		// Then: X should win
+		assert.Equal(t, PlayerX, result)
+	})
+
+	t.Run("Three in a row does not win on 4x4", func(t *testing.T) {
+		// Given: a 4x4 game with three X in a row
+		game := NewGameWithSettings("123", PrivateType, GameSettings{Rows: 4, Cols: 4})
+		for _, cell := range []int{0, 1, 2} {
+			game.Board[cell] = PlayerX
+		}
+
+		// When: determining the result
+		result := game.DetermineGameResult()
+
+		// Then: the game should go on
+		assert.Empty(t, result)
+	})
+
+	t.Run("Anti-diagonal five wins on 15x15", func(t *testing.T) {
+		// Given: a 15x15 game with five O on an anti-diagonal
+		game := NewGameWithSettings("123", PrivateType, GameSettings{Rows: 15, Cols: 15, WinLength: 5})
+		for i := range 5 {
+			game.Board[(3+i)*15+(10-i)] = PlayerO
+		}
+
+		// When: determining the result
+		result := game.DetermineGameResult()
+
+		// Then: O should win
+		assert.Equal(t, PlayerO, result)
+	})
+
+	t.Run("Lines do not wrap around the edge", func(t *testing.T) {
+		// Given: a 5x5 game with marks at the end of one row and the start of the next
+		game := NewGameWithSettings("123", PrivateType, GameSettings{Rows: 5, Cols: 5, WinLength: 4})
+		for _, cell := range []int{3, 4, 5, 6} {
+			game.Board[cell] = PlayerX
+		}
+
+		// When: determining the result
+		result := game.DetermineGameResult()
+
+		// Then: the game should go on
+		assert.Empty(t, result)
+	})
+}
+
+func TestGame_BotMakeTurn_LargeBoards(t *testing.T) {
+	t.Run("Hard bot blocks an open line on 15x15", func(t *testing.T) {
+		// Given: a gomoku game where the player has four in a row with one end blocked
+		game := NewGameWithSettings("gomoku-hard", WithBotType, GameSettings{Rows: 15, Cols: 15, WinLength: 5})
+		game.Difficulty = HardDifficulty
+		game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+		addBotPlayer(game)
+
+		game.Board[7*15+3] = PlayerO
+		for col := 4; col < 8; col++ {
+			game.Board[7*15+col] = PlayerX
+		}
+		game.Status = StatusOngoing
+		game.Turn = PlayerO
+
+		// When: the bot makes a move
+		err := game.BotMakeTurn()
+
+		// Then: the bot should close the line
+		require.NoError(t, err)
+		assert.Equal(t, PlayerO, game.Board[7*15+8])
+	})
+
+	t.Run("Invincible bot extends its own line on 5x5", func(t *testing.T) {
+		// Given: a 5x5 game where the bot has two in a row and no threats exist
+		game := NewGameWithSettings("five-invincible", WithBotType, GameSettings{Rows: 5, Cols: 5, WinLength: 4})
+		game.Difficulty = InvincibleDifficulty
+		game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+		addBotPlayer(game)
+
+		game.Board[0] = PlayerX
+		game.Board[24] = PlayerX
+		game.Board[11] = PlayerO
+		game.Board[12] = PlayerO
+		game.Status = StatusOngoing
+		game.Turn = PlayerO
+
+		// When: the bot makes a move
+		err := game.BotMakeTurn()
+
+		// Then: the bot should extend its row
+		require.NoError(t, err)
+		assert.True(t, game.Board[10] == PlayerO || game.Board[13] == PlayerO, "bot should extend its row")
+	})
+}
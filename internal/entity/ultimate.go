@@ -0,0 +1,217 @@
+package entity
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+)
+
+// The ultimate board is stored sub-board by sub-board: cell s*9+i is the cell i of the sub-board s,
+// both numbered row by row like a classic board. The cell i played sends the opponent to the sub-board i.
+const (
+	ultimateSubBoards     = 9
+	ultimateSubBoardCells = 9
+)
+
+// Scores the ultimate bot weighs its moves with.
+const (
+	scoreWinGame      = 10000
+	scoreWinSubBoard  = 100
+	scoreBlock        = 50
+	scoreCenter       = 3
+	scoreCorner       = 2
+	scoreGiveFreeMove = -40
+	scoreGiveSubBoard = -60
+	scoreGiveGame     = -5000
+)
+
+// tripleLines are the lines of a 3x3 board, both of a sub-board and of the board of sub-boards.
+var tripleLines = [8][3]int{
+	{0, 1, 2},
+	{3, 4, 5},
+	{6, 7, 8},
+	{0, 3, 6},
+	{1, 4, 7},
+	{2, 5, 8},
+	{0, 4, 8},
+	{2, 4, 6},
+}
+
+// ultimateRules - nine 3x3 sub-boards, the cell played sends the opponent to the sub-board of the same position.
+type ultimateRules struct{}
+
+func (ultimateRules) Piece(playerMark, piece string) (string, error) {
+	return ownPiece(playerMark, piece)
+}
+
+func (ultimateRules) CheckMove(game *Game, cell int) error {
+	return game.checkUltimateMove(cell)
+}
+
+func (ultimateRules) Played(game *Game, cell int) {
+	game.applyUltimateMove(cell)
+}
+
+func (ultimateRules) Result(game *Game, _ string) string {
+	return game.ultimateResult()
+}
+
+func (ultimateRules) BotMove(game *Game, mark string, available []int) (int, string) {
+	return game.selectUltimateMove(mark, opponentOf(mark), game.botDifficulty(), available), mark
+}
+
+func (that *Game) IsUltimate() bool {
+	return that.Variant == UltimateVariant
+}
+
+// checkUltimateMove - the cell must be on an undecided sub-board, and on the active sub-board when there is one.
+func (that *Game) checkUltimateMove(cell int) error {
+	subBoard := cell / ultimateSubBoardCells
+
+	if that.SubBoards[subBoard] != EmptyCell {
+		return fmt.Errorf("%w: sub-board %d is already decided", apperror.ErrWrongSubBoard, subBoard)
+	}
+
+	if that.ActiveBoard != nil && *that.ActiveBoard != subBoard {
+		return fmt.Errorf("%w: the move must be made on sub-board %d", apperror.ErrWrongSubBoard, *that.ActiveBoard)
+	}
+
+	return nil
+}
+
+// applyUltimateMove - decides the sub-board of the cell and sends the opponent to the sub-board the cell points at.
+// An opponent sent to a decided sub-board may play on any undecided one.
+func (that *Game) applyUltimateMove(cell int) {
+	subBoard, inner := cell/ultimateSubBoardCells, cell%ultimateSubBoardCells
+
+	that.SubBoards[subBoard] = tripleResult(that.subBoardCells(subBoard))
+
+	that.ActiveBoard = nil
+	if that.SubBoards[inner] == EmptyCell {
+		that.ActiveBoard = &inner
+	}
+}
+
+// ultimateResult - the game is won by three won sub-boards in a row, and tied once every sub-board is decided.
+func (that *Game) ultimateResult() string {
+	return tripleResult(that.SubBoards)
+}
+
+func (that *Game) subBoardCells(subBoard int) []string {
+	return that.Board[subBoard*ultimateSubBoardCells : (subBoard+1)*ultimateSubBoardCells]
+}
+
+// selectUltimateMove - the hard bot wins and blocks sub-boards, the invincible bot also looks at where its move
+// sends the opponent. Equally good moves are picked at random.
+func (that *Game) selectUltimateMove(mark, oppMark, difficulty string, available []int) int {
+	if difficulty != HardDifficulty && difficulty != InvincibleDifficulty {
+		return that.easyStrategy(available)
+	}
+
+	var best []int
+	bestScore := 0
+	for _, cell := range available {
+		score := that.ultimateMoveScore(cell, mark, oppMark, difficulty == InvincibleDifficulty)
+
+		switch {
+		case len(best) == 0 || score > bestScore:
+			best, bestScore = []int{cell}, score
+		case score == bestScore:
+			best = append(best, cell)
+		}
+	}
+
+	return best[rand.Intn(len(best))] //nolint:gosec // it`s ok
+}
+
+func (that *Game) ultimateMoveScore(cell int, mark, oppMark string, lookAhead bool) int {
+	subBoard, inner := cell/ultimateSubBoardCells, cell%ultimateSubBoardCells
+	cells := that.subBoardCells(subBoard)
+
+	score := 0
+
+	winsSubBoard := completesTriple(cells, inner, mark)
+	if winsSubBoard {
+		score += scoreWinSubBoard
+		if completesTriple(that.SubBoards, subBoard, mark) {
+			score += scoreWinGame
+		}
+	}
+
+	if completesTriple(cells, inner, oppMark) {
+		score += scoreBlock
+	}
+
+	switch inner {
+	case 4:
+		score += scoreCenter
+	case 0, 2, 6, 8:
+		score += scoreCorner
+	}
+
+	if !lookAhead {
+		return score
+	}
+
+	// the opponent plays next on the sub-board the cell points at
+	if that.SubBoards[inner] != EmptyCell || (inner == subBoard && winsSubBoard) {
+		return score + scoreGiveFreeMove
+	}
+
+	next := that.subBoardCells(inner)
+	for i, nextMark := range next {
+		if nextMark != EmptyCell || (inner == subBoard && i == inner) || !completesTriple(next, i, oppMark) {
+			continue
+		}
+
+		if completesTriple(that.SubBoards, inner, oppMark) {
+			return score + scoreGiveGame
+		}
+		return score + scoreGiveSubBoard
+	}
+
+	return score
+}
+
+// tripleResult - returns the mark with three in a row on the 3x3 cells, PlayerTie when all cells are taken and
+// nobody has a line, and an empty cell while the board is open. Tied sub-boards count as taken but belong to nobody.
+func tripleResult(cells []string) string {
+	for _, line := range tripleLines {
+		a, b, c := cells[line[0]], cells[line[1]], cells[line[2]]
+		if (a == PlayerX || a == PlayerO) && a == b && b == c {
+			return a
+		}
+	}
+
+	for _, cell := range cells {
+		if cell == EmptyCell {
+			return EmptyCell
+		}
+	}
+
+	return PlayerTie
+}
+
+// completesTriple - reports whether the mark put into the cell makes three in a row on the 3x3 cells.
+func completesTriple(cells []string, cell int, mark string) bool {
+	for _, line := range tripleLines {
+		count := 0
+		hasCell := false
+
+		for _, i := range line {
+			switch {
+			case i == cell:
+				hasCell = true
+			case cells[i] == mark:
+				count++
+			}
+		}
+
+		if hasCell && count == 2 {
+			return true
+		}
+	}
+
+	return false
+}
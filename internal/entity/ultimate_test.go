@@ -0,0 +1,192 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUltimateGame(id string) *Game {
+	game := NewGameWithSettings(id, PrivateType, GameSettings{Variant: UltimateVariant})
+	game.Status = StatusOngoing
+
+	return game
+}
+
+func TestGame_MakeTurn_Ultimate(t *testing.T) {
+	t.Run("The cell played sends the opponent to its sub-board", func(t *testing.T) {
+		// Given: a new ultimate game
+		game := newUltimateGame("ultimate-send")
+		assert.Len(t, game.Board, 81)
+		assert.Len(t, game.SubBoards, 9)
+		assert.Nil(t, game.ActiveBoard)
+
+		// When: X plays the top right cell of the center sub-board
+		err := game.MakeTurn(PlayerX, 4*9+2)
+
+		// Then: O should have to play on the top right sub-board
+		require.NoError(t, err)
+		require.NotNil(t, game.ActiveBoard)
+		assert.Equal(t, 2, *game.ActiveBoard)
+		assert.Equal(t, StatusOngoing, game.Status)
+	})
+
+	t.Run("A move on another sub-board is rejected", func(t *testing.T) {
+		// Given: an ultimate game where O must play on the top right sub-board
+		game := newUltimateGame("ultimate-wrong")
+		require.NoError(t, game.MakeTurn(PlayerX, 4*9+2))
+
+		// When: O plays on the bottom left sub-board
+		err := game.MakeTurn(PlayerO, 6*9+4)
+
+		// Then: the move should be rejected and the board left as it was
+		require.ErrorIs(t, err, apperror.ErrWrongSubBoard)
+		assert.Equal(t, EmptyCell, game.Board[6*9+4])
+		assert.Equal(t, PlayerO, game.Turn)
+	})
+
+	t.Run("A won sub-board is closed and frees the next move", func(t *testing.T) {
+		// Given: X has two in a row on the top left sub-board and may play anywhere
+		game := newUltimateGame("ultimate-close")
+		game.Board[0] = PlayerX
+		game.Board[1] = PlayerX
+		game.Board[9+4] = PlayerO
+		game.Board[2*9+4] = PlayerO
+
+		// When: X completes the row with the cell pointing at the top right sub-board
+		err := game.MakeTurn(PlayerX, 0*9+2)
+		require.NoError(t, err)
+
+		// Then: the sub-board should be won by X, and O sent back to it may play anywhere but there
+		assert.Equal(t, PlayerX, game.SubBoards[0])
+		assert.Equal(t, 2, *game.ActiveBoard)
+
+		require.NoError(t, game.MakeTurn(PlayerO, 2*9+0))
+		assert.Nil(t, game.ActiveBoard)
+		require.ErrorIs(t, game.MakeTurn(PlayerX, 0*9+5), apperror.ErrWrongSubBoard)
+		require.NoError(t, game.MakeTurn(PlayerX, 8*9+8))
+	})
+
+	t.Run("Three sub-boards in a row win the game", func(t *testing.T) {
+		// Given: X has won the top left and top middle sub-boards and two in a row on the top right one
+		game := newUltimateGame("ultimate-win")
+		game.SubBoards[0] = PlayerX
+		game.SubBoards[1] = PlayerX
+		game.Board[2*9+0] = PlayerX
+		game.Board[2*9+1] = PlayerX
+
+		// When: X completes the top right sub-board
+		err := game.MakeTurn(PlayerX, 2*9+2)
+
+		// Then: X should win the game
+		require.NoError(t, err)
+		assert.Equal(t, PlayerX, game.Winner)
+		assert.Equal(t, StatusFinished, game.Status)
+		assert.Nil(t, game.ActiveBoard)
+	})
+
+	t.Run("The game is tied once every sub-board is decided without a line", func(t *testing.T) {
+		// Given: eight sub-boards decided with no line for anybody and the last one a move away from a tie
+		game := newUltimateGame("ultimate-tie")
+		copy(game.SubBoards, []string{PlayerX, PlayerO, PlayerX, PlayerX, PlayerO, PlayerO, PlayerO, PlayerX, EmptyCell})
+		copy(game.Board[8*9:], []string{PlayerX, PlayerO, PlayerX, PlayerX, PlayerO, PlayerO, PlayerO, PlayerX, EmptyCell})
+
+		// When: X fills the last cell
+		err := game.MakeTurn(PlayerX, 8*9+8)
+
+		// Then: the game should end in a tie
+		require.NoError(t, err)
+		assert.Equal(t, PlayerTie, game.SubBoards[8])
+		assert.Equal(t, PlayerTie, game.Winner)
+	})
+}
+
+func TestGame_UltimateJSON(t *testing.T) {
+	// Given: an ultimate game after one move
+	game := newUltimateGame("ultimate-json")
+	require.NoError(t, game.MakeTurn(PlayerX, 4))
+
+	// When: serializing the game
+	data, err := json.Marshal(game)
+	require.NoError(t, err)
+
+	// Then: clients should get the variant, the sub-board results and the active sub-board
+	var state map[string]any
+	require.NoError(t, json.Unmarshal(data, &state))
+	assert.Equal(t, UltimateVariant, state["variant"])
+	assert.Len(t, state["board"], 81)
+	assert.Len(t, state["sub_boards"], 9)
+	assert.InDelta(t, 4, state["active_board"], 0)
+}
+
+func TestGame_BotMakeTurn_Ultimate(t *testing.T) {
+	for _, difficulty := range []string{EasyDifficulty, HardDifficulty, InvincibleDifficulty} {
+		t.Run("Bot plays a whole game by the rules on "+difficulty, func(t *testing.T) {
+			// Given: an ultimate game against the bot
+			game := NewGameWithSettings("ultimate-bot-"+difficulty, WithBotType, GameSettings{Variant: UltimateVariant})
+			game.Difficulty = difficulty
+			game.Status = StatusOngoing
+			game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+			addBotPlayer(game)
+
+			// When: the player always takes the first legal cell and the bot answers
+			for !game.IsFinished() {
+				require.NoError(t, game.MakeTurn(PlayerX, game.getAvailableCells()[0]))
+				if game.IsFinished() {
+					break
+				}
+				require.NoError(t, game.BotMakeTurn())
+			}
+
+			// Then: the game should come to an end
+			assert.NotEmpty(t, game.Winner)
+		})
+	}
+
+	t.Run("Hard bot takes a sub-board that wins the game", func(t *testing.T) {
+		// Given: the bot has won two sub-boards in a row and may complete the third one
+		game := newUltimateGame("ultimate-bot-win")
+		game.Type = WithBotType
+		game.Difficulty = HardDifficulty
+		game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+		addBotPlayer(game)
+		game.Turn = PlayerO
+		game.SubBoards[3] = PlayerO
+		game.SubBoards[4] = PlayerO
+		game.Board[5*9+0] = PlayerO
+		game.Board[5*9+1] = PlayerO
+		active := 5
+		game.ActiveBoard = &active
+
+		// When: the bot makes a move
+		err := game.BotMakeTurn()
+
+		// Then: the bot should win the game
+		require.NoError(t, err)
+		assert.Equal(t, PlayerO, game.Winner)
+	})
+
+	t.Run("Invincible bot does not send the player to a sub-board they can win", func(t *testing.T) {
+		// Given: the player has two in a row on the top middle sub-board, the bot plays on the bottom right one
+		game := newUltimateGame("ultimate-bot-send")
+		game.Type = WithBotType
+		game.Difficulty = InvincibleDifficulty
+		game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+		addBotPlayer(game)
+		game.Turn = PlayerO
+		game.Board[1*9+0] = PlayerX
+		game.Board[1*9+1] = PlayerX
+		active := 8
+		game.ActiveBoard = &active
+
+		// When: the bot makes a move
+		err := game.BotMakeTurn()
+
+		// Then: the bot should not play the top middle cell
+		require.NoError(t, err)
+		assert.Equal(t, EmptyCell, game.Board[8*9+1])
+	})
+}
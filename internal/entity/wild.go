@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// wildRules - either player may put X or O, the player who makes WinLength in a row of either mark wins.
+type wildRules struct{}
+
+func (wildRules) Piece(playerMark, piece string) (string, error) {
+	switch piece {
+	case "":
+		return playerMark, nil
+	case PlayerX, PlayerO:
+		return piece, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidPiece, piece)
+	}
+}
+
+func (wildRules) CheckMove(*Game, int) error {
+	return nil
+}
+
+func (wildRules) Played(*Game, int) {}
+
+func (wildRules) Result(game *Game, mover string) string {
+	switch mark := game.lineResult(); mark {
+	case PlayerX, PlayerO:
+		return mover
+	default:
+		return mark
+	}
+}
+
+func (wildRules) BotMove(game *Game, _ string, available []int) (int, string) {
+	return game.selectWildMove(available)
+}
+
+// wildMove - a cell together with the piece put into it.
+type wildMove struct {
+	cell  int
+	piece string
+}
+
+// selectWildMove - every line wins, so the hard bot makes a line whenever it can and otherwise does not leave one
+// to the opponent while it has another move. The invincible bot picks the move closest to the center of those.
+func (that *Game) selectWildMove(available []int) (int, string) {
+	pieces := []string{PlayerX, PlayerO}
+
+	difficulty := that.botDifficulty()
+	if difficulty != HardDifficulty && difficulty != InvincibleDifficulty {
+		return that.easyStrategy(available), pieces[rand.Intn(len(pieces))] //nolint:gosec // it`s ok
+	}
+
+	safe := []wildMove{}
+	for _, cell := range available {
+		for _, piece := range pieces {
+			if that.completesLine(cell, piece) {
+				return cell, piece
+			}
+
+			that.Board[cell] = piece
+			if !that.hasWildLine() {
+				safe = append(safe, wildMove{cell: cell, piece: piece})
+			}
+			that.Board[cell] = EmptyCell
+		}
+	}
+
+	if len(safe) == 0 {
+		return that.easyStrategy(available), pieces[rand.Intn(len(pieces))] //nolint:gosec // it`s ok
+	}
+
+	if difficulty == HardDifficulty {
+		move := safe[rand.Intn(len(safe))] //nolint:gosec // it`s ok
+		return move.cell, move.piece
+	}
+
+	settings := that.Settings()
+
+	best := safe[0]
+	for _, move := range safe[1:] {
+		if centerDistance(settings, move.cell) < centerDistance(settings, best.cell) {
+			best = move
+		}
+	}
+
+	return best.cell, best.piece
+}
+
+// hasWildLine - reports whether a line of either mark can be made in one move.
+func (that *Game) hasWildLine() bool {
+	for _, cell := range that.getAvailableCells() {
+		if that.completesLine(cell, PlayerX) || that.completesLine(cell, PlayerO) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGame_MakeTurn_Wild(t *testing.T) {
+	t.Run("Players choose the piece", func(t *testing.T) {
+		// Given: a new wild game
+		game := newVariantGame("wild-piece", WildVariant)
+
+		// When: X puts an O, and O puts its own mark without asking for a piece
+		require.NoError(t, game.MakeMove(PlayerX, 0, PlayerO))
+		require.NoError(t, game.MakeTurn(PlayerO, 1))
+
+		// Then: both cells should hold an O
+		assert.Equal(t, PlayerO, game.Board[0])
+		assert.Equal(t, PlayerO, game.Board[1])
+	})
+
+	t.Run("Unknown pieces are rejected", func(t *testing.T) {
+		// Given: a new wild game
+		game := newVariantGame("wild-unknown", WildVariant)
+
+		// When: X asks for a piece that does not exist
+		err := game.MakeMove(PlayerX, 0, "Z")
+
+		// Then: the move should be rejected
+		require.ErrorIs(t, err, ErrInvalidPiece)
+	})
+
+	t.Run("A line of the opponent's mark wins for the mover", func(t *testing.T) {
+		// Given: two Os in a row and X to move
+		game := newVariantGame("wild-win", WildVariant)
+		game.Board[0] = PlayerO
+		game.Board[1] = PlayerO
+		game.Board[4] = PlayerX
+
+		// When: X completes the row with an O
+		err := game.MakeMove(PlayerX, 2, PlayerO)
+
+		// Then: X should win the game
+		require.NoError(t, err)
+		assert.Equal(t, PlayerX, game.Winner)
+	})
+}
+
+func TestGame_BotMakeTurn_Wild(t *testing.T) {
+	t.Run("Hard bot makes a line of either mark", func(t *testing.T) {
+		// Given: two Xs in a row and the bot to move
+		game := newVariantGame("wild-hard-win", WildVariant)
+		game.Difficulty = HardDifficulty
+		game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+		addBotPlayer(game)
+		game.Board[0] = PlayerX
+		game.Board[1] = PlayerX
+		game.Board[4] = PlayerO
+		game.Turn = PlayerO
+
+		// When: the bot makes a move
+		err := game.BotMakeTurn()
+
+		// Then: the bot should complete the row of X and win
+		require.NoError(t, err)
+		assert.Equal(t, PlayerX, game.Board[2])
+		assert.Equal(t, PlayerO, game.Winner)
+	})
+
+	for _, difficulty := range []string{HardDifficulty, InvincibleDifficulty} {
+		t.Run("Bot does not leave a line to the opponent on "+difficulty, func(t *testing.T) {
+			for range 20 {
+				// Given: a wild game with a single X in the corner
+				game := newVariantGame("wild-"+difficulty, WildVariant)
+				game.Difficulty = difficulty
+				game.Players = append(game.Players, &Player{ID: "player1", Mark: PlayerX, GameID: game.ID})
+				addBotPlayer(game)
+				game.Board[0] = PlayerX
+				game.Turn = PlayerO
+
+				// When: the bot makes a move
+				err := game.BotMakeTurn()
+
+				// Then: no line should be one move away
+				require.NoError(t, err)
+				assert.False(t, game.hasWildLine())
+			}
+		})
+	}
+}
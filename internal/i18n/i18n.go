@@ -0,0 +1,127 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	LocaleEN = "en"
+	LocaleRU = "ru"
+
+	// DefaultLocale is used when the client asked for nothing the server speaks, every key must exist in it.
+	DefaultLocale = LocaleEN
+)
+
+// Params are the values substituted for the {name} placeholders of a message.
+type Params map[string]any
+
+// IsSupported - reports whether the server has a catalog for the locale.
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Translate - returns the message of the key in the locale with the parameters substituted.
+// Keys missing in the locale fall back to DefaultLocale, unknown keys are returned as they are.
+func Translate(locale, key string, params Params) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message, ok = catalogs[DefaultLocale][key]
+	}
+
+	if !ok {
+		return key
+	}
+
+	if len(params) == 0 {
+		return message
+	}
+
+	replacements := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", format(value))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+// Negotiate - picks the first supported locale out of the candidates, DefaultLocale when there is none.
+// Region subtags are ignored, so "ru-RU" selects "ru".
+func Negotiate(candidates ...string) string {
+	for _, candidate := range candidates {
+		if locale := baseLanguage(candidate); IsSupported(locale) {
+			return locale
+		}
+	}
+
+	return DefaultLocale
+}
+
+// ParseAcceptLanguage - returns the languages of an Accept-Language header ordered by their quality.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		language string
+		quality  float64
+	}
+
+	var languages []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		language, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if language == "" || language == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		if quality <= 0 {
+			continue
+		}
+
+		languages = append(languages, weighted{language: language, quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	result := make([]string, 0, len(languages))
+	for _, language := range languages {
+		result = append(result, language.language)
+	}
+
+	return result
+}
+
+func baseLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	base, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+
+	return base
+}
+
+func format(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case interface{ String() string }:
+		return v.String()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,102 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	t.Run("Returns the message in the requested locale", func(t *testing.T) {
+		// When: translating a key into Russian
+		message := Translate(LocaleRU, KeyErrorNotYourTurn, nil)
+
+		// Then: the Russian message should be returned
+		assert.Equal(t, "Сейчас не ваш ход", message)
+	})
+
+	t.Run("Falls back to the default locale for an unknown locale", func(t *testing.T) {
+		// When: translating a key into a locale without a catalog
+		message := Translate("de", KeyErrorNotYourTurn, nil)
+
+		// Then: the English message should be returned
+		assert.Equal(t, "It's not your turn", message)
+	})
+
+	t.Run("Returns the key when it is missing in every catalog", func(t *testing.T) {
+		// When: translating an unknown key
+		message := Translate(LocaleEN, "unknown.key", nil)
+
+		// Then: the key itself should be returned
+		assert.Equal(t, "unknown.key", message)
+	})
+
+	t.Run("Substitutes parameters", func(t *testing.T) {
+		// Given: a catalog entry with placeholders
+		catalogs[LocaleEN]["test.params"] = "{name} waits {seconds}s"
+		defer delete(catalogs[LocaleEN], "test.params")
+
+		// When: translating it with parameters
+		message := Translate(LocaleEN, "test.params", Params{"name": "Bob", "seconds": 5})
+
+		// Then: the placeholders should be replaced
+		assert.Equal(t, "Bob waits 5s", message)
+	})
+}
+
+func TestCatalogsAreComplete(t *testing.T) {
+	// Every key of the default catalog must be translated into every other locale
+	for locale, catalog := range catalogs {
+		for key := range catalogs[DefaultLocale] {
+			assert.Contains(t, catalog, key, "locale %s misses key %s", locale, key)
+		}
+	}
+}
+
+func TestCatalogsHaveSamePlaceholders(t *testing.T) {
+	placeholder := regexp.MustCompile(`\{[a-z_]+\}`)
+
+	// Every translation must take the parameters the default catalog message takes
+	for locale, catalog := range catalogs {
+		for key, message := range catalogs[DefaultLocale] {
+			want := placeholder.FindAllString(message, -1)
+			got := placeholder.FindAllString(catalog[key], -1)
+
+			assert.ElementsMatch(t, compact(want), compact(got), "locale %s key %s", locale, key)
+		}
+	}
+}
+
+// compact - the sorted placeholders without repeats.
+func compact(placeholders []string) []string {
+	slices.Sort(placeholders)
+	return slices.Compact(placeholders)
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Run("Picks the first supported candidate ignoring the region", func(t *testing.T) {
+		// When: negotiating between an unsupported and a regional supported locale
+		locale := Negotiate("de-DE", "ru-RU", "en")
+
+		// Then: the base language of the first supported candidate should be selected
+		assert.Equal(t, LocaleRU, locale)
+	})
+
+	t.Run("Falls back to the default locale", func(t *testing.T) {
+		// When: no candidate is supported
+		locale := Negotiate("de", "fr")
+
+		// Then: the default locale should be selected
+		assert.Equal(t, DefaultLocale, locale)
+	})
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	// When: parsing a header with quality values
+	languages := ParseAcceptLanguage("de;q=0.5, ru-RU, en;q=0.8, fr;q=0, *;q=0.1")
+
+	// Then: the languages should be ordered by quality without rejected ones and the wildcard
+	assert.Equal(t, []string{"ru-RU", "en", "de"}, languages)
+}
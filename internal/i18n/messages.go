@@ -0,0 +1,129 @@
+package i18n
+
+// Message keys of the texts the server sends to players.
+const (
+	KeyErrorInternal          = "error.internal"
+	KeyErrorUnknownAction     = "error.unknown_action"
+	KeyErrorNotConnected      = "error.not_connected"
+	KeyErrorSessionBound      = "error.session_bound"
+	KeyErrorInvalidToken      = "error.invalid_token"
+	KeyErrorTokenExpired      = "error.token_expired"
+	KeyErrorGameRequired      = "error.game_required"
+	KeyErrorCellRequired      = "error.cell_required"
+	KeyErrorInvalidAnswer     = "error.invalid_answer"
+	KeyErrorNoOpponent        = "error.no_opponent"
+	KeyErrorRateLimited       = "error.rate_limited"
+	KeyErrorPlayerNotFound    = "error.player_not_found"
+	KeyErrorGameNotFound      = "error.game_not_found"
+	KeyErrorGameFull          = "error.game_full"
+	KeyErrorGameAlreadyExists = "error.game_already_exists"
+	KeyErrorGameNotStarted    = "error.game_not_started"
+	KeyErrorGameFinished      = "error.game_finished"
+	KeyErrorNotYourTurn       = "error.not_your_turn"
+	KeyErrorCellOccupied      = "error.cell_occupied"
+	KeyErrorWrongSubBoard     = "error.wrong_sub_board"
+	KeyErrorInvalidPiece      = "error.invalid_piece"
+	KeyErrorInvalidStrength   = "error.invalid_strength"
+	KeyErrorInvalidCell       = "error.invalid_cell"
+	KeyErrorInvalidSettings   = "error.invalid_settings"
+	KeyErrorReplayRequired    = "error.replay_required"
+	KeyErrorNoReplay          = "error.no_replay"
+	KeyErrorInvalidReplay     = "error.invalid_replay"
+	KeyErrorSpectatorsFull    = "error.spectators_full"
+	KeyErrorSpectating        = "error.spectating"
+	KeyErrorAlreadyInGame     = "error.already_in_game"
+
+	KeyRematchRequested        = "rematch.requested"
+	KeyRematchAlreadyResponded = "rematch.already_responded"
+	KeyRematchConfirmed        = "rematch.confirmed"
+	KeyRematchOpponentBusy     = "rematch.opponent_busy"
+	KeyRematchStarted          = "rematch.started"
+	KeyRematchRequestBlocked   = "rematch.request_blocked"
+	KeyRematchOpponentWants    = "rematch.opponent_wants"
+	KeyRematchDeclined         = "rematch.declined"
+)
+
+var catalogs = map[string]map[string]string{
+	LocaleEN: {
+		KeyErrorInternal:          "Something went wrong, please try again",
+		KeyErrorUnknownAction:     "Unknown action",
+		KeyErrorNotConnected:      "Connect is required before any other action",
+		KeyErrorSessionBound:      "Session is already bound to another player",
+		KeyErrorInvalidToken:      "Session token is invalid, connect without it to start a new session",
+		KeyErrorTokenExpired:      "Session token has expired, connect without it to start a new session",
+		KeyErrorGameRequired:      "Game is required",
+		KeyErrorCellRequired:      "Cell is required",
+		KeyErrorInvalidAnswer:     "Answer must be 'yes' or 'no'",
+		KeyErrorNoOpponent:        "No last opponent found",
+		KeyErrorRateLimited:       "Too many requests, please slow down",
+		KeyErrorPlayerNotFound:    "Player not found",
+		KeyErrorGameNotFound:      "Game not found",
+		KeyErrorGameFull:          "Game is full",
+		KeyErrorGameAlreadyExists: "Game already exists",
+		KeyErrorGameNotStarted:    "Game is not started yet",
+		KeyErrorGameFinished:      "Game is already finished",
+		KeyErrorNotYourTurn:       "It's not your turn",
+		KeyErrorCellOccupied:      "Cell is already occupied",
+		KeyErrorWrongSubBoard:     "Move must be made on the active sub-board",
+		KeyErrorInvalidPiece:      "This piece can not be played in this game",
+		KeyErrorInvalidStrength:   "Bot strength must be from {min} to {max}",
+		KeyErrorInvalidCell:       "Invalid cell",
+		KeyErrorInvalidSettings:   "Board must be from {min_size}x{min_size} to {max_size}x{max_size}, the win length must fit it and the time control must be in range",
+		KeyErrorReplayRequired:    "Replay is required",
+		KeyErrorNoReplay:          "No replay is loaded, start one with the ID of a finished game",
+		KeyErrorInvalidReplay:     "Unknown replay command, or the speed is out of {min_speed}..{max_speed}",
+		KeyErrorSpectatorsFull:    "The game already has {max} spectators, as many as it may have",
+		KeyErrorSpectating:        "Spectators can not make moves",
+		KeyErrorAlreadyInGame:     "Finish your game before watching another one",
+
+		KeyRematchRequested:        "Rematch request created, waiting for opponent to confirm",
+		KeyRematchAlreadyResponded: "You have already responded to the rematch request",
+		KeyRematchConfirmed:        "Rematch confirmed, waiting for opponent to confirm",
+		KeyRematchOpponentBusy:     "Cannot start rematch: Opponent is currently in another game.",
+		KeyRematchStarted:          "Rematch confirmed. New game has started!",
+		KeyRematchRequestBlocked:   "Cannot send rematch request: Opponent is currently in another game.",
+		KeyRematchOpponentWants:    "Your opponent wants a rematch.",
+		KeyRematchDeclined:         "Rematch request was declined",
+	},
+	LocaleRU: {
+		KeyErrorInternal:          "Что-то пошло не так, попробуйте ещё раз",
+		KeyErrorUnknownAction:     "Неизвестное действие",
+		KeyErrorNotConnected:      "Сначала нужно подключиться",
+		KeyErrorSessionBound:      "Сессия уже привязана к другому игроку",
+		KeyErrorInvalidToken:      "Недействительный токен сессии, подключитесь без него, чтобы начать новую сессию",
+		KeyErrorTokenExpired:      "Срок действия токена сессии истёк, подключитесь без него, чтобы начать новую сессию",
+		KeyErrorGameRequired:      "Не указана игра",
+		KeyErrorCellRequired:      "Не указана клетка",
+		KeyErrorInvalidAnswer:     "Ответ должен быть 'yes' или 'no'",
+		KeyErrorNoOpponent:        "Последний соперник не найден",
+		KeyErrorRateLimited:       "Слишком много запросов, подождите немного",
+		KeyErrorPlayerNotFound:    "Игрок не найден",
+		KeyErrorGameNotFound:      "Игра не найдена",
+		KeyErrorGameFull:          "В игре нет свободных мест",
+		KeyErrorGameAlreadyExists: "Игра уже существует",
+		KeyErrorGameNotStarted:    "Игра ещё не началась",
+		KeyErrorGameFinished:      "Игра уже закончилась",
+		KeyErrorNotYourTurn:       "Сейчас не ваш ход",
+		KeyErrorCellOccupied:      "Клетка уже занята",
+		KeyErrorWrongSubBoard:     "Ход нужно сделать на активном малом поле",
+		KeyErrorInvalidPiece:      "Этой фигурой нельзя ходить в этой игре",
+		KeyErrorInvalidStrength:   "Сила бота должна быть от {min} до {max}",
+		KeyErrorInvalidCell:       "Неверная клетка",
+		KeyErrorInvalidSettings:   "Поле должно быть от {min_size}x{min_size} до {max_size}x{max_size}, длина выигрышной линии должна на нём помещаться, а контроль времени — в допустимых пределах",
+		KeyErrorReplayRequired:    "Не указан повтор",
+		KeyErrorNoReplay:          "Повтор не загружен, начните его с ID законченной игры",
+		KeyErrorInvalidReplay:     "Неизвестная команда повтора, или скорость вне {min_speed}..{max_speed}",
+		KeyErrorSpectatorsFull:    "У игры уже {max} зрителей, больше смотреть её нельзя",
+		KeyErrorSpectating:        "Зрители не могут делать ходы",
+		KeyErrorAlreadyInGame:     "Закончите свою игру, прежде чем смотреть другую",
+
+		KeyRematchRequested:        "Запрос на реванш создан, ждём подтверждения соперника",
+		KeyRematchAlreadyResponded: "Вы уже ответили на запрос реванша",
+		KeyRematchConfirmed:        "Реванш подтверждён, ждём подтверждения соперника",
+		KeyRematchOpponentBusy:     "Реванш невозможен: соперник сейчас в другой игре.",
+		KeyRematchStarted:          "Реванш подтверждён. Новая игра началась!",
+		KeyRematchRequestBlocked:   "Нельзя отправить запрос на реванш: соперник сейчас в другой игре.",
+		KeyRematchOpponentWants:    "Соперник хочет реванш.",
+		KeyRematchDeclined:         "Запрос на реванш отклонён",
+	},
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+const (
+	archiveKeyPrefix = "archive:"
+	// archiveIndexKeyPrefix keys the sorted set of the archived games of a player, scored by the end time in milliseconds.
+	archiveIndexKeyPrefix = "archive_player:"
+	// archivePlayersKeyPrefix keys the set of the players whose history holds the archived game, the game is deleted
+	// once the last of them trims it off the history.
+	archivePlayersKeyPrefix = "archive_players:"
+)
+
+type ArchiveRepository interface {
+	Save(ctx context.Context, game *entity.ArchivedGame, playerIDs []string) error
+	GetByID(ctx context.Context, id string) (*entity.ArchivedGame, error)
+	GetByPlayerID(ctx context.Context, playerID string, offset, limit int) ([]*entity.ArchivedGame, int, error)
+}
+
+type archiveRepository struct {
+	client *redis.Client
+
+	retention config.Archive
+}
+
+func NewArchiveRepository(client *redis.Client, retention config.Archive) ArchiveRepository {
+	return &archiveRepository{
+		client:    client,
+		retention: retention,
+	}
+}
+
+// Save - stores the archived game and adds it to the history of every player by the private IDs.
+// The record expires after the retention TTL, the history of a player is trimmed to the latest games
+// and a game trimmed off the history of every player is deleted.
+func (that *archiveRepository) Save(ctx context.Context, game *entity.ArchivedGame, playerIDs []string) error {
+	gameJSON, err := json.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("could not marshal archived game: %w", err)
+	}
+
+	_, err = that.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, archiveKeyPrefix+game.ID, gameJSON, that.retention.TTL)
+
+		for _, playerID := range playerIDs {
+			indexKey := archiveIndexKeyPrefix + playerID
+
+			pipe.ZAdd(ctx, indexKey, redis.Z{Score: float64(game.FinishedAt.UnixMilli()), Member: game.ID})
+			pipe.SAdd(ctx, archivePlayersKeyPrefix+game.ID, playerID)
+
+			if that.retention.TTL > 0 {
+				pipe.Expire(ctx, indexKey, that.retention.TTL)
+				pipe.Expire(ctx, archivePlayersKeyPrefix+game.ID, that.retention.TTL)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive game: %w", err)
+	}
+
+	for _, playerID := range playerIDs {
+		if err = that.trim(ctx, playerID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetByID - returns the archived game, games past the retention are not found.
+func (that *archiveRepository) GetByID(ctx context.Context, id string) (*entity.ArchivedGame, error) {
+	response, err := that.client.Get(ctx, archiveKeyPrefix+id).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrGameNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get archived game by ID: %w", err)
+	}
+
+	var game entity.ArchivedGame
+	if err = json.Unmarshal([]byte(response), &game); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal archived game: %w", err)
+	}
+
+	return &game, nil
+}
+
+// GetByPlayerID - returns the page of the history of the player, the latest game first, and the number of games in it.
+func (that *archiveRepository) GetByPlayerID(
+	ctx context.Context, playerID string, offset, limit int,
+) ([]*entity.ArchivedGame, int, error) {
+	indexKey := archiveIndexKeyPrefix + playerID
+
+	if err := that.trim(ctx, playerID); err != nil {
+		return nil, 0, err
+	}
+
+	var total *redis.IntCmd
+	var ids *redis.StringSliceCmd
+
+	_, err := that.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		total = pipe.ZCard(ctx, indexKey)
+		ids = pipe.ZRevRange(ctx, indexKey, int64(offset), int64(offset+limit-1))
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get archived game IDs: %w", err)
+	}
+
+	if len(ids.Val()) == 0 {
+		return []*entity.ArchivedGame{}, int(total.Val()), nil
+	}
+
+	keys := make([]string, 0, len(ids.Val()))
+	for _, id := range ids.Val() {
+		keys = append(keys, archiveKeyPrefix+id)
+	}
+
+	records, err := that.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get archived games: %w", err)
+	}
+
+	games := make([]*entity.ArchivedGame, 0, len(records))
+	for _, record := range records {
+		// the record expired before the index was trimmed
+		data, ok := record.(string)
+		if !ok {
+			continue
+		}
+
+		var game entity.ArchivedGame
+		if err = json.Unmarshal([]byte(data), &game); err != nil {
+			return nil, 0, fmt.Errorf("failed to unmarshal archived game: %w", err)
+		}
+
+		games = append(games, &game)
+	}
+
+	return games, int(total.Val()), nil
+}
+
+// trim - drops the games past the retention from the history of the player: the expired ones and the ones over
+// the limit. A game over the limit is deleted with the history of the last player that held it, so it is not
+// readable nor replayable by its ID any more. Games archived without the set of their players expire with the TTL.
+func (that *archiveRepository) trim(ctx context.Context, playerID string) error {
+	indexKey := archiveIndexKeyPrefix + playerID
+
+	if that.retention.TTL > 0 {
+		expiredBefore := time.Now().Add(-that.retention.TTL).UnixMilli()
+		if err := that.client.ZRemRangeByScore(ctx, indexKey, "-inf", "("+strconv.FormatInt(expiredBefore, 10)).Err(); err != nil {
+			return fmt.Errorf("failed to drop expired archived games: %w", err)
+		}
+	}
+
+	if that.retention.MaxGamesPerPlayer <= 0 {
+		return nil
+	}
+
+	dropped, err := that.client.ZRange(ctx, indexKey, 0, int64(-that.retention.MaxGamesPerPlayer-1)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get archived games over the limit: %w", err)
+	}
+
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	released := make(map[string]*redis.IntCmd, len(dropped))
+	remaining := make(map[string]*redis.IntCmd, len(dropped))
+
+	_, err = that.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range dropped {
+			pipe.ZRem(ctx, indexKey, id)
+			released[id] = pipe.SRem(ctx, archivePlayersKeyPrefix+id, playerID)
+			remaining[id] = pipe.SCard(ctx, archivePlayersKeyPrefix+id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop archived games over the limit: %w", err)
+	}
+
+	// a game no history holds any more is never added to one again, a concurrent trim deletes it just the same
+	orphaned := make([]string, 0, len(dropped))
+	for _, id := range dropped {
+		if released[id].Val() == 1 && remaining[id].Val() == 0 {
+			orphaned = append(orphaned, archiveKeyPrefix+id)
+		}
+	}
+
+	if len(orphaned) == 0 {
+		return nil
+	}
+
+	if err = that.client.Del(ctx, orphaned...).Err(); err != nil {
+		return fmt.Errorf("failed to delete archived games: %w", err)
+	}
+
+	return nil
+}
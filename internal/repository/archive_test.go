@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+	"github.com/rocketscienceinc/tictactoe-backend/testing/suite"
+)
+
+func newArchivedGame(id string, finishedAt time.Time) *entity.ArchivedGame {
+	return &entity.ArchivedGame{
+		ID:         id,
+		Board:      []string{"X", "X", "X", "O", "O", "", "", "", ""},
+		Winner:     entity.PlayerX,
+		Status:     entity.StatusFinished,
+		Players:    []*entity.Player{{PublicID: "pub1", Mark: entity.PlayerX}, {PublicID: "pub2", Mark: entity.PlayerO}},
+		Type:       entity.PrivateType,
+		StartedAt:  finishedAt.Add(-time.Minute),
+		FinishedAt: finishedAt,
+		DurationMs: time.Minute.Milliseconds(),
+	}
+}
+
+func TestArchiveRepository_GetByPlayerID(t *testing.T) {
+	t.Run("Pages through the games of the player, the latest first", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		archiveRepo := NewArchiveRepository(st.Storage, config.Archive{TTL: time.Hour})
+
+		// Given: five games of p1, the last two of them against p2
+		now := time.Now()
+		for i := range 5 {
+			players := []string{"p1"}
+			if i >= 3 {
+				players = append(players, "p2")
+			}
+			require.NoError(t, archiveRepo.Save(ctx, newArchivedGame(fmt.Sprintf("g%d", i), now.Add(time.Duration(i)*time.Second)), players))
+		}
+
+		// When: GetByPlayerID is called for the second page of two games
+		games, total, err := archiveRepo.GetByPlayerID(ctx, "p1", 2, 2)
+
+		// Then: the games in the middle of the history should be returned
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+		require.Len(t, games, 2)
+		assert.Equal(t, "g2", games[0].ID)
+		assert.Equal(t, "g1", games[1].ID)
+		assert.Equal(t, entity.PlayerX, games[0].Winner)
+
+		opponentGames, opponentTotal, err := archiveRepo.GetByPlayerID(ctx, "p2", 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 2, opponentTotal)
+		assert.Equal(t, "g4", opponentGames[0].ID)
+	})
+
+	t.Run("Keeps only the latest games of the player", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		archiveRepo := NewArchiveRepository(st.Storage, config.Archive{TTL: time.Hour, MaxGamesPerPlayer: 3})
+
+		// Given: one game more than the player may keep
+		now := time.Now()
+		for i := range 4 {
+			require.NoError(t, archiveRepo.Save(ctx, newArchivedGame(fmt.Sprintf("g%d", i), now.Add(time.Duration(i)*time.Second)), []string{"p1"}))
+		}
+
+		// When: GetByPlayerID is called for the whole history
+		games, total, err := archiveRepo.GetByPlayerID(ctx, "p1", 0, 10)
+
+		// Then: the oldest game should be gone
+		require.NoError(t, err)
+		assert.Equal(t, 3, total)
+		require.Len(t, games, 3)
+		assert.Equal(t, "g1", games[2].ID)
+	})
+
+	t.Run("Deletes a game once no history holds it", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		archiveRepo := NewArchiveRepository(st.Storage, config.Archive{TTL: time.Hour, MaxGamesPerPlayer: 1})
+
+		// Given: a game of p1 against p2, then a later game of p1 alone
+		now := time.Now()
+		require.NoError(t, archiveRepo.Save(ctx, newArchivedGame("shared", now), []string{"p1", "p2"}))
+		require.NoError(t, archiveRepo.Save(ctx, newArchivedGame("p1-later", now.Add(time.Second)), []string{"p1"}))
+
+		// Then: the shared game should stay readable while the history of p2 holds it
+		_, err := archiveRepo.GetByID(ctx, "shared")
+		require.NoError(t, err)
+
+		// When: a later game of p2 trims it off the history of p2 too
+		require.NoError(t, archiveRepo.Save(ctx, newArchivedGame("p2-later", now.Add(2*time.Second)), []string{"p2"}))
+
+		// Then: the shared game should be gone
+		_, err = archiveRepo.GetByID(ctx, "shared")
+		require.ErrorIs(t, err, ErrGameNotFound)
+
+		games, total, err := archiveRepo.GetByPlayerID(ctx, "p2", 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, games, 1)
+		assert.Equal(t, "p2-later", games[0].ID)
+	})
+
+	t.Run("Drops the games past the retention", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		archiveRepo := NewArchiveRepository(st.Storage, config.Archive{TTL: time.Hour})
+
+		// Given: a game that ended before the retention and a recent one
+		require.NoError(t, archiveRepo.Save(ctx, newArchivedGame("old", time.Now().Add(-2*time.Hour)), []string{"p1"}))
+		require.NoError(t, archiveRepo.Save(ctx, newArchivedGame("new", time.Now()), []string{"p1"}))
+
+		// When: GetByPlayerID is called
+		games, total, err := archiveRepo.GetByPlayerID(ctx, "p1", 0, 10)
+
+		// Then: only the recent game should be listed
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, games, 1)
+		assert.Equal(t, "new", games[0].ID)
+	})
+
+	t.Run("Returns an empty page for a player without games", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		archiveRepo := NewArchiveRepository(st.Storage, config.Archive{})
+
+		// When: GetByPlayerID is called for a player who never finished a game
+		games, total, err := archiveRepo.GetByPlayerID(ctx, "nobody", 0, 10)
+
+		// Then: the page should be empty
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, games)
+	})
+}
+
+func TestArchiveRepository_GetByID(t *testing.T) {
+	t.Run("GetByID_Success", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		archiveRepo := NewArchiveRepository(st.Storage, config.Archive{TTL: time.Hour})
+
+		// Given: an archived game with its moves
+		game := newArchivedGame("g1", time.Now().UTC())
+		game.Moves = []entity.Move{{Seq: 1, Mark: entity.PlayerX, Cell: 0, PlayerID: "pub1", PlayedAt: game.StartedAt}}
+		require.NoError(t, archiveRepo.Save(ctx, game, []string{"p1"}))
+
+		// When: GetByID is called
+		archived, err := archiveRepo.GetByID(ctx, "g1")
+
+		// Then: the game should be returned with its moves
+		require.NoError(t, err)
+		assert.Equal(t, game.Board, archived.Board)
+		assert.Equal(t, game.Moves[0].Cell, archived.Moves[0].Cell)
+		assert.Equal(t, "pub1", archived.Players[0].PublicID)
+	})
+
+	t.Run("GetByID_NotFound", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		archiveRepo := NewArchiveRepository(st.Storage, config.Archive{})
+
+		// When: GetByID is called with an ID that was never archived
+		_, err := archiveRepo.GetByID(ctx, "missing")
+
+		// Then: ErrGameNotFound should be returned
+		require.ErrorIs(t, err, ErrGameNotFound)
+	})
+}
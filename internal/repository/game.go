@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
@@ -13,13 +15,21 @@ import (
 	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
 )
 
-var ErrGameNotFound = errors.New("game not found")
+var ErrGameNotFound = apperror.ErrGameNotFound
+
+// clockDeadlinesKey - the sorted set of the timed games in play scored by the deadline of the player to move
+// in milliseconds, it lets the server find the games whose clocks ran out, after a restart too.
+const clockDeadlinesKey = "clock_deadlines"
 
 type GameRepository interface {
 	CreateOrUpdate(ctx context.Context, game *entity.Game) error
 
 	GetByID(ctx context.Context, id string) (*entity.Game, error)
-	GetOpenPublicGame(ctx context.Context) (*entity.Game, error)
+	GetOpenPublicGame(ctx context.Context, settings entity.GameSettings) (*entity.Game, error)
+	GetTimedOut(ctx context.Context, now time.Time) ([]*entity.Game, error)
+
+	UpdateIfDeadline(ctx context.Context, game *entity.Game, deadline time.Time) error
+	UpdateIfMoves(ctx context.Context, game *entity.Game, moves int) error
 
 	DeleteByID(ctx context.Context, id string) error
 }
@@ -41,6 +51,7 @@ func NewGameRepository(logger *slog.Logger, client *redis.Client) GameRepository
 // Note:
 // If the game is public, it adds it to the setList of public games.
 // This solution is used to be able to retrieve all public games that can be connected.
+// The deadline of a timed game is indexed the same way, so its clock is watched until the game is over.
 func (that *gameRepository) CreateOrUpdate(ctx context.Context, game *entity.Game) error {
 	gameJSON, err := json.Marshal(game)
 	if err != nil {
@@ -59,13 +70,35 @@ func (that *gameRepository) CreateOrUpdate(ctx context.Context, game *entity.Gam
 		}
 	}
 
+	if err = indexDeadline(ctx, that.client, game); err != nil {
+		return fmt.Errorf("failed to index clock deadline: %w", err)
+	}
+
 	return nil
 }
 
+// indexDeadline - keeps the deadline of the timed game in the index while its clock runs.
+func indexDeadline(ctx context.Context, client redis.Cmdable, game *entity.Game) error {
+	if game.Clock == nil {
+		return nil
+	}
+
+	if !game.IsOngoing() || game.Clock.Deadline == nil {
+		return client.ZRem(ctx, clockDeadlinesKey, game.ID).Err()
+	}
+
+	return client.ZAdd(ctx, clockDeadlinesKey, redis.Z{Score: float64(game.Clock.Deadline.UnixMilli()), Member: game.ID}).Err()
+}
+
 func (that *gameRepository) GetByID(ctx context.Context, id string) (*entity.Game, error) {
+	return getGame(ctx, that.client, id)
+}
+
+// getGame - reads the game with the client or within the transaction that watches it.
+func getGame(ctx context.Context, client redis.Cmdable, id string) (*entity.Game, error) {
 	gameKey := "game:" + id
 
-	response, err := that.client.Get(ctx, gameKey).Result()
+	response, err := client.Get(ctx, gameKey).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return nil, ErrGameNotFound
@@ -82,7 +115,92 @@ func (that *gameRepository) GetByID(ctx context.Context, id string) (*entity.Gam
 	return &game, nil
 }
 
-func (that *gameRepository) GetOpenPublicGame(ctx context.Context) (*entity.Game, error) {
+// UpdateIfDeadline - stores the timed game only if the stored one still runs the clock deadline it was read with.
+// A move or an end stored since changes the deadline, so it is not overwritten and ErrGameChanged is returned,
+// ErrGameNotFound when the game was ended and deleted. The game key is watched from the check to the write.
+func (that *gameRepository) UpdateIfDeadline(ctx context.Context, game *entity.Game, deadline time.Time) error {
+	gameJSON, err := json.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("could not marshal game: %w", err)
+	}
+
+	gameKey := "game:" + game.ID
+
+	err = that.client.Watch(ctx, func(tx *redis.Tx) error {
+		stored, err := getGame(ctx, tx, game.ID)
+		if err != nil {
+			return err
+		}
+
+		if stored.Clock == nil || stored.Clock.Deadline == nil || !stored.Clock.Deadline.Equal(deadline) {
+			return apperror.ErrGameChanged
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, gameKey, gameJSON, 0)
+
+			return indexDeadline(ctx, pipe, game)
+		})
+
+		return err
+	}, gameKey)
+
+	// the game was written between the check and the write
+	if errors.Is(err, redis.TxFailedErr) {
+		return apperror.ErrGameChanged
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to update game: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateIfMoves - stores the game only if the stored one is still in play with the number of moves it was read with.
+// A game moved in or ended since is not overwritten and ErrGameChanged is returned, ErrGameNotFound when the game
+// was ended or left and deleted. The game key is watched from the check to the write.
+func (that *gameRepository) UpdateIfMoves(ctx context.Context, game *entity.Game, moves int) error {
+	gameJSON, err := json.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("could not marshal game: %w", err)
+	}
+
+	gameKey := "game:" + game.ID
+
+	err = that.client.Watch(ctx, func(tx *redis.Tx) error {
+		stored, err := getGame(ctx, tx, game.ID)
+		if err != nil {
+			return err
+		}
+
+		if !stored.IsOngoing() || len(stored.Moves) != moves {
+			return apperror.ErrGameChanged
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, gameKey, gameJSON, 0)
+
+			return indexDeadline(ctx, pipe, game)
+		})
+
+		return err
+	}, gameKey)
+
+	// the game was written between the check and the write
+	if errors.Is(err, redis.TxFailedErr) {
+		return apperror.ErrGameChanged
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to update game: %w", err)
+	}
+
+	return nil
+}
+
+// GetOpenPublicGame - returns a public game with the settings that waits for the second player.
+func (that *gameRepository) GetOpenPublicGame(ctx context.Context, settings entity.GameSettings) (*entity.Game, error) {
 	log := that.logger.With("method", "GetLastActivePublicGame")
 
 	gameIDs, err := that.client.SMembers(ctx, entity.PublicType).Result()
@@ -97,7 +215,7 @@ func (that *gameRepository) GetOpenPublicGame(ctx context.Context) (*entity.Game
 			continue
 		}
 
-		if !game.IsWaiting() {
+		if !game.IsWaiting() || game.Settings() != settings.WithDefaults() {
 			continue
 		}
 
@@ -113,9 +231,48 @@ func (that *gameRepository) GetOpenPublicGame(ctx context.Context) (*entity.Game
 	return publicGames[len(publicGames)-1], nil
 }
 
+// GetTimedOut - returns the games whose deadlines have passed by now.
+// Deadlines of games that are gone are dropped from the index.
+func (that *gameRepository) GetTimedOut(ctx context.Context, now time.Time) ([]*entity.Game, error) {
+	log := that.logger.With("method", "GetTimedOut")
+
+	gameIDs, err := that.client.ZRangeByScore(ctx, clockDeadlinesKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timed out game IDs: %w", err)
+	}
+
+	games := make([]*entity.Game, 0, len(gameIDs))
+	for _, id := range gameIDs {
+		game, err := that.GetByID(ctx, id)
+		if errors.Is(err, ErrGameNotFound) {
+			log.Warn("dropping deadline of missing game", "gameID", id)
+			if err = that.client.ZRem(ctx, clockDeadlinesKey, id).Err(); err != nil {
+				return nil, fmt.Errorf("failed to drop clock deadline: %w", err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get timed out game: %w", err)
+		}
+
+		games = append(games, game)
+	}
+
+	return games, nil
+}
+
 func (that *gameRepository) DeleteByID(ctx context.Context, id string) error {
 	gameKey := "game:" + id
 
+	if err := that.client.ZRem(ctx, clockDeadlinesKey, id).Err(); err != nil {
+		return fmt.Errorf("failed to drop clock deadline: %w", err)
+	}
+
 	result, err := that.client.Del(ctx, gameKey).Result()
 	if err != nil {
 		return fmt.Errorf("failed to delete game by ID: %w", err)
@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -148,7 +150,7 @@ func TestGameRepository_GetWaitingPublicGame(t *testing.T) {
 		require.NoError(t, err)
 
 		// When: GetWaitingPublicGame is called
-		game, err := gameRepo.GetOpenPublicGame(ctx)
+		game, err := gameRepo.GetOpenPublicGame(ctx, entity.DefaultGameSettings())
 
 		// Then: the retrieved game should match the existing game
 		require.NoError(t, err)
@@ -170,7 +172,7 @@ func TestGameRepository_GetWaitingPublicGame(t *testing.T) {
 		err := gameRepo.CreateOrUpdate(ctx, existingGame)
 		require.NoError(t, err)
 
-		game, err := gameRepo.GetOpenPublicGame(ctx)
+		game, err := gameRepo.GetOpenPublicGame(ctx, entity.DefaultGameSettings())
 
 		// Then: an ErrNoActiveGames error should be returned and no game should be retrieved
 		require.Error(t, err)
@@ -178,3 +180,247 @@ func TestGameRepository_GetWaitingPublicGame(t *testing.T) {
 		require.Nil(t, game)
 	})
 }
+
+func TestGameRepository_GetTimedOut(t *testing.T) {
+	t.Run("Returns the games whose clocks ran out", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: a timed game that started a minute ago with ten seconds a move, one with a minute ahead of it,
+		// and an untimed game
+		flagged := entity.NewGameWithSettings("flagged", entity.PrivateType, entity.GameSettings{TimeControl: entity.TimeControl{MoveLimitMs: 10000}})
+		flagged.Start()
+		deadline := time.Now().Add(-time.Minute)
+		flagged.Clock.Deadline = &deadline
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, flagged))
+
+		running := entity.NewGameWithSettings("running", entity.PrivateType, entity.GameSettings{TimeControl: entity.TimeControl{MoveLimitMs: 60000}})
+		running.Start()
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, running))
+
+		untimed := entity.NewGame("untimed", entity.PrivateType)
+		untimed.Start()
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, untimed))
+
+		// When: GetTimedOut is called
+		games, err := gameRepo.GetTimedOut(ctx, time.Now())
+
+		// Then: only the game past its deadline should be returned, with its clock
+		require.NoError(t, err)
+		require.Len(t, games, 1)
+		assert.Equal(t, "flagged", games[0].ID)
+		require.NotNil(t, games[0].Clock)
+		assert.True(t, games[0].IsTimedOut(time.Now()))
+	})
+
+	t.Run("Forgets the deadlines of games that are over", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: two timed games, one of them finished and the other one deleted
+		finished := entity.NewGameWithSettings("finished", entity.PrivateType, entity.GameSettings{TimeControl: entity.TimeControl{MoveLimitMs: 10000}})
+		finished.Start()
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, finished))
+		finished.TimeOut()
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, finished))
+
+		deleted := entity.NewGameWithSettings("deleted", entity.PrivateType, entity.GameSettings{TimeControl: entity.TimeControl{MoveLimitMs: 10000}})
+		deleted.Start()
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, deleted))
+		require.NoError(t, gameRepo.DeleteByID(ctx, "deleted"))
+
+		// When: GetTimedOut is called after both deadlines
+		games, err := gameRepo.GetTimedOut(ctx, time.Now().Add(time.Minute))
+
+		// Then: no game should be returned
+		require.NoError(t, err)
+		assert.Empty(t, games)
+	})
+}
+
+func TestGameRepository_UpdateIfDeadline(t *testing.T) {
+	// storeFlagged - stores a timed game whose clock ran out a minute ago and returns it as the clock monitor reads it
+	storeFlagged := func(t *testing.T, gameRepo GameRepository) *entity.Game {
+		t.Helper()
+
+		ctx := context.Background()
+
+		flagged := entity.NewGameWithSettings("flagged", entity.PrivateType, entity.GameSettings{TimeControl: entity.TimeControl{MoveLimitMs: 10000}})
+		flagged.Start()
+		deadline := time.Now().Add(-time.Minute)
+		flagged.Clock.Deadline = &deadline
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, flagged))
+
+		games, err := gameRepo.GetTimedOut(ctx, time.Now())
+		require.NoError(t, err)
+		require.Len(t, games, 1)
+
+		return games[0]
+	}
+
+	t.Run("Stores the game the clock still runs for", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: a game read with the deadline it still runs to
+		game := storeFlagged(t, gameRepo)
+		deadline := *game.Clock.Deadline
+
+		// When: the game is lost on time and stored
+		game.TimeOut()
+		err := gameRepo.UpdateIfDeadline(ctx, game, deadline)
+
+		// Then: the stored game should be over and its deadline forgotten
+		require.NoError(t, err)
+
+		stored, err := gameRepo.GetByID(ctx, "flagged")
+		require.NoError(t, err)
+		assert.Equal(t, entity.FinishReasonTimeout, stored.FinishReason)
+
+		games, err := gameRepo.GetTimedOut(ctx, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		assert.Empty(t, games)
+	})
+
+	t.Run("Keeps a move stored between the read and the write", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: a game read by the clock monitor, then moved in and stored by the player
+		game := storeFlagged(t, gameRepo)
+		deadline := *game.Clock.Deadline
+
+		moved, err := gameRepo.GetByID(ctx, "flagged")
+		require.NoError(t, err)
+		require.NoError(t, moved.MakeMove(entity.PlayerX, 4, ""))
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, moved))
+
+		// When: the monitor stores the game it read as lost on time
+		game.TimeOut()
+		err = gameRepo.UpdateIfDeadline(ctx, game, deadline)
+
+		// Then: the write should be refused and the move kept
+		require.ErrorIs(t, err, apperror.ErrGameChanged)
+
+		stored, err := gameRepo.GetByID(ctx, "flagged")
+		require.NoError(t, err)
+		assert.True(t, stored.IsOngoing())
+		assert.Equal(t, entity.PlayerX, stored.Board[4])
+		assert.Equal(t, entity.PlayerO, stored.Turn)
+	})
+
+	t.Run("Does not bring back a game that was ended", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: a game read by the clock monitor, then ended and deleted
+		game := storeFlagged(t, gameRepo)
+		deadline := *game.Clock.Deadline
+		require.NoError(t, gameRepo.DeleteByID(ctx, "flagged"))
+
+		// When: the monitor stores the game it read as lost on time
+		game.TimeOut()
+		err := gameRepo.UpdateIfDeadline(ctx, game, deadline)
+
+		// Then: the game should stay gone
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+
+		_, err = gameRepo.GetByID(ctx, "flagged")
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+	})
+}
+
+func TestGameRepository_UpdateIfMoves(t *testing.T) {
+	// storeBotGame - stores a game against the bot with the first move of X and returns it as the bot reads it
+	storeBotGame := func(t *testing.T, gameRepo GameRepository) *entity.Game {
+		t.Helper()
+
+		ctx := context.Background()
+
+		game := entity.NewGame("bot-game", entity.WithBotType)
+		game.Players = []*entity.Player{
+			{ID: "p1", GameID: game.ID, Mark: entity.PlayerX},
+			entity.NewBotPlayer(game.ID, entity.PlayerO),
+		}
+		game.Start()
+		require.NoError(t, game.MakeTurn(entity.PlayerX, 0))
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, game))
+
+		stored, err := gameRepo.GetByID(ctx, game.ID)
+		require.NoError(t, err)
+
+		return stored
+	}
+
+	t.Run("Stores the game read with the moves", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: a game read with its single move
+		game := storeBotGame(t, gameRepo)
+
+		// When: the bot moves and stores the game
+		require.NoError(t, game.MakeTurn(entity.PlayerO, 4))
+		err := gameRepo.UpdateIfMoves(ctx, game, 1)
+
+		// Then: the move should be stored
+		require.NoError(t, err)
+
+		stored, err := gameRepo.GetByID(ctx, "bot-game")
+		require.NoError(t, err)
+		assert.Len(t, stored.Moves, 2)
+		assert.Equal(t, entity.PlayerO, stored.Board[4])
+	})
+
+	t.Run("Keeps a move stored between the read and the write", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: a game read by the bot, then moved in and stored by another writer
+		game := storeBotGame(t, gameRepo)
+
+		moved, err := gameRepo.GetByID(ctx, "bot-game")
+		require.NoError(t, err)
+		require.NoError(t, moved.MakeTurn(entity.PlayerO, 8))
+		require.NoError(t, gameRepo.CreateOrUpdate(ctx, moved))
+
+		// When: the bot stores its move over the game it read
+		require.NoError(t, game.MakeTurn(entity.PlayerO, 4))
+		err = gameRepo.UpdateIfMoves(ctx, game, 1)
+
+		// Then: the write should be refused and the other move kept
+		require.ErrorIs(t, err, apperror.ErrGameChanged)
+
+		stored, err := gameRepo.GetByID(ctx, "bot-game")
+		require.NoError(t, err)
+		assert.Equal(t, entity.PlayerO, stored.Board[8])
+		assert.Empty(t, stored.Board[4])
+	})
+
+	t.Run("Does not bring back a game that was removed", func(t *testing.T) {
+		ctx, st := suite.New(t)
+
+		gameRepo := NewGameRepository(getLogger(), st.Storage)
+
+		// Given: a game read by the bot, then left by the player and deleted
+		game := storeBotGame(t, gameRepo)
+		require.NoError(t, gameRepo.DeleteByID(ctx, "bot-game"))
+
+		// When: the bot stores its move
+		require.NoError(t, game.MakeTurn(entity.PlayerO, 4))
+		err := gameRepo.UpdateIfMoves(ctx, game, 1)
+
+		// Then: the game should stay gone
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+
+		_, err = gameRepo.GetByID(ctx, "bot-game")
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+	})
+}
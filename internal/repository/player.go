@@ -2,20 +2,35 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/redis/go-redis/v9"
 
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
 )
 
-var ErrPlayerNotFound = errors.New("player not found")
+const (
+	playerKeyPrefix = "player:"
+	// publicIDKeyPrefix indexes the private player IDs by their public IDs, it must not match playerKeyPrefix + "*".
+	publicIDKeyPrefix = "player_public:"
+
+	migrationScanCount = 100
+)
+
+var ErrPlayerNotFound = apperror.ErrPlayerNotFound
 
 type PlayerRepository interface {
 	CreateOrUpdate(ctx context.Context, player *entity.Player) error
 	GetByID(ctx context.Context, id string) (*entity.Player, error)
+	GetByPublicID(ctx context.Context, publicID string) (*entity.Player, error)
+
+	MigratePublicIDs(ctx context.Context) (int, error)
 }
 
 type playerRepository struct {
@@ -28,14 +43,28 @@ func NewPlayerRepository(client *redis.Client) PlayerRepository {
 	}
 }
 
+// CreateOrUpdate - creates or updates a player object.
+// A player without a public ID gets a new one, the public ID is indexed to find the player by it.
 func (that *playerRepository) CreateOrUpdate(ctx context.Context, player *entity.Player) error {
+	if player.PublicID == "" {
+		publicID, err := newPublicID()
+		if err != nil {
+			return err
+		}
+		player.PublicID = publicID
+	}
+
 	playerJSON, err := json.Marshal(player)
 	if err != nil {
 		return fmt.Errorf("failed to marshal player: %w", err)
 	}
 
-	playerKey := "player:" + player.ID
-	err = that.client.Set(ctx, playerKey, playerJSON, 0).Err()
+	_, err = that.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, playerKeyPrefix+player.ID, playerJSON, 0)
+		pipe.Set(ctx, publicIDKeyPrefix+player.PublicID, player.ID, 0)
+
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create player: %w", err)
 	}
@@ -43,10 +72,110 @@ func (that *playerRepository) CreateOrUpdate(ctx context.Context, player *entity
 	return nil
 }
 
+// GetByID - returns the player by the private ID.
+// Players stored before public IDs were introduced get one on the first read.
 func (that *playerRepository) GetByID(ctx context.Context, id string) (*entity.Player, error) {
-	playerKey := "player:" + id
+	player, err := that.get(ctx, that.client, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if player.PublicID != "" {
+		return player, nil
+	}
+
+	return that.migratePlayer(ctx, id)
+}
 
-	response, err := that.client.Get(ctx, playerKey).Result()
+func (that *playerRepository) GetByPublicID(ctx context.Context, publicID string) (*entity.Player, error) {
+	id, err := that.client.Get(ctx, publicIDKeyPrefix+publicID).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrPlayerNotFound
+		}
+		return nil, fmt.Errorf("failed to get player by public ID: %w", err)
+	}
+
+	return that.GetByID(ctx, id)
+}
+
+// MigratePublicIDs - gives a public ID to every stored player that has none and returns the number of migrated players.
+// It is safe to run while the server is serving players, GetByID migrates the players it reads the same way.
+func (that *playerRepository) MigratePublicIDs(ctx context.Context) (int, error) {
+	migrated := 0
+
+	iter := that.client.Scan(ctx, 0, playerKeyPrefix+"*", migrationScanCount).Iterator()
+	for iter.Next(ctx) {
+		id := strings.TrimPrefix(iter.Val(), playerKeyPrefix)
+
+		player, err := that.get(ctx, that.client, id)
+		if err != nil {
+			if errors.Is(err, ErrPlayerNotFound) {
+				continue
+			}
+			return migrated, err
+		}
+
+		if player.PublicID != "" {
+			continue
+		}
+
+		if _, err = that.migratePlayer(ctx, id); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	if err := iter.Err(); err != nil {
+		return migrated, fmt.Errorf("failed to scan players: %w", err)
+	}
+
+	return migrated, nil
+}
+
+// migratePlayer - gives the player a public ID unless a concurrent migration already did.
+func (that *playerRepository) migratePlayer(ctx context.Context, id string) (*entity.Player, error) {
+	var player *entity.Player
+
+	err := that.client.Watch(ctx, func(tx *redis.Tx) error {
+		var err error
+
+		player, err = that.get(ctx, tx, id)
+		if err != nil || player.PublicID != "" {
+			return err
+		}
+
+		if player.PublicID, err = newPublicID(); err != nil {
+			return err
+		}
+
+		playerJSON, err := json.Marshal(player)
+		if err != nil {
+			return fmt.Errorf("failed to marshal player: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, playerKeyPrefix+id, playerJSON, 0)
+			pipe.Set(ctx, publicIDKeyPrefix+player.PublicID, id, 0)
+
+			return nil
+		})
+
+		return err
+	}, playerKeyPrefix+id)
+	if errors.Is(err, redis.TxFailedErr) {
+		// the player changed while being migrated, the concurrent writer has given it a public ID
+		return that.get(ctx, that.client, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate player: %w", err)
+	}
+
+	return player, nil
+}
+
+func (that *playerRepository) get(ctx context.Context, client redis.Cmdable, id string) (*entity.Player, error) {
+	response, err := client.Get(ctx, playerKeyPrefix+id).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return nil, ErrPlayerNotFound
@@ -61,3 +190,13 @@ func (that *playerRepository) GetByID(ctx context.Context, id string) (*entity.P
 
 	return &existingPlayer, nil
 }
+
+// newPublicID - generates a public ID, it is unrelated to the private ID so it cannot be used to derive it.
+func newPublicID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate public ID: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -65,3 +65,56 @@ func TestPlayerRepository_GetByID(t *testing.T) {
 		assert.Nil(t, retrievedPlayer)
 	})
 }
+
+func TestPlayerRepository_GetByPublicID(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	playerRepo := NewPlayerRepository(st.Storage)
+
+	// Given: a stored player, CreateOrUpdate gives it a public ID
+	player := &entity.Player{
+		ID: "123",
+	}
+
+	err := playerRepo.CreateOrUpdate(ctx, player)
+	require.NoError(t, err)
+	require.NotEmpty(t, player.PublicID)
+	require.NotEqual(t, player.ID, player.PublicID)
+
+	// When: GetByPublicID is called with the public ID
+	retrievedPlayer, err := playerRepo.GetByPublicID(ctx, player.PublicID)
+
+	// Then: the player with the private ID should be returned
+	require.NoError(t, err)
+	assert.Equal(t, player.ID, retrievedPlayer.ID)
+}
+
+func TestPlayerRepository_MigratePublicIDs(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	playerRepo := NewPlayerRepository(st.Storage)
+
+	// Given: players stored before public IDs existed
+	require.NoError(t, st.Storage.Set(ctx, "player:old1", `{"id":"old1","game_id":"g1"}`, 0).Err())
+	require.NoError(t, st.Storage.Set(ctx, "player:old2", `{"id":"old2"}`, 0).Err())
+
+	// When: the migration runs twice
+	migrated, err := playerRepo.MigratePublicIDs(ctx)
+	require.NoError(t, err)
+
+	migratedAgain, err := playerRepo.MigratePublicIDs(ctx)
+	require.NoError(t, err)
+
+	// Then: every player should be migrated once and keep its data
+	assert.Equal(t, 2, migrated)
+	assert.Equal(t, 0, migratedAgain)
+
+	player, err := playerRepo.GetByID(ctx, "old1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, player.PublicID)
+	assert.Equal(t, "g1", player.GameID)
+
+	byPublicID, err := playerRepo.GetByPublicID(ctx, player.PublicID)
+	require.NoError(t, err)
+	assert.Equal(t, "old1", byPublicID.ID)
+}
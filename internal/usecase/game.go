@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
@@ -15,6 +16,13 @@ import (
 
 const lettersAndNumbers = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+const (
+	// DefaultHistoryLimit - how many games a page of the history holds when the client does not ask for a size.
+	DefaultHistoryLimit = 20
+	// MaxHistoryLimit - the largest page of the history a client may ask for.
+	MaxHistoryLimit = 50
+)
+
 type playerRepoDep interface {
 	CreateOrUpdate(ctx context.Context, player *entity.Player) error
 	GetByID(ctx context.Context, id string) (*entity.Player, error)
@@ -24,20 +32,32 @@ type gameRepoDep interface {
 	CreateOrUpdate(ctx context.Context, game *entity.Game) error
 
 	GetByID(ctx context.Context, id string) (*entity.Game, error)
-	GetOpenPublicGame(ctx context.Context) (*entity.Game, error)
+	GetOpenPublicGame(ctx context.Context, settings entity.GameSettings) (*entity.Game, error)
+	GetTimedOut(ctx context.Context, now time.Time) ([]*entity.Game, error)
+
+	UpdateIfDeadline(ctx context.Context, game *entity.Game, deadline time.Time) error
+	UpdateIfMoves(ctx context.Context, game *entity.Game, moves int) error
 
 	DeleteByID(ctx context.Context, id string) error
 }
 
+type archiveRepoDep interface {
+	Save(ctx context.Context, game *entity.ArchivedGame, playerIDs []string) error
+	GetByID(ctx context.Context, id string) (*entity.ArchivedGame, error)
+	GetByPlayerID(ctx context.Context, playerID string, offset, limit int) ([]*entity.ArchivedGame, int, error)
+}
+
 type gameUseCase struct {
-	playerRepo playerRepoDep
-	gameRepo   gameRepoDep
+	playerRepo  playerRepoDep
+	gameRepo    gameRepoDep
+	archiveRepo archiveRepoDep
 }
 
-func NewGameUseCase(playerRepo playerRepoDep, gameRepo gameRepoDep) *gameUseCase { //nolint: revive // it's ok
+func NewGameUseCase(playerRepo playerRepoDep, gameRepo gameRepoDep, archiveRepo archiveRepoDep) *gameUseCase { //nolint: revive // it's ok
 	return &gameUseCase{
-		playerRepo: playerRepo,
-		gameRepo:   gameRepo,
+		playerRepo:  playerRepo,
+		gameRepo:    gameRepo,
+		archiveRepo: archiveRepo,
 	}
 }
 
@@ -61,14 +81,26 @@ func (that *gameUseCase) GetOrCreatePlayer(ctx context.Context, playerID string)
 	return player, nil
 }
 
-func (that *gameUseCase) GetOrCreateGame(ctx context.Context, playerID, gameType, difficulty string) (*entity.Game, error) {
+// GetOrCreateGame - returns the game of the player, a player without one gets a new game with the settings.
+func (that *gameUseCase) GetOrCreateGame(
+	ctx context.Context, playerID, gameType, difficulty string, strength int, settings entity.GameSettings,
+) (*entity.Game, error) {
+	settings = settings.WithDefaults()
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := entity.ValidateStrength(strength); err != nil {
+		return nil, err
+	}
+
 	player, err := that.getPlayerByID(ctx, playerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve player from storage: %w", err)
 	}
 
 	if player.GameID == "" {
-		game, err := that.createGame(ctx, gameType, difficulty, player)
+		game, err := that.createGame(ctx, gameType, difficulty, strength, settings, player)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create game: %w", err)
 		}
@@ -88,7 +120,7 @@ func (that *gameUseCase) addBotToGame(ctx context.Context, game *entity.Game) er
 	botPlayer := entity.NewBotPlayer(game.ID, "")
 
 	game.Players = append(game.Players, botPlayer)
-	game.Status = entity.StatusOngoing
+	game.Start()
 
 	playerMark, botMark := game.GetRandomMarks()
 	for _, player := range game.Players {
@@ -105,12 +137,6 @@ func (that *gameUseCase) addBotToGame(ctx context.Context, game *entity.Game) er
 		return fmt.Errorf("failed to update bot player: %w", err)
 	}
 
-	if botMark == entity.PlayerX {
-		if err := game.BotMakeTurn(); err != nil {
-			return fmt.Errorf("bot failed to make first turn: %w", err)
-		}
-	}
-
 	if err := that.gameRepo.CreateOrUpdate(ctx, game); err != nil {
 		return fmt.Errorf("failed to update game with bot: %w", err)
 	}
@@ -118,6 +144,16 @@ func (that *gameUseCase) addBotToGame(ctx context.Context, game *entity.Game) er
 	return nil
 }
 
+// GetGameByID - returns the live game, finished games are only found in the archive.
+func (that *gameUseCase) GetGameByID(ctx context.Context, gameID string) (*entity.Game, error) {
+	game, err := that.gameRepo.GetByID(ctx, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game by id: %w", err)
+	}
+
+	return game, nil
+}
+
 func (that *gameUseCase) GetGameByPlayerID(ctx context.Context, playerID string) (*entity.Game, error) {
 	player, err := that.getPlayerByID(ctx, playerID)
 	if err != nil {
@@ -150,7 +186,7 @@ func (that *gameUseCase) JoinGameByID(ctx context.Context, gameID, playerID stri
 	}
 
 	if len(game.Players) >= 2 {
-		return nil, fmt.Errorf("%w: game id %s", apperror.ErrGameAlreadyExists, gameID)
+		return nil, fmt.Errorf("%w: game id %s", apperror.ErrGameFull, gameID)
 	}
 
 	player.GameID = game.ID
@@ -160,7 +196,7 @@ func (that *gameUseCase) JoinGameByID(ctx context.Context, gameID, playerID stri
 		return nil, fmt.Errorf("failed to update player from storage: %w", err)
 	}
 
-	game.Status = entity.StatusOngoing
+	game.Start()
 	game.Players = append(game.Players, player)
 
 	if err = that.gameRepo.CreateOrUpdate(ctx, game); err != nil {
@@ -170,16 +206,22 @@ func (that *gameUseCase) JoinGameByID(ctx context.Context, gameID, playerID stri
 	return game, nil
 }
 
-func (that *gameUseCase) CreateOrJoinToPublicGame(ctx context.Context, playerID, gameType string) (*entity.Game, error) {
+// CreateOrJoinToPublicGame - joins an open public game with the same settings or creates one.
+func (that *gameUseCase) CreateOrJoinToPublicGame(ctx context.Context, playerID, gameType string, settings entity.GameSettings) (*entity.Game, error) {
+	settings = settings.WithDefaults()
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
 	player, err := that.getPlayerByID(ctx, playerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve player from storage: %w", err)
 	}
 
-	game, err := that.gameRepo.GetOpenPublicGame(ctx)
+	game, err := that.gameRepo.GetOpenPublicGame(ctx, settings)
 	if err != nil {
 		if errors.Is(err, apperror.ErrNoActiveGames) {
-			game, err = that.createGame(ctx, gameType, "", player)
+			game, err = that.createGame(ctx, gameType, "", 0, settings, player)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create game: %w", err)
 			}
@@ -194,7 +236,7 @@ func (that *gameUseCase) CreateOrJoinToPublicGame(ctx context.Context, playerID,
 	}
 
 	if len(game.Players) >= 2 {
-		return nil, fmt.Errorf("%w: game id %s", apperror.ErrGameAlreadyExists, game.ID)
+		return nil, fmt.Errorf("%w: game id %s", apperror.ErrGameFull, game.ID)
 	}
 
 	player.GameID = game.ID
@@ -204,7 +246,7 @@ func (that *gameUseCase) CreateOrJoinToPublicGame(ctx context.Context, playerID,
 		return nil, fmt.Errorf("failed to update player from storage: %w", err)
 	}
 
-	game.Status = entity.StatusOngoing
+	game.Start()
 	game.Players = append(game.Players, player)
 
 	if err = that.gameRepo.CreateOrUpdate(ctx, game); err != nil {
@@ -214,15 +256,18 @@ func (that *gameUseCase) CreateOrJoinToPublicGame(ctx context.Context, playerID,
 	return game, nil
 }
 
-func (that *gameUseCase) createGame(ctx context.Context, gameType, difficulty string, player *entity.Player) (*entity.Game, error) {
+func (that *gameUseCase) createGame(
+	ctx context.Context, gameType, difficulty string, strength int, settings entity.GameSettings, player *entity.Player,
+) (*entity.Game, error) {
 	gameID, err := that.generateGameID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate game ID: %w", err)
 	}
 
-	game := entity.NewGame(gameID, gameType)
+	game := entity.NewGameWithSettings(gameID, gameType, settings)
 	if game.IsWithBot() {
 		game.Difficulty = difficulty
+		game.Strength = strength
 	}
 
 	player.GameID = gameID
@@ -246,7 +291,7 @@ func (that *gameUseCase) createGame(ctx context.Context, gameType, difficulty st
 	return game, nil
 }
 
-func (that *gameUseCase) MakeTurn(ctx context.Context, playerID string, cell int) (*entity.Game, error) {
+func (that *gameUseCase) MakeTurn(ctx context.Context, playerID string, cell int, piece string) (*entity.Game, error) {
 	player, err := that.getPlayerByID(ctx, playerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve player from storage: %w", err)
@@ -257,42 +302,94 @@ func (that *gameUseCase) MakeTurn(ctx context.Context, playerID string, cell int
 		return nil, fmt.Errorf("failed to get game by id: %w", err)
 	}
 
-	if !game.IsOngoing() {
-		return nil, apperror.ErrGameAlreadyExists
+	if err = game.ConfirmOngoingState(); err != nil {
+		return nil, fmt.Errorf("failed to make turn: %w", err)
+	}
+
+	// the clock ran out before the move came, the game is lost on time whoever tried to move
+	if game.IsTimedOut(time.Now()) {
+		return that.endTimedOut(ctx, game)
 	}
 
-	if err = game.MakeTurn(player.Mark, cell); err != nil {
+	deadline, moves := clockDeadline(game), len(game.Moves)
+
+	if err = game.MakeMove(player.Mark, cell, piece); err != nil {
 		return game, fmt.Errorf("failed to make turn: %w", err)
 	}
 
-	if game.IsFinished() {
-		if err = that.EndGame(ctx, game); err != nil {
-			return game, fmt.Errorf("failed to end game: %w", err)
-		}
+	return that.saveTurn(ctx, game, deadline, moves)
+}
 
-		return game, apperror.ErrGameFinished
+// MakeBotTurn - makes the move of the bot in the game, it must be the turn of the bot.
+// The search of a strong bot takes up to a second, the game is read again after it and the move is stored only
+// over the game it was read as, so a game the player left or lost on time in the meantime is not brought back.
+func (that *gameUseCase) MakeBotTurn(ctx context.Context, gameID string) (*entity.Game, error) {
+	game, err := that.getBotTurnGame(ctx, gameID)
+	if err != nil {
+		return nil, err
 	}
 
-	if !game.IsWithBot() {
-		if err = that.gameRepo.CreateOrUpdate(ctx, game); err != nil {
-			return nil, fmt.Errorf("failed to update bot to game: %w", err)
-		}
+	cell, piece, err := game.SelectBotMove()
+	if err != nil {
+		return nil, fmt.Errorf("failed to select bot move: %w", err)
+	}
 
-		return game, nil
+	game, err = that.getBotTurnGame(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.IsTimedOut(time.Now()) {
+		return that.endTimedOut(ctx, game)
 	}
 
-	// in this case if we are playing vs bot we should make a turn after players turn.
-	if err = game.BotMakeTurn(); err != nil {
+	deadline, moves := clockDeadline(game), len(game.Moves)
+
+	if err = game.MakeMove(game.GetBotPlayer().Mark, cell, piece); err != nil {
 		return nil, fmt.Errorf("failed to make bot turn: %w", err)
 	}
 
-	// we update this for redis storage
-	if err = that.gameRepo.CreateOrUpdate(ctx, game); err != nil {
-		return nil, fmt.Errorf("failed to update game: %w", err)
+	return that.saveTurn(ctx, game, deadline, moves)
+}
+
+// getBotTurnGame - returns the game if it is in play and the bot is to move.
+func (that *gameUseCase) getBotTurnGame(ctx context.Context, gameID string) (*entity.Game, error) {
+	game, err := that.gameRepo.GetByID(ctx, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game by id: %w", err)
+	}
+
+	if err = game.ConfirmOngoingState(); err != nil {
+		return nil, fmt.Errorf("failed to make bot turn: %w", err)
+	}
+
+	botPlayer := game.GetBotPlayer()
+	if botPlayer == nil {
+		return nil, entity.ErrBotNotFound
+	}
+
+	if game.Turn != botPlayer.Mark {
+		return nil, fmt.Errorf("failed to make bot turn: %w", apperror.ErrNotYourTurn)
+	}
+
+	return game, nil
+}
+
+// saveTurn - stores the game after a move, a game the move finished is ended and ErrGameFinished is returned with it.
+// The game is stored only over the one the move was made on: a timed game while its clock still runs the deadline
+// it was read with, any other while it is in play with the moves it was read with. The move is dropped with
+// ErrGameChanged when the clock ended the game, the game was left or another move was stored in the meantime.
+func (that *gameUseCase) saveTurn(ctx context.Context, game *entity.Game, deadline *time.Time, moves int) (*entity.Game, error) {
+	if deadline != nil {
+		if err := that.updateIfDeadline(ctx, game, *deadline); err != nil {
+			return nil, err
+		}
+	} else if err := that.updateIfMoves(ctx, game, moves); err != nil {
+		return nil, err
 	}
 
 	if game.IsFinished() {
-		if err = that.EndGame(ctx, game); err != nil {
+		if err := that.EndGame(ctx, game); err != nil {
 			return game, fmt.Errorf("failed to end game: %w", err)
 		}
 
@@ -302,12 +399,73 @@ func (that *gameUseCase) MakeTurn(ctx context.Context, playerID string, cell int
 	return game, nil
 }
 
-func (that *gameUseCase) CreatePrivateGameWithTwoPlayers(ctx context.Context, player1, player2 *entity.Player) (*entity.Game, error) {
+// endTimedOut - ends the game whose clock ran out as a loss of the player to move, ErrGameFinished tells it is over.
+// The game is lost on time only if no move or end was stored since it was read, otherwise ErrGameChanged is returned.
+func (that *gameUseCase) endTimedOut(ctx context.Context, game *entity.Game) (*entity.Game, error) {
+	deadline := clockDeadline(game)
+
+	game.TimeOut()
+
+	if err := that.updateIfDeadline(ctx, game, *deadline); err != nil {
+		return nil, err
+	}
+
+	if err := that.EndGame(ctx, game); err != nil {
+		return game, fmt.Errorf("failed to end game: %w", err)
+	}
+
+	return game, apperror.ErrGameFinished
+}
+
+// updateIfDeadline - stores the timed game over the one read with the deadline. A game that was moved or ended
+// since is not overwritten, ErrGameChanged tells the write lost the race and the game went on without it.
+func (that *gameUseCase) updateIfDeadline(ctx context.Context, game *entity.Game, deadline time.Time) error {
+	return changedGameError(game, that.gameRepo.UpdateIfDeadline(ctx, game, deadline))
+}
+
+// updateIfMoves - stores the game over the one read with the number of moves, the same way as updateIfDeadline.
+func (that *gameUseCase) updateIfMoves(ctx context.Context, game *entity.Game, moves int) error {
+	return changedGameError(game, that.gameRepo.UpdateIfMoves(ctx, game, moves))
+}
+
+// changedGameError - reports a guarded write of the game refused because the game was changed or deleted
+// as ErrGameChanged.
+func changedGameError(game *entity.Game, err error) error {
+	if errors.Is(err, apperror.ErrGameChanged) || errors.Is(err, apperror.ErrGameNotFound) {
+		return fmt.Errorf("failed to update game %s: %w", game.ID, apperror.ErrGameChanged)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to update game: %w", err)
+	}
+
+	return nil
+}
+
+// clockDeadline - returns the deadline the clock of the game runs to, nil for a game without a running clock.
+func clockDeadline(game *entity.Game) *time.Time {
+	if game.Clock == nil || game.Clock.Deadline == nil {
+		return nil
+	}
+
+	deadline := *game.Clock.Deadline
+
+	return &deadline
+}
+
+func (that *gameUseCase) CreatePrivateGameWithTwoPlayers(
+	ctx context.Context, player1, player2 *entity.Player, settings entity.GameSettings,
+) (*entity.Game, error) {
+	settings = settings.WithDefaults()
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
 	gameID, err := that.generateGameID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate game ID: %w", err)
 	}
-	game := entity.NewGame(gameID, entity.PrivateType)
+	game := entity.NewGameWithSettings(gameID, entity.PrivateType, settings)
 
 	player1.GameID = game.ID
 	player2.GameID = game.ID
@@ -324,7 +482,7 @@ func (that *gameUseCase) CreatePrivateGameWithTwoPlayers(ctx context.Context, pl
 
 	game.Players = []*entity.Player{player1, player2}
 
-	game.Status = entity.StatusOngoing
+	game.Start()
 
 	if err = that.gameRepo.CreateOrUpdate(ctx, game); err != nil {
 		return nil, fmt.Errorf("failed to update game with player: %w", err)
@@ -333,7 +491,15 @@ func (that *gameUseCase) CreatePrivateGameWithTwoPlayers(ctx context.Context, pl
 	return game, nil
 }
 
+// EndGame - moves the game into the archive and frees its players for the next one.
+// Games nobody made a move in are not worth keeping and are only deleted.
 func (that *gameUseCase) EndGame(ctx context.Context, game *entity.Game) error {
+	if game.IsArchivable() {
+		if err := that.archiveRepo.Save(ctx, game.Archive(time.Now().UTC()), humanPlayerIDs(game)); err != nil {
+			return fmt.Errorf("failed to archive game: %w", err)
+		}
+	}
+
 	if err := that.gameRepo.DeleteByID(ctx, game.ID); err != nil {
 		return fmt.Errorf("failed to delete game: %w", err)
 	}
@@ -344,6 +510,10 @@ func (that *gameUseCase) EndGame(ctx context.Context, game *entity.Game) error {
 
 		player1.LastOpponentID = player2.ID
 		player2.LastOpponentID = player1.ID
+
+		terms := game.Terms()
+		player1.LastGame = &terms
+		player2.LastGame = &terms
 	}
 
 	for _, player := range game.Players {
@@ -359,6 +529,89 @@ func (that *gameUseCase) EndGame(ctx context.Context, game *entity.Game) error {
 	return nil
 }
 
+// TimeOutGames - finishes the games whose clocks ran out by now as losses of the players to move,
+// and returns them. A game that fails to end does not keep the others from ending, its error is returned with them.
+func (that *gameUseCase) TimeOutGames(ctx context.Context, now time.Time) ([]*entity.Game, error) {
+	games, err := that.gameRepo.GetTimedOut(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timed out games: %w", err)
+	}
+
+	timedOut := make([]*entity.Game, 0, len(games))
+	var errs []error
+
+	for _, game := range games {
+		// a move may have come in since the deadline was indexed
+		if !game.IsTimedOut(now) {
+			continue
+		}
+
+		// or between the read of the game and its end, then the game goes on
+		ended, err := that.endTimedOut(ctx, game)
+		if errors.Is(err, apperror.ErrGameChanged) {
+			continue
+		}
+
+		if !errors.Is(err, apperror.ErrGameFinished) {
+			errs = append(errs, fmt.Errorf("failed to time out game %s: %w", game.ID, err))
+			continue
+		}
+
+		timedOut = append(timedOut, ended)
+	}
+
+	return timedOut, errors.Join(errs...)
+}
+
+// GetHistory - returns the page of the archived games of the player, the latest first, and the number of games
+// in the history. The limit falls back to DefaultHistoryLimit and is capped by MaxHistoryLimit.
+func (that *gameUseCase) GetHistory(ctx context.Context, playerID string, offset, limit int) ([]*entity.ArchivedGame, int, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	limit = min(limit, MaxHistoryLimit)
+	offset = max(offset, 0)
+
+	games, total, err := that.archiveRepo.GetByPlayerID(ctx, playerID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	return games, total, nil
+}
+
+// GetArchivedGame - returns the finished game from the archive, only to a player who played in it.
+// The games of others are not found, so a guessed ID tells nothing about them.
+func (that *gameUseCase) GetArchivedGame(ctx context.Context, playerID, gameID string) (*entity.ArchivedGame, error) {
+	player, err := that.getPlayerByID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	game, err := that.archiveRepo.GetByID(ctx, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived game: %w", err)
+	}
+
+	if !game.HasPlayer(player.PublicID) {
+		return nil, fmt.Errorf("failed to get archived game: %w", apperror.ErrGameNotFound)
+	}
+
+	return game, nil
+}
+
+// humanPlayerIDs - returns the private IDs of the players of the game who are not bots.
+func humanPlayerIDs(game *entity.Game) []string {
+	ids := make([]string, 0, len(game.Players))
+	for _, player := range game.Players {
+		if !player.IsBot() {
+			ids = append(ids, player.ID)
+		}
+	}
+
+	return ids
+}
+
 func (that *gameUseCase) getPlayerByID(ctx context.Context, playerID string) (*entity.Player, error) {
 	player, err := that.playerRepo.GetByID(ctx, playerID)
 	if err != nil {
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
 	mockedUseCase "github.com/rocketscienceinc/tictactoe-backend/mocks/usecase"
 )
@@ -29,7 +31,7 @@ func TestGameUseCase_GetOrCreatePlayer(t *testing.T) {
 		// Given: A mock player repository and a mock game repository
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		mockPlayerRepo.EXPECT().
 			CreateOrUpdate(mock.Anything, mock.AnythingOfType("*entity.Player")).
@@ -48,7 +50,7 @@ func TestGameUseCase_GetOrCreatePlayer(t *testing.T) {
 		// Given: A mock player repository that returns an existing player
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		existingPlayer := &entity.Player{ID: "player123"}
 		mockPlayerRepo.EXPECT().
@@ -68,7 +70,7 @@ func TestGameUseCase_GetOrCreatePlayer(t *testing.T) {
 		// Given: A mock player repository that fails to get the player
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		mockPlayerRepo.EXPECT().
 			GetByID(mock.Anything, "playerErr").
@@ -87,7 +89,7 @@ func TestGameUseCase_GetOrCreatePlayer(t *testing.T) {
 		// Given: A mock player repository that fails on CreateOrUpdate
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		mockPlayerRepo.EXPECT().
 			CreateOrUpdate(mock.Anything, mock.AnythingOfType("*entity.Player")).
@@ -110,7 +112,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 		// Given: A mock setup where the player has no GameID
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		playerID := "p1"
 		player := &entity.Player{ID: playerID, GameID: ""}
@@ -130,7 +132,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 			Once()
 
 		// When: Calling GetOrCreateGame with a player who has no GameID
-		game, err := useCaseInstance.GetOrCreateGame(ctx, playerID, entity.PrivateType, entity.HardDifficulty)
+		game, err := useCaseInstance.GetOrCreateGame(ctx, playerID, entity.PrivateType, entity.HardDifficulty, 0, entity.GameSettings{})
 
 		// Then: A new game should be created and returned without error
 		require.NoError(t, err)
@@ -142,7 +144,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 		// Given: A mock setup where the player already has a GameID
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		playerID := "p2"
 		player := &entity.Player{ID: playerID, GameID: "g123"}
@@ -159,7 +161,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 			Once()
 
 		// When: Calling GetOrCreateGame with a player who has an existing GameID
-		game, err := useCaseInstance.GetOrCreateGame(ctx, playerID, entity.PublicType, entity.EasyDifficulty)
+		game, err := useCaseInstance.GetOrCreateGame(ctx, playerID, entity.PublicType, entity.EasyDifficulty, 0, entity.GameSettings{})
 
 		// Then: The existing game should be returned without error
 		require.NoError(t, err)
@@ -170,7 +172,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 		// Given: A mock player repository that fails when getting the player
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		mockPlayerRepo.EXPECT().
 			GetByID(ctx, "somePlayer").
@@ -178,7 +180,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 			Once()
 
 		// When: Calling GetOrCreateGame but GetByID fails
-		game, err := useCaseInstance.GetOrCreateGame(ctx, "somePlayer", entity.WithBotType, entity.EasyDifficulty)
+		game, err := useCaseInstance.GetOrCreateGame(ctx, "somePlayer", entity.WithBotType, entity.EasyDifficulty, 0, entity.GameSettings{})
 
 		// Then: An error should be returned, and the game should be nil
 		require.Error(t, err)
@@ -189,7 +191,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 		// Given: A mock game repository that fails on CreateOrUpdate
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		player := &entity.Player{ID: "p3", GameID: ""}
 
@@ -211,7 +213,7 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 			Once()
 
 		// When: Calling GetOrCreateGame and CreateOrUpdate fails
-		game, err := useCaseInstance.GetOrCreateGame(ctx, "p3", entity.WithBotType, entity.HardDifficulty)
+		game, err := useCaseInstance.GetOrCreateGame(ctx, "p3", entity.WithBotType, entity.HardDifficulty, 0, entity.GameSettings{})
 
 		// Then: An error should be returned, and the game should be nil
 		require.Error(t, err)
@@ -219,6 +221,159 @@ func TestGameUseCase_GetOrCreateGame(t *testing.T) {
 	})
 }
 
+func TestGameUseCase_GetOrCreateGame_Settings(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Creates a game with the board of the settings", func(t *testing.T) {
+		// Given: a player without a game
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		mockPlayerRepo.EXPECT().GetByID(ctx, "p1").Return(&entity.Player{ID: "p1"}, nil).Once()
+		mockPlayerRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Player")).Return(nil).Once()
+		mockGameRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Game")).Return(nil).Once()
+
+		// When: creating a 15x15 game
+		game, err := useCaseInstance.GetOrCreateGame(ctx, "p1", entity.PrivateType, "", 0, entity.GameSettings{Rows: 15, Cols: 15})
+
+		// Then: the game should have a 15x15 board with five in a row
+		require.NoError(t, err)
+		assert.Len(t, game.Board, 225)
+		assert.Equal(t, entity.GameSettings{Rows: 15, Cols: 15, WinLength: 5}, game.Settings())
+	})
+
+	t.Run("Rejects invalid settings before touching storage", func(t *testing.T) {
+		// Given: settings with a win length that does not fit the board
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		// When: creating the game
+		game, err := useCaseInstance.GetOrCreateGame(ctx, "p1", entity.PrivateType, "", 0, entity.GameSettings{Rows: 4, Cols: 4, WinLength: 6})
+
+		// Then: ErrInvalidSettings should be returned
+		require.ErrorIs(t, err, entity.ErrInvalidSettings)
+		assert.Nil(t, game)
+	})
+
+	t.Run("Rejects a bot strength out of range", func(t *testing.T) {
+		// Given: a strength above the strongest bot
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		// When: creating the game
+		game, err := useCaseInstance.GetOrCreateGame(ctx, "p1", entity.WithBotType, "", entity.MaxStrength+1, entity.GameSettings{})
+
+		// Then: ErrInvalidStrength should be returned
+		require.ErrorIs(t, err, entity.ErrInvalidStrength)
+		assert.Nil(t, game)
+	})
+
+	t.Run("Looks for a public game with the same settings", func(t *testing.T) {
+		// Given: no open public 4x4 game
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		settings := entity.GameSettings{Rows: 4, Cols: 4, WinLength: 4}
+
+		mockPlayerRepo.EXPECT().GetByID(ctx, "p1").Return(&entity.Player{ID: "p1"}, nil).Once()
+		mockGameRepo.EXPECT().GetOpenPublicGame(ctx, settings).Return(nil, apperror.ErrNoActiveGames).Once()
+		mockPlayerRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Player")).Return(nil).Once()
+		mockGameRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Game")).Return(nil).Once()
+
+		// When: joining a public 4x4 game without a win length
+		game, err := useCaseInstance.CreateOrJoinToPublicGame(ctx, "p1", entity.PublicType, entity.GameSettings{Rows: 4, Cols: 4})
+
+		// Then: a new public 4x4 game should be created
+		require.NoError(t, err)
+		assert.Equal(t, settings, game.Settings())
+	})
+}
+
+func TestGameUseCase_JoinGameByID(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Returns ErrGameFull when the game already has two players", func(t *testing.T) {
+		// Given: A private game with two players and a third player trying to join it
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		fullGame := &entity.Game{
+			ID:     "G1",
+			Status: entity.StatusOngoing,
+			Players: []*entity.Player{
+				{ID: "p1", GameID: "G1", Mark: entity.PlayerX},
+				{ID: "p2", GameID: "G1", Mark: entity.PlayerO},
+			},
+		}
+
+		mockGameRepo.EXPECT().
+			GetByID(ctx, "G1").
+			Return(fullGame, nil).
+			Once()
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "p3").
+			Return(&entity.Player{ID: "p3"}, nil).
+			Once()
+
+		// When: The third player joins the game
+		game, err := useCaseInstance.JoinGameByID(ctx, "g1", "p3")
+
+		// Then: ErrGameFull should be returned, and the game should be nil
+		require.ErrorIs(t, err, apperror.ErrGameFull)
+		assert.Nil(t, game)
+	})
+}
+
+// newTimedOutGame - returns a timed game of p1 and p2 that X has run out of time in.
+func newTimedOutGame(id string) *entity.Game {
+	game := entity.NewGameWithSettings(id, entity.PrivateType, entity.GameSettings{TimeControl: entity.TimeControl{MoveLimitMs: 10000}})
+	game.Players = []*entity.Player{
+		{ID: "p1", GameID: id, Mark: entity.PlayerX},
+		{ID: "p2", GameID: id, Mark: entity.PlayerO},
+	}
+	game.Start()
+
+	deadline := time.Now().Add(-time.Second)
+	game.Clock.Deadline = &deadline
+
+	return game
+}
+
+// expectTimeOut - expects the game to be stored lost on time against the deadline it was read with.
+func expectTimeOut(ctx context.Context, mockGameRepo *mockedUseCase.MockgameRepoDep, game *entity.Game) {
+	mockGameRepo.EXPECT().
+		UpdateIfDeadline(ctx, game, *game.Clock.Deadline).
+		Return(nil).
+		Once()
+}
+
+// expectEndGame - expects the game of p1 and p2 to be archived, deleted and its players freed.
+func expectEndGame(
+	ctx context.Context, mockPlayerRepo *mockedUseCase.MockplayerRepoDep, mockGameRepo *mockedUseCase.MockgameRepoDep,
+	mockArchiveRepo *mockedUseCase.MockarchiveRepoDep, gameID string,
+) {
+	mockArchiveRepo.EXPECT().
+		Save(ctx, mock.MatchedBy(func(archived *entity.ArchivedGame) bool { return archived.ID == gameID }), []string{"p1", "p2"}).
+		Return(nil).
+		Once()
+
+	mockGameRepo.EXPECT().
+		DeleteByID(ctx, gameID).
+		Return(nil).
+		Once()
+
+	mockPlayerRepo.EXPECT().
+		CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Player")).
+		Return(nil).
+		Times(2)
+}
+
 func TestGameUseCase_MakeTurn(t *testing.T) {
 	ctx := context.Background()
 
@@ -226,7 +381,7 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 		// Given: A mock setup where retrieving the player fails
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		mockPlayerRepo.EXPECT().
 			GetByID(ctx, "p1").
@@ -234,7 +389,7 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 			Once()
 
 		// When: Calling MakeTurn and the player does not exist
-		game, err := useCaseInstance.MakeTurn(ctx, "p1", 0)
+		game, err := useCaseInstance.MakeTurn(ctx, "p1", 0, "")
 
 		// Then: An error should be returned, and the game should be nil
 		require.Error(t, err)
@@ -245,7 +400,7 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 		// Given: A mock setup where the game cannot be found
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		mockPlayerRepo.EXPECT().
 			GetByID(ctx, "p2").
@@ -258,7 +413,7 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 			Once()
 
 		// When: Calling MakeTurn but the game does not exist
-		game, err := useCaseInstance.MakeTurn(ctx, "p2", 1)
+		game, err := useCaseInstance.MakeTurn(ctx, "p2", 1, "")
 
 		// Then: An error should be returned, and the game should be nil
 		require.Error(t, err)
@@ -269,7 +424,7 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 		// Given: A mock setup where the game is finished
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		mockPlayerRepo.EXPECT().
 			GetByID(ctx, "p3").
@@ -283,10 +438,10 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 			Once()
 
 		// When: Calling MakeTurn on a finished game
-		game, err := useCaseInstance.MakeTurn(ctx, "p3", 2)
+		game, err := useCaseInstance.MakeTurn(ctx, "p3", 2, "")
 
-		// Then: An error should be returned, and the game should be nil
-		require.Error(t, err)
+		// Then: ErrGameFinished should be returned, and the game should be nil
+		require.ErrorIs(t, err, apperror.ErrGameFinished)
 		assert.Nil(t, game)
 	})
 
@@ -294,13 +449,13 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 		// Given: A mock setup for a valid ongoing game with two human players
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		playerX := &entity.Player{ID: "pX", GameID: "gX", Mark: entity.PlayerX}
 		gameOngoing := &entity.Game{
 			ID:     "gX",
 			Status: entity.StatusOngoing,
-			Board:  [9]string{"", "", "", "", "", "", "", "", ""},
+			Board:  []string{"", "", "", "", "", "", "", "", ""},
 			Turn:   entity.PlayerX,
 			Type:   entity.PrivateType,
 		}
@@ -316,12 +471,12 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 			Once()
 
 		mockGameRepo.EXPECT().
-			CreateOrUpdate(ctx, gameOngoing).
+			UpdateIfMoves(ctx, gameOngoing, 0).
 			Return(nil).
 			Once()
 
 		// When: Player X makes a valid turn on cell 4
-		game, err := useCaseInstance.MakeTurn(ctx, "pX", 4)
+		game, err := useCaseInstance.MakeTurn(ctx, "pX", 4, "")
 
 		// Then: The turn should succeed, and the game should update accordingly
 		require.NoError(t, err)
@@ -329,23 +484,113 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 		assert.Equal(t, entity.PlayerX, game.Board[4])
 	})
 
-	t.Run("Player moves in a Bot game => Bot moves next", func(t *testing.T) {
+	t.Run("Move after the clock ran out loses on time", func(t *testing.T) {
+		// Given: A timed game X has run out of time in
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockArchiveRepo)
+
+		timedOut := newTimedOutGame("gT")
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "p1").
+			Return(&entity.Player{ID: "p1", GameID: "gT", Mark: entity.PlayerX}, nil).
+			Once()
+
+		mockGameRepo.EXPECT().
+			GetByID(ctx, "gT").
+			Return(timedOut, nil).
+			Once()
+
+		expectTimeOut(ctx, mockGameRepo, timedOut)
+		expectEndGame(ctx, mockPlayerRepo, mockGameRepo, mockArchiveRepo, "gT")
+
+		// When: Player X tries to make a turn
+		game, err := useCaseInstance.MakeTurn(ctx, "p1", 4, "")
+
+		// Then: The move should not be made and O should win on time
+		require.ErrorIs(t, err, apperror.ErrGameFinished)
+		assert.Equal(t, entity.PlayerO, game.Winner)
+		assert.Equal(t, entity.FinishReasonTimeout, game.FinishReason)
+		assert.Empty(t, game.Board[4])
+	})
+
+	t.Run("Timed move is stored against the deadline it was read with", func(t *testing.T) {
+		// Given: A timed game X has time left to move in
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		timed := newTimedOutGame("gT")
+		readDeadline := time.Now().Add(time.Minute)
+		timed.Clock.Deadline = &readDeadline
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "p1").
+			Return(&entity.Player{ID: "p1", GameID: "gT", Mark: entity.PlayerX}, nil).
+			Once()
+
+		mockGameRepo.EXPECT().
+			GetByID(ctx, "gT").
+			Return(timed, nil).
+			Once()
+
+		mockGameRepo.EXPECT().
+			UpdateIfDeadline(ctx, timed, readDeadline).
+			Return(nil).
+			Once()
+
+		// When: Player X makes a turn
+		game, err := useCaseInstance.MakeTurn(ctx, "p1", 4, "")
+
+		// Then: The move should be stored with the clock of O running
+		require.NoError(t, err)
+		assert.Equal(t, entity.PlayerX, game.Board[4])
+		assert.NotEqual(t, readDeadline, *game.Clock.Deadline)
+	})
+
+	t.Run("Timed move that lost the race to the clock is dropped", func(t *testing.T) {
+		// Given: A timed game the clock monitor ends while X moves
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		timed := newTimedOutGame("gT")
+		readDeadline := time.Now().Add(100 * time.Millisecond)
+		timed.Clock.Deadline = &readDeadline
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "p1").
+			Return(&entity.Player{ID: "p1", GameID: "gT", Mark: entity.PlayerX}, nil).
+			Once()
+
+		mockGameRepo.EXPECT().
+			GetByID(ctx, "gT").
+			Return(timed, nil).
+			Once()
+
+		mockGameRepo.EXPECT().
+			UpdateIfDeadline(ctx, timed, readDeadline).
+			Return(apperror.ErrGameChanged).
+			Once()
+
+		// When: Player X makes a turn
+		game, err := useCaseInstance.MakeTurn(ctx, "p1", 4, "")
+
+		// Then: The move should not be stored nor the game ended a second time
+		require.ErrorIs(t, err, apperror.ErrGameChanged)
+		assert.Nil(t, game)
+	})
+
+	t.Run("Player moves in a Bot game => the turn passes to the bot", func(t *testing.T) {
 		// Given: A mock setup for a game with a bot and an ongoing status
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
 
 		playerX := &entity.Player{ID: "pX", GameID: "gBot", Mark: entity.PlayerX}
-		botPlayer := &entity.Player{ID: "bot:gBot", GameID: "gBot", Mark: entity.PlayerO}
-		gameWithBot := &entity.Game{
-			ID:         "gBot",
-			Status:     entity.StatusOngoing,
-			Board:      [9]string{"", "", "", "", "", "", "", "", ""},
-			Turn:       entity.PlayerX,
-			Players:    []*entity.Player{playerX, botPlayer},
-			Type:       entity.WithBotType,
-			Difficulty: entity.EasyDifficulty,
-		}
+		gameWithBot := newBotGame("gBot", playerX)
 
 		mockPlayerRepo.EXPECT().
 			GetByID(ctx, "pX").
@@ -357,32 +602,212 @@ func TestGameUseCase_MakeTurn(t *testing.T) {
 			Return(gameWithBot, nil).
 			Once()
 
-		// Expect a single CreateOrUpdate call for this test scenario
 		mockGameRepo.EXPECT().
-			CreateOrUpdate(ctx, gameWithBot).
+			UpdateIfMoves(ctx, gameWithBot, 0).
 			Return(nil).
 			Once()
 
-		// When: Player X makes a turn on cell 0, then the bot should move
-		game, err := useCaseInstance.MakeTurn(ctx, "pX", 0)
+		// When: Player X makes a turn on cell 0
+		game, err := useCaseInstance.MakeTurn(ctx, "pX", 0, "")
 
-		// Then: The player's move should succeed, and the bot should also move
+		// Then: The player's move should be stored and the bot left to move, MakeBotTurn makes its move
 		require.NoError(t, err)
-		require.NotNil(t, game)
 		assert.Equal(t, entity.PlayerX, game.Board[0])
+		assert.Equal(t, entity.PlayerO, game.Turn)
+		assert.Len(t, game.Moves, 1)
+		assert.Equal(t, entity.StatusOngoing, game.Status)
+	})
+}
+
+// newBotGame - an ongoing classic game of the player as X against an easy bot.
+func newBotGame(gameID string, player *entity.Player) *entity.Game {
+	game := entity.NewGame(gameID, entity.WithBotType)
+	game.Difficulty = entity.EasyDifficulty
+	game.Players = []*entity.Player{player, entity.NewBotPlayer(gameID, entity.PlayerO)}
+	game.Start()
+
+	return game
+}
+
+// storedGame - returns a copy of the game as the repository would return it, every read gets its own.
+func storedGame(game *entity.Game) func(context.Context, string) (*entity.Game, error) {
+	return func(context.Context, string) (*entity.Game, error) {
+		stored := *game
+		stored.Board = append([]string(nil), game.Board...)
+		stored.Moves = append([]entity.Move(nil), game.Moves...)
+
+		return &stored, nil
+	}
+}
+
+func TestGameUseCase_MakeBotTurn(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Bot moves and the game is stored", func(t *testing.T) {
+		// Given: A game where the player has moved and the bot is to move
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		game := newBotGame("gBot", &entity.Player{ID: "pX", GameID: "gBot", Mark: entity.PlayerX})
+		require.NoError(t, game.MakeTurn(entity.PlayerX, 0))
+
+		mockGameRepo.EXPECT().GetByID(ctx, "gBot").RunAndReturn(storedGame(game)).Times(2)
+		mockGameRepo.EXPECT().UpdateIfMoves(ctx, mock.AnythingOfType("*entity.Game"), 1).Return(nil).Once()
+
+		// When: MakeBotTurn is called
+		got, err := useCaseInstance.MakeBotTurn(ctx, "gBot")
+
+		// Then: The bot should have made a single recorded move and passed the turn back
+		require.NoError(t, err)
+		assert.Len(t, got.Moves, 2)
+		assert.Equal(t, entity.PlayerO, got.Moves[1].Mark)
+		assert.Equal(t, entity.PlayerO, got.Board[got.Moves[1].Cell])
+		assert.Equal(t, entity.PlayerX, got.Turn)
+	})
 
-		botHasMoved := false
-		for i := 1; i < 9; i++ {
-			if game.Board[i] == entity.PlayerO {
-				botHasMoved = true
-				break
-			}
+	t.Run("Game ended during the search is not brought back", func(t *testing.T) {
+		// Given: A game the player leaves while the bot searches its move
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		game := newBotGame("gBot", &entity.Player{ID: "pX", GameID: "gBot", Mark: entity.PlayerX})
+		require.NoError(t, game.MakeTurn(entity.PlayerX, 0))
+
+		mockGameRepo.EXPECT().GetByID(ctx, "gBot").RunAndReturn(storedGame(game)).Once()
+		mockGameRepo.EXPECT().GetByID(ctx, "gBot").Return(nil, apperror.ErrGameNotFound).Once()
+
+		// When: MakeBotTurn is called
+		got, err := useCaseInstance.MakeBotTurn(ctx, "gBot")
+
+		// Then: ErrGameNotFound should be returned and nothing stored
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Game removed between the search and the store is not brought back", func(t *testing.T) {
+		// Given: A game the player leaves after the bot read it again to store its move
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		game := newBotGame("gBot", &entity.Player{ID: "pX", GameID: "gBot", Mark: entity.PlayerX})
+		require.NoError(t, game.MakeTurn(entity.PlayerX, 0))
+
+		mockGameRepo.EXPECT().GetByID(ctx, "gBot").RunAndReturn(storedGame(game)).Times(2)
+		mockGameRepo.EXPECT().UpdateIfMoves(ctx, mock.AnythingOfType("*entity.Game"), 1).Return(apperror.ErrGameNotFound).Once()
+
+		// When: MakeBotTurn is called
+		got, err := useCaseInstance.MakeBotTurn(ctx, "gBot")
+
+		// Then: ErrGameChanged should be returned and the game neither stored nor ended
+		require.ErrorIs(t, err, apperror.ErrGameChanged)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Winning move of a game ended meanwhile does not end it again", func(t *testing.T) {
+		// Given: A game the hard bot wins with its next move, ended by the player before the move is stored
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		game := newBotGame("gBot", &entity.Player{ID: "pX", GameID: "gBot", Mark: entity.PlayerX})
+		game.Difficulty = entity.HardDifficulty
+		for _, cell := range []int{0, 3, 1, 4, 8} {
+			require.NoError(t, game.MakeTurn(game.Turn, cell))
 		}
-		assert.True(t, botHasMoved)
-		assert.Equal(t, entity.StatusOngoing, game.Status)
 
-		mockPlayerRepo.AssertExpectations(t)
-		mockGameRepo.AssertExpectations(t)
+		mockGameRepo.EXPECT().GetByID(ctx, "gBot").RunAndReturn(storedGame(game)).Times(2)
+		mockGameRepo.EXPECT().UpdateIfMoves(ctx, mock.AnythingOfType("*entity.Game"), 5).Return(apperror.ErrGameChanged).Once()
+
+		// When: MakeBotTurn is called
+		got, err := useCaseInstance.MakeBotTurn(ctx, "gBot")
+
+		// Then: ErrGameChanged should be returned, nothing archived and no player moved out of a game
+		require.ErrorIs(t, err, apperror.ErrGameChanged)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Refuses to move out of turn", func(t *testing.T) {
+		// Given: A game where the player is to move
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		game := newBotGame("gBot", &entity.Player{ID: "pX", GameID: "gBot", Mark: entity.PlayerX})
+		mockGameRepo.EXPECT().GetByID(ctx, "gBot").RunAndReturn(storedGame(game)).Once()
+
+		// When: MakeBotTurn is called
+		got, err := useCaseInstance.MakeBotTurn(ctx, "gBot")
+
+		// Then: ErrNotYourTurn should be returned
+		require.ErrorIs(t, err, apperror.ErrNotYourTurn)
+		assert.Nil(t, got)
+	})
+
+	t.Run("Bot move that wins ends the game", func(t *testing.T) {
+		// Given: A game the hard bot wins with its next move
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockArchiveRepo)
+
+		game := newBotGame("gBot", &entity.Player{ID: "pX", GameID: "gBot", Mark: entity.PlayerX})
+		game.Difficulty = entity.HardDifficulty
+		for _, cell := range []int{0, 3, 1, 4, 8} {
+			require.NoError(t, game.MakeTurn(game.Turn, cell))
+		}
+
+		mockGameRepo.EXPECT().GetByID(ctx, "gBot").RunAndReturn(storedGame(game)).Times(2)
+		mockGameRepo.EXPECT().UpdateIfMoves(ctx, mock.AnythingOfType("*entity.Game"), 5).Return(nil).Once()
+		mockArchiveRepo.EXPECT().Save(ctx, mock.AnythingOfType("*entity.ArchivedGame"), []string{"pX"}).Return(nil).Once()
+		mockGameRepo.EXPECT().DeleteByID(ctx, "gBot").Return(nil).Once()
+		mockPlayerRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Player")).Return(nil).Times(2)
+
+		// When: MakeBotTurn is called
+		got, err := useCaseInstance.MakeBotTurn(ctx, "gBot")
+
+		// Then: The game should be won by the bot and ended
+		require.ErrorIs(t, err, apperror.ErrGameFinished)
+		assert.Equal(t, entity.PlayerO, got.Winner)
+	})
+}
+
+func TestGameUseCase_GetGameByID(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Returns the live game", func(t *testing.T) {
+		// Given: An ongoing game
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		expectedGame := entity.NewGame("g1", entity.PublicType)
+		expectedGame.Start()
+		mockGameRepo.EXPECT().
+			GetByID(ctx, "g1").
+			Return(expectedGame, nil).
+			Once()
+
+		// When: GetGameByID is called
+		game, err := useCaseInstance.GetGameByID(ctx, "g1")
+
+		// Then: The game should be returned
+		require.NoError(t, err)
+		assert.Equal(t, expectedGame, game)
+	})
+
+	t.Run("Returns ErrGameNotFound for a game that is not live", func(t *testing.T) {
+		// Given: No game with the ID
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		mockGameRepo.EXPECT().
+			GetByID(ctx, "missing").
+			Return(nil, apperror.ErrGameNotFound).
+			Once()
+
+		// When: GetGameByID is called
+		game, err := useCaseInstance.GetGameByID(ctx, "missing")
+
+		// Then: The error should be returned
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+		assert.Nil(t, game)
 	})
 }
 
@@ -393,17 +818,30 @@ func TestGameUseCase_EndGame(t *testing.T) {
 		// Given: A mock setup for an already finished game with two players
 		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
 		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
-		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockArchiveRepo)
 
 		players := []*entity.Player{
-			{ID: "p1", GameID: "game123", Mark: entity.PlayerX},
-			{ID: "p2", GameID: "game123", Mark: entity.PlayerO},
+			{ID: "p1", PublicID: "pub1", GameID: "game123", Mark: entity.PlayerX},
+			{ID: "p2", PublicID: "pub2", GameID: "game123", Mark: entity.PlayerO},
 		}
+		settings := entity.GameSettings{Rows: 5, Cols: 5, WinLength: 4, TimeControl: entity.TimeControl{BankMs: 60000}}
 		game := &entity.Game{
-			ID:      "game123",
-			Players: players,
-			Status:  entity.StatusFinished,
+			ID:           "game123",
+			GameSettings: settings,
+			Players:      players,
+			Status:       entity.StatusFinished,
+			Winner:       entity.PlayerX,
 		}
+		lastGame := &entity.GameTerms{GameSettings: settings}
+
+		mockArchiveRepo.EXPECT().
+			Save(ctx, mock.MatchedBy(func(archived *entity.ArchivedGame) bool {
+				return archived.ID == "game123" && archived.Winner == entity.PlayerX &&
+					archived.Players[0].ID == "" && archived.Players[0].PublicID == "pub1"
+			}), []string{"p1", "p2"}).
+			Return(nil).
+			Once()
 
 		mockGameRepo.EXPECT().
 			DeleteByID(ctx, "game123").
@@ -411,19 +849,425 @@ func TestGameUseCase_EndGame(t *testing.T) {
 			Once()
 
 		mockPlayerRepo.EXPECT().
-			CreateOrUpdate(ctx, &entity.Player{ID: "p1", GameID: "", Mark: "", LastOpponentID: "p2"}).
+			CreateOrUpdate(ctx, &entity.Player{ID: "p1", PublicID: "pub1", GameID: "", Mark: "", LastOpponentID: "p2", LastGame: lastGame}).
 			Return(nil).
 			Once()
 
 		mockPlayerRepo.EXPECT().
-			CreateOrUpdate(ctx, &entity.Player{ID: "p2", GameID: "", Mark: "", LastOpponentID: "p1"}).
+			CreateOrUpdate(ctx, &entity.Player{ID: "p2", PublicID: "pub2", GameID: "", Mark: "", LastOpponentID: "p1", LastGame: lastGame}).
 			Return(nil).
 			Once()
 
 		// When: EndGame is called on a finished game
 		err := useCaseInstance.EndGame(ctx, game)
 
-		// Then: The game should be deleted and players should be cleared
+		// Then: The game should be archived and deleted, players should be cleared and keep the terms for a rematch
+		require.NoError(t, err)
+	})
+
+	t.Run("Keeps the bot terms for a rematch", func(t *testing.T) {
+		// Given: A finished game against a hard bot
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockArchiveRepo)
+
+		player := &entity.Player{ID: "p1", GameID: "game321", Mark: entity.PlayerX}
+		game := &entity.Game{
+			ID:           "game321",
+			GameSettings: entity.GameSettings{Variant: entity.MisereVariant},
+			Players:      []*entity.Player{player, entity.NewBotPlayer("game321", entity.PlayerO)},
+			Status:       entity.StatusFinished,
+			Type:         entity.WithBotType,
+			Difficulty:   entity.HardDifficulty,
+			Strength:     7,
+		}
+
+		mockArchiveRepo.EXPECT().Save(ctx, mock.AnythingOfType("*entity.ArchivedGame"), []string{"p1"}).Return(nil).Once()
+		mockGameRepo.EXPECT().DeleteByID(ctx, "game321").Return(nil).Once()
+		mockPlayerRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Player")).Return(nil).Times(2)
+
+		// When: EndGame is called
+		err := useCaseInstance.EndGame(ctx, game)
+
+		// Then: The player should keep the variant, the difficulty and the strength of the bot
+		require.NoError(t, err)
+		assert.Equal(t, &entity.GameTerms{
+			GameSettings: entity.GameSettings{Variant: entity.MisereVariant},
+			Difficulty:   entity.HardDifficulty,
+			Strength:     7,
+		}, player.LastGame)
+	})
+
+	t.Run("Deletes a game nobody moved in without archiving it", func(t *testing.T) {
+		// Given: A game left by its only player before the bot joined
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		game := &entity.Game{
+			ID:      "game456",
+			Players: []*entity.Player{{ID: "p1", GameID: "game456", Mark: entity.PlayerX}},
+			Status:  entity.StatusWaiting,
+		}
+
+		mockGameRepo.EXPECT().
+			DeleteByID(ctx, "game456").
+			Return(nil).
+			Once()
+
+		mockPlayerRepo.EXPECT().
+			CreateOrUpdate(ctx, &entity.Player{ID: "p1"}).
+			Return(nil).
+			Once()
+
+		// When: EndGame is called
+		err := useCaseInstance.EndGame(ctx, game)
+
+		// Then: The game should only be deleted
+		require.NoError(t, err)
+	})
+
+	t.Run("Keeps the game when it cannot be archived", func(t *testing.T) {
+		// Given: An abandoned bot game and an archive that fails
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockArchiveRepo)
+
+		game := &entity.Game{
+			ID:     "game789",
+			Status: entity.StatusOngoing,
+			Players: []*entity.Player{
+				{ID: "p1", GameID: "game789", Mark: entity.PlayerX},
+				entity.NewBotPlayer("game789", entity.PlayerO),
+			},
+			Moves: []entity.Move{{Seq: 1, Mark: entity.PlayerX, Cell: 4}},
+		}
+
+		mockArchiveRepo.EXPECT().
+			Save(ctx, mock.MatchedBy(func(archived *entity.ArchivedGame) bool {
+				return archived.Status == entity.StatusAbandoned
+			}), []string{"p1"}).
+			Return(errRedisDown).
+			Once()
+
+		// When: EndGame is called
+		err := useCaseInstance.EndGame(ctx, game)
+
+		// Then: The error should be returned and the game left in storage
+		require.ErrorIs(t, err, errRedisDown)
+	})
+}
+
+func TestGameUseCase_CreatePrivateGameWithTwoPlayers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Starts the game on the settings", func(t *testing.T) {
+		// Given: two players who finished a timed 5x5 game
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		player1 := &entity.Player{ID: "p1"}
+		player2 := &entity.Player{ID: "p2"}
+		settings := entity.GameSettings{Rows: 5, Cols: 5, WinLength: 4, TimeControl: entity.TimeControl{BankMs: 60000}}
+
+		mockPlayerRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Player")).Return(nil).Times(2)
+		mockGameRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Game")).Return(nil).Once()
+
+		// When: creating their game on the same settings
+		game, err := useCaseInstance.CreatePrivateGameWithTwoPlayers(ctx, player1, player2, settings)
+
+		// Then: the game should be started on a 5x5 board with the clock running
+		require.NoError(t, err)
+		assert.Equal(t, settings, game.Settings())
+		assert.Len(t, game.Board, 25)
+		assert.True(t, game.IsOngoing())
+		require.NotNil(t, game.Clock)
+		assert.NotNil(t, game.Clock.Deadline)
+		assert.Equal(t, entity.PlayerX, player1.Mark)
+		assert.Equal(t, entity.PlayerO, player2.Mark)
+	})
+
+	t.Run("Starts a classic game without settings", func(t *testing.T) {
+		// Given: two players
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		mockPlayerRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Player")).Return(nil).Times(2)
+		mockGameRepo.EXPECT().CreateOrUpdate(ctx, mock.AnythingOfType("*entity.Game")).Return(nil).Once()
+
+		// When: creating their game without settings
+		game, err := useCaseInstance.CreatePrivateGameWithTwoPlayers(ctx, &entity.Player{ID: "p1"}, &entity.Player{ID: "p2"}, entity.GameSettings{})
+
+		// Then: the game should be a classic one
 		require.NoError(t, err)
+		assert.Len(t, game.Board, 9)
+		assert.Equal(t, entity.GameSettings{}, game.GameSettings)
+	})
+
+	t.Run("Rejects invalid settings before touching storage", func(t *testing.T) {
+		// Given: settings with a win length that does not fit the board
+		useCaseInstance := NewGameUseCase(
+			mockedUseCase.NewMockplayerRepoDep(t), mockedUseCase.NewMockgameRepoDep(t), mockedUseCase.NewMockarchiveRepoDep(t),
+		)
+
+		// When: creating the game
+		game, err := useCaseInstance.CreatePrivateGameWithTwoPlayers(
+			ctx, &entity.Player{ID: "p1"}, &entity.Player{ID: "p2"}, entity.GameSettings{Rows: 4, Cols: 4, WinLength: 6},
+		)
+
+		// Then: ErrInvalidSettings should be returned
+		require.ErrorIs(t, err, entity.ErrInvalidSettings)
+		assert.Nil(t, game)
+	})
+}
+
+func TestGameUseCase_TimeOutGames(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Ends the games whose clocks ran out", func(t *testing.T) {
+		// Given: A game X has run out of time in, and one the last move came in just before the check
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockGameRepo, mockArchiveRepo)
+
+		now := time.Now()
+		timedOut := newTimedOutGame("g1")
+		moved := newTimedOutGame("g2")
+		deadline := now.Add(time.Second)
+		moved.Clock.Deadline = &deadline
+
+		mockGameRepo.EXPECT().
+			GetTimedOut(ctx, now).
+			Return([]*entity.Game{timedOut, moved}, nil).
+			Once()
+
+		expectTimeOut(ctx, mockGameRepo, timedOut)
+		expectEndGame(ctx, mockPlayerRepo, mockGameRepo, mockArchiveRepo, "g1")
+
+		// When: TimeOutGames is called
+		games, err := useCaseInstance.TimeOutGames(ctx, now)
+
+		// Then: Only the game past its deadline should end, as a loss of X on time
+		require.NoError(t, err)
+		require.Len(t, games, 1)
+		assert.Equal(t, "g1", games[0].ID)
+		assert.Equal(t, entity.PlayerO, games[0].Winner)
+		assert.True(t, moved.IsOngoing())
+	})
+
+	t.Run("Leaves the games a move came in between the read and the write", func(t *testing.T) {
+		// Given: Two games read past their deadlines, one moved in and one finished by a move before they are stored
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		now := time.Now()
+		moved := newTimedOutGame("g1")
+		finished := newTimedOutGame("g2")
+
+		mockGameRepo.EXPECT().
+			GetTimedOut(ctx, now).
+			Return([]*entity.Game{moved, finished}, nil).
+			Once()
+
+		mockGameRepo.EXPECT().
+			UpdateIfDeadline(ctx, moved, *moved.Clock.Deadline).
+			Return(apperror.ErrGameChanged).
+			Once()
+
+		mockGameRepo.EXPECT().
+			UpdateIfDeadline(ctx, finished, *finished.Clock.Deadline).
+			Return(apperror.ErrGameNotFound).
+			Once()
+
+		// When: TimeOutGames is called
+		games, err := useCaseInstance.TimeOutGames(ctx, now)
+
+		// Then: Neither game should be ended on time, the moves stand
+		require.NoError(t, err)
+		assert.Empty(t, games)
+	})
+
+	t.Run("Returns the error of a game that fails to end", func(t *testing.T) {
+		// Given: A game past its deadline the repository fails to store
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		now := time.Now()
+		timedOut := newTimedOutGame("g1")
+
+		mockGameRepo.EXPECT().
+			GetTimedOut(ctx, now).
+			Return([]*entity.Game{timedOut}, nil).
+			Once()
+
+		mockGameRepo.EXPECT().
+			UpdateIfDeadline(ctx, timedOut, *timedOut.Clock.Deadline).
+			Return(errRedisDown).
+			Once()
+
+		// When: TimeOutGames is called
+		games, err := useCaseInstance.TimeOutGames(ctx, now)
+
+		// Then: The error should be returned without the game
+		require.ErrorIs(t, err, errRedisDown)
+		assert.Empty(t, games)
+	})
+
+	t.Run("Returns the error of the repository", func(t *testing.T) {
+		// Given: A repository that fails
+		mockGameRepo := mockedUseCase.NewMockgameRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockGameRepo, mockedUseCase.NewMockarchiveRepoDep(t))
+
+		now := time.Now()
+		mockGameRepo.EXPECT().
+			GetTimedOut(ctx, now).
+			Return(nil, errRedisDown).
+			Once()
+
+		// When: TimeOutGames is called
+		games, err := useCaseInstance.TimeOutGames(ctx, now)
+
+		// Then: The error should be returned
+		require.ErrorIs(t, err, errRedisDown)
+		assert.Nil(t, games)
+	})
+}
+
+func TestGameUseCase_GetHistory(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		wantOffset    int
+		wantLimit     int
+	}{
+		{name: "Passes the page as asked", offset: 20, limit: 10, wantOffset: 20, wantLimit: 10},
+		{name: "Falls back to the default page size", offset: 0, limit: 0, wantOffset: 0, wantLimit: DefaultHistoryLimit},
+		{name: "Caps the page size", offset: 0, limit: 1000, wantOffset: 0, wantLimit: MaxHistoryLimit},
+		{name: "Starts a negative offset from the latest game", offset: -5, limit: 5, wantOffset: 0, wantLimit: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A player with an archived game
+			mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+			useCaseInstance := NewGameUseCase(mockedUseCase.NewMockplayerRepoDep(t), mockedUseCase.NewMockgameRepoDep(t), mockArchiveRepo)
+
+			archived := []*entity.ArchivedGame{{ID: "g1", Status: entity.StatusFinished}}
+			mockArchiveRepo.EXPECT().
+				GetByPlayerID(ctx, "p1", tt.wantOffset, tt.wantLimit).
+				Return(archived, 1, nil).
+				Once()
+
+			// When: GetHistory is called
+			games, total, err := useCaseInstance.GetHistory(ctx, "p1", tt.offset, tt.limit)
+
+			// Then: The page of the archive should be returned
+			require.NoError(t, err)
+			assert.Equal(t, archived, games)
+			assert.Equal(t, 1, total)
+		})
+	}
+}
+
+func TestGameUseCase_GetArchivedGame(t *testing.T) {
+	ctx := context.Background()
+	player := &entity.Player{ID: "p1", PublicID: "pub1"}
+	archived := &entity.ArchivedGame{
+		ID:      "g1",
+		Status:  entity.StatusFinished,
+		Players: []*entity.Player{{PublicID: "pub1", Mark: entity.PlayerX}, {PublicID: "pub2", Mark: entity.PlayerO}},
+	}
+
+	t.Run("Returns the archived game to its player", func(t *testing.T) {
+		// Given: A finished game of the player in the archive
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockedUseCase.NewMockgameRepoDep(t), mockArchiveRepo)
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "p1").
+			Return(player, nil).
+			Once()
+		mockArchiveRepo.EXPECT().
+			GetByID(ctx, "g1").
+			Return(archived, nil).
+			Once()
+
+		// When: GetArchivedGame is called
+		game, err := useCaseInstance.GetArchivedGame(ctx, "p1", "g1")
+
+		// Then: The archived game should be returned
+		require.NoError(t, err)
+		assert.Equal(t, archived, game)
+	})
+
+	t.Run("Returns ErrGameNotFound for a game of other players", func(t *testing.T) {
+		// Given: A player who did not play in the archived game
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockedUseCase.NewMockgameRepoDep(t), mockArchiveRepo)
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "p3").
+			Return(&entity.Player{ID: "p3", PublicID: "pub3"}, nil).
+			Once()
+		mockArchiveRepo.EXPECT().
+			GetByID(ctx, "g1").
+			Return(archived, nil).
+			Once()
+
+		// When: GetArchivedGame is called with the guessed ID
+		game, err := useCaseInstance.GetArchivedGame(ctx, "p3", "g1")
+
+		// Then: The game should not be found
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+		assert.Nil(t, game)
+	})
+
+	t.Run("Returns ErrGameNotFound for a game out of the archive", func(t *testing.T) {
+		// Given: An archive without the game
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		mockArchiveRepo := mockedUseCase.NewMockarchiveRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockedUseCase.NewMockgameRepoDep(t), mockArchiveRepo)
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "p1").
+			Return(player, nil).
+			Once()
+		mockArchiveRepo.EXPECT().
+			GetByID(ctx, "missing").
+			Return(nil, apperror.ErrGameNotFound).
+			Once()
+
+		// When: GetArchivedGame is called
+		game, err := useCaseInstance.GetArchivedGame(ctx, "p1", "missing")
+
+		// Then: The error should be returned
+		require.ErrorIs(t, err, apperror.ErrGameNotFound)
+		assert.Nil(t, game)
+	})
+
+	t.Run("Returns the error of an unknown player", func(t *testing.T) {
+		// Given: A player out of the storage
+		mockPlayerRepo := mockedUseCase.NewMockplayerRepoDep(t)
+		useCaseInstance := NewGameUseCase(mockPlayerRepo, mockedUseCase.NewMockgameRepoDep(t), mockedUseCase.NewMockarchiveRepoDep(t))
+
+		mockPlayerRepo.EXPECT().
+			GetByID(ctx, "ghost").
+			Return(nil, apperror.ErrPlayerNotFound).
+			Once()
+
+		// When: GetArchivedGame is called
+		game, err := useCaseInstance.GetArchivedGame(ctx, "ghost", "g1")
+
+		// Then: The archive should not be read
+		require.ErrorIs(t, err, apperror.ErrPlayerNotFound)
+		assert.Nil(t, game)
 	})
 }
@@ -7,6 +7,8 @@ import (
 
 	entity "github.com/rocketscienceinc/tictactoe-backend/internal/entity"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // MockgameRepoDep is an autogenerated mock type for the gameRepoDep type
@@ -175,9 +177,9 @@ func (_c *MockgameRepoDep_GetByID_Call) RunAndReturn(run func(context.Context, s
 	return _c
 }
 
-// GetOpenPublicGame provides a mock function with given fields: ctx
-func (_m *MockgameRepoDep) GetOpenPublicGame(ctx context.Context) (*entity.Game, error) {
-	ret := _m.Called(ctx)
+// GetOpenPublicGame provides a mock function with given fields: ctx, settings
+func (_m *MockgameRepoDep) GetOpenPublicGame(ctx context.Context, settings entity.GameSettings) (*entity.Game, error) {
+	ret := _m.Called(ctx, settings)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetOpenPublicGame")
@@ -185,19 +187,19 @@ func (_m *MockgameRepoDep) GetOpenPublicGame(ctx context.Context) (*entity.Game,
 
 	var r0 *entity.Game
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (*entity.Game, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.GameSettings) (*entity.Game, error)); ok {
+		return rf(ctx, settings)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) *entity.Game); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, entity.GameSettings) *entity.Game); ok {
+		r0 = rf(ctx, settings)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*entity.Game)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, entity.GameSettings) error); ok {
+		r1 = rf(ctx, settings)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -212,13 +214,14 @@ type MockgameRepoDep_GetOpenPublicGame_Call struct {
 
 // GetOpenPublicGame is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockgameRepoDep_Expecter) GetOpenPublicGame(ctx interface{}) *MockgameRepoDep_GetOpenPublicGame_Call {
-	return &MockgameRepoDep_GetOpenPublicGame_Call{Call: _e.mock.On("GetOpenPublicGame", ctx)}
+//   - settings entity.GameSettings
+func (_e *MockgameRepoDep_Expecter) GetOpenPublicGame(ctx interface{}, settings interface{}) *MockgameRepoDep_GetOpenPublicGame_Call {
+	return &MockgameRepoDep_GetOpenPublicGame_Call{Call: _e.mock.On("GetOpenPublicGame", ctx, settings)}
 }
 
-func (_c *MockgameRepoDep_GetOpenPublicGame_Call) Run(run func(ctx context.Context)) *MockgameRepoDep_GetOpenPublicGame_Call {
+func (_c *MockgameRepoDep_GetOpenPublicGame_Call) Run(run func(ctx context.Context, settings entity.GameSettings)) *MockgameRepoDep_GetOpenPublicGame_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(entity.GameSettings))
 	})
 	return _c
 }
@@ -228,7 +231,162 @@ func (_c *MockgameRepoDep_GetOpenPublicGame_Call) Return(_a0 *entity.Game, _a1 e
 	return _c
 }
 
-func (_c *MockgameRepoDep_GetOpenPublicGame_Call) RunAndReturn(run func(context.Context) (*entity.Game, error)) *MockgameRepoDep_GetOpenPublicGame_Call {
+func (_c *MockgameRepoDep_GetOpenPublicGame_Call) RunAndReturn(run func(context.Context, entity.GameSettings) (*entity.Game, error)) *MockgameRepoDep_GetOpenPublicGame_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTimedOut provides a mock function with given fields: ctx, now
+func (_m *MockgameRepoDep) GetTimedOut(ctx context.Context, now time.Time) ([]*entity.Game, error) {
+	ret := _m.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTimedOut")
+	}
+
+	var r0 []*entity.Game
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]*entity.Game, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []*entity.Game); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*entity.Game)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockgameRepoDep_GetTimedOut_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTimedOut'
+type MockgameRepoDep_GetTimedOut_Call struct {
+	*mock.Call
+}
+
+// GetTimedOut is a helper method to define mock.On call
+//   - ctx context.Context
+//   - now time.Time
+func (_e *MockgameRepoDep_Expecter) GetTimedOut(ctx interface{}, now interface{}) *MockgameRepoDep_GetTimedOut_Call {
+	return &MockgameRepoDep_GetTimedOut_Call{Call: _e.mock.On("GetTimedOut", ctx, now)}
+}
+
+func (_c *MockgameRepoDep_GetTimedOut_Call) Run(run func(ctx context.Context, now time.Time)) *MockgameRepoDep_GetTimedOut_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockgameRepoDep_GetTimedOut_Call) Return(_a0 []*entity.Game, _a1 error) *MockgameRepoDep_GetTimedOut_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockgameRepoDep_GetTimedOut_Call) RunAndReturn(run func(context.Context, time.Time) ([]*entity.Game, error)) *MockgameRepoDep_GetTimedOut_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateIfDeadline provides a mock function with given fields: ctx, game, deadline
+func (_m *MockgameRepoDep) UpdateIfDeadline(ctx context.Context, game *entity.Game, deadline time.Time) error {
+	ret := _m.Called(ctx, game, deadline)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateIfDeadline")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Game, time.Time) error); ok {
+		r0 = rf(ctx, game, deadline)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockgameRepoDep_UpdateIfDeadline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateIfDeadline'
+type MockgameRepoDep_UpdateIfDeadline_Call struct {
+	*mock.Call
+}
+
+// UpdateIfDeadline is a helper method to define mock.On call
+//   - ctx context.Context
+//   - game *entity.Game
+//   - deadline time.Time
+func (_e *MockgameRepoDep_Expecter) UpdateIfDeadline(ctx interface{}, game interface{}, deadline interface{}) *MockgameRepoDep_UpdateIfDeadline_Call {
+	return &MockgameRepoDep_UpdateIfDeadline_Call{Call: _e.mock.On("UpdateIfDeadline", ctx, game, deadline)}
+}
+
+func (_c *MockgameRepoDep_UpdateIfDeadline_Call) Run(run func(ctx context.Context, game *entity.Game, deadline time.Time)) *MockgameRepoDep_UpdateIfDeadline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*entity.Game), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockgameRepoDep_UpdateIfDeadline_Call) Return(_a0 error) *MockgameRepoDep_UpdateIfDeadline_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockgameRepoDep_UpdateIfDeadline_Call) RunAndReturn(run func(context.Context, *entity.Game, time.Time) error) *MockgameRepoDep_UpdateIfDeadline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateIfMoves provides a mock function with given fields: ctx, game, moves
+func (_m *MockgameRepoDep) UpdateIfMoves(ctx context.Context, game *entity.Game, moves int) error {
+	ret := _m.Called(ctx, game, moves)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateIfMoves")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Game, int) error); ok {
+		r0 = rf(ctx, game, moves)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockgameRepoDep_UpdateIfMoves_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateIfMoves'
+type MockgameRepoDep_UpdateIfMoves_Call struct {
+	*mock.Call
+}
+
+// UpdateIfMoves is a helper method to define mock.On call
+//   - ctx context.Context
+//   - game *entity.Game
+//   - moves int
+func (_e *MockgameRepoDep_Expecter) UpdateIfMoves(ctx interface{}, game interface{}, moves interface{}) *MockgameRepoDep_UpdateIfMoves_Call {
+	return &MockgameRepoDep_UpdateIfMoves_Call{Call: _e.mock.On("UpdateIfMoves", ctx, game, moves)}
+}
+
+func (_c *MockgameRepoDep_UpdateIfMoves_Call) Run(run func(ctx context.Context, game *entity.Game, moves int)) *MockgameRepoDep_UpdateIfMoves_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*entity.Game), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockgameRepoDep_UpdateIfMoves_Call) Return(_a0 error) *MockgameRepoDep_UpdateIfMoves_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockgameRepoDep_UpdateIfMoves_Call) RunAndReturn(run func(context.Context, *entity.Game, int) error) *MockgameRepoDep_UpdateIfMoves_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+// isBotTurn - reports whether the game waits for the move of its bot. Ask it before the game is masked,
+// the masked game has no players to find the bot among.
+func isBotTurn(game *entity.Game) bool {
+	botPlayer := game.GetBotPlayer()
+
+	return game.IsOngoing() && botPlayer != nil && game.Turn == botPlayer.Mark
+}
+
+// playBotTurn - makes the move of the bot off the reader of the player, the search of a strong bot takes
+// up to a second and the player's messages keep being read meanwhile. The move comes to the player as an event.
+// Call it after the player got the game the bot answers to, so the events come in the order of the moves.
+func (that *Server) playBotTurn(ctx context.Context, gameID string) {
+	// the bot finishes its move even if the player disconnects meanwhile, they find it when they return
+	ctx = context.WithoutCancel(ctx)
+
+	go that.makeBotTurn(ctx, gameID)
+}
+
+// makeBotTurn - makes the move of the bot and sends the game to the player and to the spectators.
+func (that *Server) makeBotTurn(ctx context.Context, gameID string) {
+	log := that.logger.With("method", "makeBotTurn", "gameID", gameID)
+
+	// nobody asked for the move, so the player gets it as an event
+	event := &Message{Action: actionGameTurn}
+
+	game, err := that.gameUseCase.MakeBotTurn(ctx, gameID)
+	if errors.Is(err, apperror.ErrGameFinished) && game != nil {
+		if err = that.handleGameFinished(nil, event, game); err != nil {
+			log.Error("failed to finish game", "error", err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		log.Error("failed to make bot turn", "error", err)
+		return
+	}
+
+	for _, player := range game.Players {
+		if player.IsBot() {
+			continue
+		}
+
+		playerSession, ok := that.sessionByPlayerID(player.ID)
+		if !ok {
+			log.Warn("connection not found for player", "playerID", player.ID)
+			continue
+		}
+
+		payloadResp := Payload{
+			Player: maskPlayerDetails(player),
+			Game:   maskGameDetails(game),
+		}
+
+		if err = that.sendEvent(playerSession, event.Action, payloadResp); err != nil {
+			log.Error("failed to send bot turn", "error", err)
+		}
+	}
+
+	that.notifySpectators(event.Action, game, "")
+
+	log.Info("Bot made a turn")
+}
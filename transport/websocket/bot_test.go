@@ -0,0 +1,195 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+// testBotGame - an ongoing classic game of p1 as X against the bot, with the moves played in turn.
+func testBotGame(t *testing.T, cells ...int) *entity.Game {
+	t.Helper()
+
+	game := entity.NewGame("bot-game", entity.WithBotType)
+	game.Players = []*entity.Player{
+		{ID: "p1", PublicID: "pub1", GameID: game.ID, Mark: entity.PlayerX},
+		entity.NewBotPlayer(game.ID, entity.PlayerO),
+	}
+	game.Start()
+
+	for _, cell := range cells {
+		require.NoError(t, game.MakeTurn(game.Turn, cell))
+	}
+
+	return game
+}
+
+func TestIsBotTurn(t *testing.T) {
+	finished := testBotGame(t)
+	finished.Status = entity.StatusFinished
+
+	pvp := entity.NewGame("pvp", entity.PrivateType)
+	pvp.Players = []*entity.Player{{ID: "p1", Mark: entity.PlayerX}, {ID: "p2", Mark: entity.PlayerO}}
+	pvp.Start()
+
+	assert.False(t, isBotTurn(testBotGame(t)), "player to move")
+	assert.True(t, isBotTurn(testBotGame(t, 4)), "bot to move")
+	assert.False(t, isBotTurn(finished), "finished game")
+	assert.False(t, isBotTurn(pvp), "game without a bot")
+}
+
+func TestServer_HandleGameTurn_Bot(t *testing.T) {
+	turnMessage := func() *Message {
+		return &Message{ID: "1", Action: actionGameTurn, Payload: json.RawMessage(`{"cell":4}`)}
+	}
+
+	t.Run("Bot moves off the reader and its move comes as an event", func(t *testing.T) {
+		// Given: a bot whose search does not end until the test lets it
+		release := make(chan struct{})
+		uc := &stubGameUseCase{
+			makeTurn: func(context.Context, string, int, string) (*entity.Game, error) {
+				return testBotGame(t, 4), nil
+			},
+			makeBotTurn: func(_ context.Context, gameID string) (*entity.Game, error) {
+				assert.Equal(t, "bot-game", gameID)
+				<-release
+				return testBotGame(t, 4, 0), nil
+			},
+		}
+		server, _ := newTestServer(t, testConfig(), uc)
+		session, client := boundSession(t, server, "p1")
+
+		// When: the player makes a turn
+		handled := make(chan error, 1)
+		go func() { handled <- server.handleGameTurn(context.Background(), turnMessage(), session) }()
+
+		// Then: the handler should return and reply while the bot still searches
+		select {
+		case err := <-handled:
+			require.NoError(t, err)
+		case <-time.After(testTimeout):
+			require.FailNow(t, "the handler waited for the bot")
+		}
+
+		reply, payload := client.readMessage()
+		assert.Equal(t, messageTypeResponse, reply.Type)
+		assert.Equal(t, "1", reply.ID)
+		assert.Equal(t, entity.PlayerO, payload["game"].(map[string]any)["player_turn"])
+
+		// When: the bot finds its move
+		close(release)
+
+		// Then: the player should get the move of the bot as an event
+		event, payload := client.readMessage()
+		assert.Equal(t, messageTypeEvent, event.Type)
+		assert.Equal(t, actionGameTurn, event.Action)
+		assert.Empty(t, event.ID)
+
+		game := payload["game"].(map[string]any)
+		assert.Equal(t, entity.PlayerO, game["board"].([]any)[0])
+		assert.Equal(t, entity.PlayerX, game["player_turn"])
+		assert.NotContains(t, game, "players")
+	})
+
+	t.Run("Bot move that ends the game comes as the final state", func(t *testing.T) {
+		// Given: a bot that wins with its move
+		won := testBotGame(t, 4, 0)
+		won.Status = entity.StatusFinished
+		won.Winner = entity.PlayerO
+
+		uc := &stubGameUseCase{
+			makeTurn: func(context.Context, string, int, string) (*entity.Game, error) {
+				return testBotGame(t, 4), nil
+			},
+			makeBotTurn: func(context.Context, string) (*entity.Game, error) {
+				return won, apperror.ErrGameFinished
+			},
+		}
+		server, _ := newTestServer(t, testConfig(), uc)
+		session, client := boundSession(t, server, "p1")
+
+		// When: the player makes a turn
+		require.NoError(t, server.handleGameTurn(context.Background(), turnMessage(), session))
+
+		// Then: the reply should be followed by the finished game
+		client.readMessage()
+
+		event, payload := client.readMessage()
+		assert.Equal(t, messageTypeEvent, event.Type)
+		assert.Equal(t, actionGameTurn, event.Action)
+		assert.Equal(t, entity.StatusFinished, payload["game"].(map[string]any)["status"])
+		assert.Equal(t, entity.PlayerO, payload["game"].(map[string]any)["winner"])
+	})
+
+	t.Run("Bot does not move when the turn finished the game", func(t *testing.T) {
+		// Given: a player who wins with the turn
+		var botTurns atomic.Int32
+
+		won := testBotGame(t, 0, 3, 1, 4)
+		won.Status = entity.StatusFinished
+		won.Winner = entity.PlayerX
+
+		uc := &stubGameUseCase{
+			makeTurn: func(context.Context, string, int, string) (*entity.Game, error) {
+				return won, apperror.ErrGameFinished
+			},
+			makeBotTurn: func(context.Context, string) (*entity.Game, error) {
+				botTurns.Add(1)
+				return nil, apperror.ErrGameFinished
+			},
+		}
+		server, _ := newTestServer(t, testConfig(), uc)
+		session, client := boundSession(t, server, "p1")
+
+		// When: the player makes the turn
+		require.NoError(t, server.handleGameTurn(context.Background(), turnMessage(), session))
+
+		// Then: the player should get the final state and the bot should not be asked to move
+		_, payload := client.readMessage()
+		assert.Equal(t, entity.StatusFinished, payload["game"].(map[string]any)["status"])
+		assert.Never(t, func() bool { return botTurns.Load() > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+	})
+}
+
+func TestServer_HandleNewGame_BotMovesFirst(t *testing.T) {
+	// Given: a new game the bot got the first move in
+	created := testBotGame(t)
+	created.Players[0].Mark, created.Players[1].Mark = entity.PlayerO, entity.PlayerX
+
+	moved := testBotGame(t)
+	moved.Players[0].Mark, moved.Players[1].Mark = entity.PlayerO, entity.PlayerX
+	require.NoError(t, moved.MakeTurn(entity.PlayerX, 4))
+
+	uc := &stubGameUseCase{
+		getOrCreateGame: func(context.Context, string, string, string, int, entity.GameSettings) (*entity.Game, error) {
+			return created, nil
+		},
+		makeBotTurn: func(context.Context, string) (*entity.Game, error) {
+			return moved, nil
+		},
+	}
+	server, _ := newTestServer(t, testConfig(), uc)
+	session, client := boundSession(t, server, "p1")
+
+	// When: the player starts the game
+	msg := &Message{ID: "1", Action: "game:new", Payload: json.RawMessage(`{"game":{"type":"bot"}}`)}
+	require.NoError(t, server.handleNewGame(context.Background(), msg, session))
+
+	// Then: the player should get the empty board first and the opening move of the bot after it
+	reply, payload := client.readMessage()
+	assert.Equal(t, messageTypeResponse, reply.Type)
+	assert.Equal(t, entity.PlayerX, payload["game"].(map[string]any)["player_turn"])
+
+	event, payload := client.readMessage()
+	assert.Equal(t, messageTypeEvent, event.Type)
+	assert.Equal(t, actionGameTurn, event.Action)
+	assert.Equal(t, entity.PlayerX, payload["game"].(map[string]any)["board"].([]any)[4])
+}
@@ -0,0 +1,49 @@
+package websocket
+
+import (
+	"context"
+	"time"
+)
+
+// actionGameTimeout - the event the players and the spectators of a game get when it is lost on time.
+const actionGameTimeout = "game:timeout"
+
+// monitorClocks - ends the timed games whose clocks ran out. The deadlines live in the repository,
+// so the games are timed out after a restart as well.
+func (that *Server) monitorClocks(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	log := that.logger.With("method", "monitorClocks")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("context cancelled, stopping clock monitor")
+			return
+		case now := <-ticker.C:
+			that.handleTimeouts(ctx, now)
+		}
+	}
+}
+
+// handleTimeouts - times out the games whose clocks ran out by now and sends their final state to everyone in them.
+func (that *Server) handleTimeouts(ctx context.Context, now time.Time) {
+	log := that.logger.With("method", "handleTimeouts")
+
+	games, err := that.gameUseCase.TimeOutGames(ctx, now)
+	if err != nil {
+		log.Error("failed to time out games", "error", err)
+	}
+
+	// nobody asked for the result, so every player gets it as an event
+	event := &Message{Action: actionGameTimeout}
+
+	for _, game := range games {
+		log.Info("game lost on time", "gameID", game.ID, "winner", game.Winner)
+
+		if err = that.handleGameFinished(nil, event, game); err != nil {
+			log.Error("failed to send timeout", "gameID", game.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+// testTimedOutGame - a timed game of p1 and p2 that X lost on time.
+func testTimedOutGame() *entity.Game {
+	game := entity.NewGameWithSettings("timed", entity.PrivateType, entity.GameSettings{TimeControl: entity.TimeControl{MoveLimitMs: 10000}})
+	game.Players = []*entity.Player{
+		{ID: "p1", PublicID: "pub1", GameID: game.ID, Mark: entity.PlayerX},
+		{ID: "p2", PublicID: "pub2", GameID: game.ID, Mark: entity.PlayerO},
+	}
+	game.Start()
+	game.TimeOut()
+
+	return game
+}
+
+// assertTimeout - reads the next message, it must be the game lost on time by X.
+func assertTimeout(t *testing.T, client *testClient) {
+	t.Helper()
+
+	event, payload := client.readMessage()
+	assert.Equal(t, messageTypeEvent, event.Type)
+	assert.Equal(t, actionGameTimeout, event.Action)
+
+	game := payload["game"].(map[string]any)
+	assert.Equal(t, entity.StatusFinished, game["status"])
+	assert.Equal(t, entity.PlayerO, game["winner"])
+	assert.Equal(t, entity.FinishReasonTimeout, game["finish_reason"])
+}
+
+func TestServer_MonitorClocks(t *testing.T) {
+	t.Run("Sends the games lost on time to their players and spectators", func(t *testing.T) {
+		// Given: a game whose clock runs out once, with both players and a spectator connected
+		var checks atomic.Int32
+
+		uc := &stubGameUseCase{
+			timeOutGames: func(context.Context, time.Time) ([]*entity.Game, error) {
+				if checks.Add(1) > 1 {
+					return nil, nil
+				}
+
+				return []*entity.Game{testTimedOutGame()}, nil
+			},
+		}
+
+		conf := testConfig()
+		conf.MaxSpectators = 1
+
+		// When: the clock monitor of the server checks the clocks
+		server, _ := newTestServer(t, conf, uc)
+		_, player1 := boundSession(t, server, "p1")
+		_, player2 := boundSession(t, server, "p2")
+		spectator, watcher := boundSession(t, server, "s1")
+		_, ok := server.subscribeSpectator(spectator, "timed")
+		require.True(t, ok)
+
+		// Then: everyone in the game should get its end on time and the spectator should stop watching it
+		assertTimeout(t, player1)
+		assertTimeout(t, player2)
+		assertTimeout(t, watcher)
+
+		assert.Eventually(t, func() bool { return server.spectatorCount("timed") == 0 }, testTimeout, 10*time.Millisecond)
+	})
+
+	t.Run("Sends the games that ended along with an error", func(t *testing.T) {
+		// Given: a check that ended a game and failed to end another one, the checks of the monitor end none
+		checkedAt := time.Unix(0, 0)
+
+		uc := &stubGameUseCase{
+			timeOutGames: func(_ context.Context, now time.Time) ([]*entity.Game, error) {
+				if !now.Equal(checkedAt) {
+					return nil, nil
+				}
+
+				return []*entity.Game{testTimedOutGame()}, errors.New("failed to time out game other")
+			},
+		}
+		server, _ := newTestServer(t, testConfig(), uc)
+		_, player1 := boundSession(t, server, "p1")
+
+		// When: the timeouts are handled
+		server.handleTimeouts(context.Background(), checkedAt)
+
+		// Then: the ended game should still be sent
+		assertTimeout(t, player1)
+	})
+
+	t.Run("Stops with its context", func(t *testing.T) {
+		// Given: a server and a context that is over
+		server, _ := newTestServer(t, testConfig(), nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// When: the clock monitor runs
+		stopped := make(chan struct{})
+		go func() {
+			server.monitorClocks(ctx)
+			close(stopped)
+		}()
+
+		// Then: it should return
+		select {
+		case <-stopped:
+		case <-time.After(testTimeout):
+			require.FailNow(t, "the clock monitor did not stop")
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var ErrInvalidMessagePack = errors.New("invalid msgpack")
+
+// codec encodes messages for the wire. It is negotiated per connection together with the protocol version,
+// handlers keep working with Message and Payload and never see the encoding in use.
+type codec interface {
+	// Marshal - encodes a value, anything encoding/json is able to marshal is accepted.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal - decodes a message into a value the way encoding/json would.
+	Unmarshal(data []byte, v any) error
+	// OpCode - the opcode of the data frames carrying the encoded messages.
+	OpCode() byte
+}
+
+// jsonCodec sends messages as JSON in text frames.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v) //nolint: wrapcheck // the codec is a thin wrapper around encoding/json
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v) //nolint: wrapcheck // the codec is a thin wrapper around encoding/json
+}
+
+func (jsonCodec) OpCode() byte {
+	return opText
+}
+
+// msgpackCodec sends messages as MessagePack in binary frames. Values are encoded by their json tags, so a message
+// carries the same keys in both codecs, times travel as MessagePack timestamps.
+// Incoming messages are decoded to generic values and handed to encoding/json, the payload stays raw JSON
+// for the handlers whatever the codec of the session.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	enc.SetSortMapKeys(true)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode msgpack: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	var generic any
+	if err := msgpack.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessagePack, err)
+	}
+
+	message, err := json.Marshal(generic)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessagePack, err)
+	}
+
+	if err = json.Unmarshal(message, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessagePack, err)
+	}
+
+	return nil
+}
+
+func (msgpackCodec) OpCode() byte {
+	return opBinary
+}
@@ -0,0 +1,160 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+// jsonShape - the generic value encoding/json gives the value.
+func jsonShape(t *testing.T, v any) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	var shape map[string]any
+	require.NoError(t, json.Unmarshal(data, &shape))
+
+	return shape
+}
+
+// msgpackShape - the generic value the msgpack codec gives the value, read back through the codec.
+func msgpackShape(t *testing.T, v any) map[string]any {
+	t.Helper()
+
+	data, err := msgpackCodec{}.Marshal(v)
+	require.NoError(t, err)
+
+	var shape map[string]any
+	require.NoError(t, msgpackCodec{}.Unmarshal(data, &shape))
+
+	return shape
+}
+
+func TestMsgpackCodec_Marshal(t *testing.T) {
+	cell := 4
+
+	game := entity.NewGame("game-1", entity.PrivateType)
+	game.Players = []*entity.Player{
+		{ID: "p1", PublicID: "pub1", GameID: game.ID, Mark: entity.PlayerX},
+		{ID: "p2", PublicID: "pub2", GameID: game.ID, Mark: entity.PlayerO},
+	}
+	game.Start()
+	game.StartedAt = nil // times are compared as instants below, the wire does not keep their zone
+
+	tests := []struct {
+		name  string
+		value outboundMessage
+	}{
+		{
+			name:  "Version 1 event with a game",
+			value: outboundMessage{Type: messageTypeEvent, Action: "game:turn", Payload: versionedPayload(protocolVersion1, Payload{Game: game, Cell: &cell})},
+		},
+		{
+			name: "Version 1 error",
+			value: outboundMessage{ID: "9", Type: messageTypeError, Action: "game:turn", Payload: versionedPayload(protocolVersion1, Payload{
+				Error: "It's not your turn", ErrorCode: CodeNotYourTurn,
+			})},
+		},
+		{
+			name: "Version 2 error object hides the plain error fields",
+			value: outboundMessage{ID: "9", Type: messageTypeError, Action: "game:turn", Payload: versionedPayload(protocolVersion2, Payload{
+				Error: "It's not your turn", ErrorCode: CodeNotYourTurn, Cell: &cell,
+			})},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: the message is encoded as MessagePack and as JSON
+			got := msgpackShape(t, tt.value)
+
+			// Then: both should carry the same keys and values
+			assert.Equal(t, jsonShape(t, tt.value), got)
+		})
+	}
+
+	t.Run("Times travel as MessagePack timestamps", func(t *testing.T) {
+		// Given: a game that started at a known time
+		startedAt := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+		started := &entity.Game{ID: "game-1", StartedAt: &startedAt}
+
+		// When: it is encoded
+		data, err := msgpackCodec{}.Marshal(started)
+		require.NoError(t, err)
+
+		// Then: its start should decode as a timestamp of the same instant
+		var decoded struct {
+			StartedAt time.Time `msgpack:"started_at"`
+		}
+		require.NoError(t, msgpack.Unmarshal(data, &decoded))
+		assert.True(t, startedAt.Equal(decoded.StartedAt))
+	})
+
+	t.Run("Version 2 error carries no error code next to the error object", func(t *testing.T) {
+		// Given: an error in the shape of version 2
+		value := versionedPayload(protocolVersion2, Payload{Error: "It's not your turn", ErrorCode: CodeNotYourTurn})
+
+		// When: it is encoded
+		data, err := msgpackCodec{}.Marshal(value)
+		require.NoError(t, err)
+
+		// Then: the error object should be the only error field
+		var payload map[string]any
+		require.NoError(t, msgpack.Unmarshal(data, &payload))
+		assert.Equal(t, map[string]any{"error": map[string]any{"code": string(CodeNotYourTurn), "message": "It's not your turn"}}, payload)
+	})
+}
+
+func TestMsgpackCodec_Unmarshal(t *testing.T) {
+	t.Run("Payload stays raw JSON for the handlers", func(t *testing.T) {
+		// Given: a request of a client encoded as MessagePack
+		data, err := msgpack.Marshal(map[string]any{
+			"id":      "1",
+			"action":  "game:turn",
+			"payload": map[string]any{"cell": 4},
+		})
+		require.NoError(t, err)
+
+		// When: it is decoded
+		var message Message
+		require.NoError(t, msgpackCodec{}.Unmarshal(data, &message))
+
+		// Then: the message should be read and its payload handed on as JSON
+		assert.Equal(t, "1", message.ID)
+		assert.Equal(t, "game:turn", message.Action)
+		assert.JSONEq(t, `{"cell":4}`, string(message.Payload))
+	})
+
+	t.Run("Wrong type is refused", func(t *testing.T) {
+		// Given: a request whose id is a number
+		data, err := msgpack.Marshal(map[string]any{"id": 1, "action": "game:turn"})
+		require.NoError(t, err)
+
+		// When: it is decoded
+		var message Message
+		err = msgpackCodec{}.Unmarshal(data, &message)
+
+		// Then: it should be refused as invalid MessagePack
+		require.ErrorIs(t, err, ErrInvalidMessagePack)
+	})
+
+	t.Run("Malformed data is refused", func(t *testing.T) {
+		// Given: a map header promising an entry that never comes
+		data := []byte{0x81}
+
+		// When: it is decoded
+		var message Message
+		err := msgpackCodec{}.Unmarshal(data, &message)
+
+		// Then: it should be refused as invalid MessagePack
+		require.ErrorIs(t, err, ErrInvalidMessagePack)
+	})
+}
@@ -0,0 +1,242 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+const (
+	extensionPermessageDeflate = "permessage-deflate"
+
+	paramServerNoContextTakeover = "server_no_context_takeover"
+	paramClientNoContextTakeover = "client_no_context_takeover"
+	paramServerMaxWindowBits     = "server_max_window_bits"
+	paramClientMaxWindowBits     = "client_max_window_bits"
+
+	minWindowBits = 8
+	maxWindowBits = 15
+	// dictionarySize is the LZ77 window of a stream compressed with maxWindowBits.
+	dictionarySize = 1 << maxWindowBits
+
+	// rsv1Bit marks the first frame of a compressed message (RFC 7692, section 6).
+	rsv1Bit byte = 0x40
+)
+
+var (
+	// deflateTail is stripped from every compressed message and appended back before it is inflated (RFC 7692, section 7.2.1).
+	deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+	// deflateFinalBlock is an empty final block, it makes the inflater report io.EOF at the end of the message.
+	deflateFinalBlock = []byte{0x01, 0x00, 0x00, 0xff, 0xff}
+)
+
+var ErrInvalidCompressedData = errors.New("invalid compressed message")
+
+// deflateParams - the permessage-deflate parameters agreed on during the handshake.
+type deflateParams struct {
+	serverNoContextTakeover bool
+	clientNoContextTakeover bool
+	// serverMaxWindowBits is set when the offer limited the server window, the response has to confirm it.
+	serverMaxWindowBits bool
+}
+
+// String - formats the parameters as the Sec-WebSocket-Extensions response header.
+func (that deflateParams) String() string {
+	parts := []string{extensionPermessageDeflate}
+
+	if that.serverNoContextTakeover {
+		parts = append(parts, paramServerNoContextTakeover)
+	}
+
+	if that.clientNoContextTakeover {
+		parts = append(parts, paramClientNoContextTakeover)
+	}
+
+	if that.serverMaxWindowBits {
+		parts = append(parts, paramServerMaxWindowBits+"="+strconv.Itoa(maxWindowBits))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// deflateExtension - the negotiated permessage-deflate extension of a single connection.
+type deflateExtension struct {
+	params       deflateParams
+	compressor   *compressor
+	decompressor *decompressor
+}
+
+// negotiateDeflate - accepts the first permessage-deflate offer the server is able to honour.
+// It returns nil when compression is disabled or the client offered nothing acceptable.
+func negotiateDeflate(header http.Header, conf config.Compression) (*deflateExtension, error) {
+	if !conf.Enabled {
+		return nil, nil //nolint: nilnil // no extension is a valid outcome of the negotiation
+	}
+
+	for _, value := range header.Values(headerSecWebSocketExtensions) {
+		for _, offer := range strings.Split(value, ",") {
+			params, ok := acceptDeflateOffer(offer, conf)
+			if !ok {
+				continue
+			}
+
+			comp, err := newCompressor(conf, params.serverNoContextTakeover)
+			if err != nil {
+				return nil, err
+			}
+
+			return &deflateExtension{
+				params:       params,
+				compressor:   comp,
+				decompressor: &decompressor{noContextTakeover: params.clientNoContextTakeover},
+			}, nil
+		}
+	}
+
+	return nil, nil //nolint: nilnil // no extension is a valid outcome of the negotiation
+}
+
+// acceptDeflateOffer - parses a single extension offer, offers with unknown or unsupported parameters are declined.
+func acceptDeflateOffer(offer string, conf config.Compression) (deflateParams, bool) {
+	parts := strings.Split(offer, ";")
+	if strings.TrimSpace(parts[0]) != extensionPermessageDeflate {
+		return deflateParams{}, false
+	}
+
+	params := deflateParams{
+		serverNoContextTakeover: conf.ServerNoContextTakeover,
+		clientNoContextTakeover: conf.ClientNoContextTakeover,
+	}
+
+	seen := make(map[string]bool)
+
+	for _, part := range parts[1:] {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.TrimSpace(name)
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		// a parameter may appear only once in an offer
+		if seen[name] {
+			return deflateParams{}, false
+		}
+		seen[name] = true
+
+		switch name {
+		case paramServerNoContextTakeover:
+			params.serverNoContextTakeover = true
+		case paramClientNoContextTakeover:
+			params.clientNoContextTakeover = true
+		case paramServerMaxWindowBits:
+			// the flate package always compresses with the largest window, a smaller one can not be honoured
+			if bits, err := strconv.Atoi(value); err != nil || bits != maxWindowBits {
+				return deflateParams{}, false
+			}
+			params.serverMaxWindowBits = true
+		case paramClientMaxWindowBits:
+			// the inflater reads streams compressed with any window, the value only has to be valid
+			if value == "" {
+				continue
+			}
+			if bits, err := strconv.Atoi(value); err != nil || bits < minWindowBits || bits > maxWindowBits {
+				return deflateParams{}, false
+			}
+		default:
+			return deflateParams{}, false
+		}
+	}
+
+	return params, true
+}
+
+// compressor - compresses outbound messages, it is used by the writer goroutine only.
+type compressor struct {
+	minSize           int
+	noContextTakeover bool
+
+	buf    bytes.Buffer
+	writer *flate.Writer
+}
+
+func newCompressor(conf config.Compression, noContextTakeover bool) (*compressor, error) {
+	comp := &compressor{
+		minSize:           conf.MinSize,
+		noContextTakeover: noContextTakeover,
+	}
+
+	writer, err := flate.NewWriter(&comp.buf, conf.Level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flate writer: %w", err)
+	}
+	comp.writer = writer
+
+	return comp, nil
+}
+
+// compress - compresses the message payload, false means the payload is too small and is sent as it is.
+func (that *compressor) compress(payload []byte) ([]byte, bool, error) {
+	if len(payload) < that.minSize {
+		return nil, false, nil
+	}
+
+	that.buf.Reset()
+
+	if that.noContextTakeover {
+		that.writer.Reset(&that.buf)
+	}
+
+	if _, err := that.writer.Write(payload); err != nil {
+		return nil, false, fmt.Errorf("failed to compress message: %w", err)
+	}
+
+	// Flush ends the message on a byte boundary without closing the stream, so the window survives for the next one
+	if err := that.writer.Flush(); err != nil {
+		return nil, false, fmt.Errorf("failed to flush compressed message: %w", err)
+	}
+
+	return bytes.Clone(bytes.TrimSuffix(that.buf.Bytes(), deflateTail)), true, nil
+}
+
+// decompressor - inflates inbound messages, it is used by the reader goroutine only.
+type decompressor struct {
+	noContextTakeover bool
+
+	reader io.ReadCloser
+	dict   []byte // the tail of the previous messages the peer may still refer to
+}
+
+// decompress - inflates the message, the inflated size is bounded by limit so a small message can not expand without end.
+func (that *decompressor) decompress(payload []byte, limit int64) ([]byte, error) {
+	input := io.MultiReader(bytes.NewReader(payload), bytes.NewReader(deflateTail), bytes.NewReader(deflateFinalBlock))
+
+	if that.reader == nil {
+		that.reader = flate.NewReaderDict(input, that.dict)
+	} else if err := that.reader.(flate.Resetter).Reset(input, that.dict); err != nil { //nolint: forcetypeassert // flate readers always implement Resetter
+		return nil, fmt.Errorf("failed to reset flate reader: %w", err)
+	}
+
+	message, err := io.ReadAll(io.LimitReader(that.reader, limit+1))
+	if err != nil {
+		return nil, newCloseError(closeInvalidPayload, fmt.Errorf("%w: %w", ErrInvalidCompressedData, err))
+	}
+
+	if int64(len(message)) > limit {
+		return nil, newCloseError(closeMessageTooBig, fmt.Errorf("%w: inflated message exceeds %d bytes", ErrMessageTooBig, limit))
+	}
+
+	if !that.noContextTakeover {
+		dict := append(that.dict, message...) //nolint: gocritic // the dictionary is replaced by its own tail
+		if len(dict) > dictionarySize {
+			dict = dict[len(dict)-dictionarySize:]
+		}
+		that.dict = dict
+	}
+
+	return message, nil
+}
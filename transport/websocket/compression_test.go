@@ -0,0 +1,250 @@
+package websocket
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+func testCompression() config.Compression {
+	return config.Compression{Enabled: true, Level: 1, MinSize: 16}
+}
+
+func TestAcceptDeflateOffer(t *testing.T) {
+	tests := []struct {
+		name       string
+		offer      string
+		conf       func(conf *config.Compression)
+		wantOK     bool
+		wantParams deflateParams
+	}{
+		{name: "Plain offer", offer: "permessage-deflate", wantOK: true},
+		{name: "Other extension", offer: "x-webkit-deflate-frame", wantOK: false},
+		{
+			name:       "Client asks the server to reset its context",
+			offer:      "permessage-deflate; server_no_context_takeover",
+			wantOK:     true,
+			wantParams: deflateParams{serverNoContextTakeover: true},
+		},
+		{
+			name:       "Client resets its own context",
+			offer:      "permessage-deflate; client_no_context_takeover",
+			wantOK:     true,
+			wantParams: deflateParams{clientNoContextTakeover: true},
+		},
+		{
+			name:  "Context takeover disabled by the config",
+			offer: "permessage-deflate",
+			conf: func(conf *config.Compression) {
+				conf.ServerNoContextTakeover, conf.ClientNoContextTakeover = true, true
+			},
+			wantOK:     true,
+			wantParams: deflateParams{serverNoContextTakeover: true, clientNoContextTakeover: true},
+		},
+		{name: "Client window without a value", offer: "permessage-deflate; client_max_window_bits", wantOK: true},
+		{name: "Client window in range", offer: "permessage-deflate; client_max_window_bits=10", wantOK: true},
+		{name: "Quoted client window", offer: `permessage-deflate; client_max_window_bits="12"`, wantOK: true},
+		{name: "Client window too large", offer: "permessage-deflate; client_max_window_bits=16", wantOK: false},
+		{name: "Client window too small", offer: "permessage-deflate; client_max_window_bits=7", wantOK: false},
+		{
+			name:       "Largest server window",
+			offer:      "permessage-deflate; server_max_window_bits=15",
+			wantOK:     true,
+			wantParams: deflateParams{serverMaxWindowBits: true},
+		},
+		{name: "Smaller server window can not be honoured", offer: "permessage-deflate; server_max_window_bits=10", wantOK: false},
+		{name: "Server window without a value", offer: "permessage-deflate; server_max_window_bits", wantOK: false},
+		{
+			name:   "Repeated parameter",
+			offer:  "permessage-deflate; client_no_context_takeover; client_no_context_takeover",
+			wantOK: false,
+		},
+		{name: "Unknown parameter", offer: "permessage-deflate; mystery=1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: the compression settings
+			conf := testCompression()
+			if tt.conf != nil {
+				tt.conf(&conf)
+			}
+
+			// When: the offer is parsed
+			params, ok := acceptDeflateOffer(tt.offer, conf)
+
+			// Then: the offer should be accepted with the agreed parameters or declined
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestNegotiateDeflate(t *testing.T) {
+	t.Run("Accepts the first offer the server can honour", func(t *testing.T) {
+		// Given: offers where the first one asks for a window the server can not use
+		header := http.Header{}
+		header.Add(headerSecWebSocketExtensions, "permessage-deflate; server_max_window_bits=9, permessage-deflate; server_no_context_takeover")
+
+		// When: negotiating
+		deflate, err := negotiateDeflate(header, testCompression())
+
+		// Then: the second offer should be accepted and confirmed in the response header
+		require.NoError(t, err)
+		require.NotNil(t, deflate)
+		assert.Equal(t, "permessage-deflate; server_no_context_takeover", deflate.params.String())
+	})
+
+	t.Run("Confirms the server window the client limited", func(t *testing.T) {
+		// Given: an offer that limits the server window to the largest one
+		header := http.Header{}
+		header.Set(headerSecWebSocketExtensions, "permessage-deflate; server_max_window_bits=15; client_max_window_bits")
+
+		// When: negotiating
+		deflate, err := negotiateDeflate(header, testCompression())
+
+		// Then: the response should repeat the limit
+		require.NoError(t, err)
+		require.NotNil(t, deflate)
+		assert.Equal(t, "permessage-deflate; server_max_window_bits=15", deflate.params.String())
+	})
+
+	t.Run("Leaves compression out when it is disabled", func(t *testing.T) {
+		// Given: compression disabled in the config
+		conf := testCompression()
+		conf.Enabled = false
+		header := http.Header{}
+		header.Set(headerSecWebSocketExtensions, "permessage-deflate")
+
+		// When: negotiating
+		deflate, err := negotiateDeflate(header, conf)
+
+		// Then: no extension should be agreed on
+		require.NoError(t, err)
+		assert.Nil(t, deflate)
+	})
+
+	t.Run("Leaves compression out when nothing was offered", func(t *testing.T) {
+		// When: negotiating without offers
+		deflate, err := negotiateDeflate(http.Header{}, testCompression())
+
+		// Then: no extension should be agreed on
+		require.NoError(t, err)
+		assert.Nil(t, deflate)
+	})
+}
+
+func TestCompressor_MinSize(t *testing.T) {
+	// Given: a compressor that skips messages shorter than 16 bytes
+	comp, err := newCompressor(testCompression(), false)
+	require.NoError(t, err)
+
+	// When: compressing messages just below and at the threshold
+	_, shortCompressed, err := comp.compress(bytes.Repeat([]byte("a"), 15))
+	require.NoError(t, err)
+	_, longCompressed, err := comp.compress(bytes.Repeat([]byte("a"), 16))
+	require.NoError(t, err)
+
+	// Then: only the message at the threshold should be compressed
+	assert.False(t, shortCompressed)
+	assert.True(t, longCompressed)
+}
+
+func TestDeflate_RoundTrip(t *testing.T) {
+	update := []byte(`{"type":"event","action":"game:turn","payload":{"game":{"id":"abc","board":["X","","","","O","","","",""],` +
+		`"players":[{"id":"p1","mark":"X"},{"id":"p2","mark":"O"}],"status":"ongoing","turn":"O"}}}`)
+	messages := [][]byte{update, update, []byte(strings.Repeat("ход игрока ", 50))}
+
+	for _, noContextTakeover := range []bool{false, true} {
+		// Given: a compressor and a decompressor that agree on context takeover
+		comp, err := newCompressor(testCompression(), noContextTakeover)
+		require.NoError(t, err)
+		decomp := &decompressor{noContextTakeover: noContextTakeover}
+
+		var sizes []int
+		for _, message := range messages {
+			// When: every message is compressed and inflated in turn
+			compressed, ok, err := comp.compress(message)
+			require.NoError(t, err)
+			require.True(t, ok)
+			sizes = append(sizes, len(compressed))
+
+			inflated, err := decomp.decompress(compressed, 4096)
+
+			// Then: the original message should come back
+			require.NoError(t, err)
+			assert.Equal(t, message, inflated, "no context takeover: %v", noContextTakeover)
+		}
+
+		// And: with context takeover the repeated message should refer back to the first one and shrink
+		if noContextTakeover {
+			assert.Equal(t, sizes[0], sizes[1])
+		} else {
+			assert.Less(t, sizes[1], sizes[0]/2)
+		}
+	}
+}
+
+func TestDecompressor_Limits(t *testing.T) {
+	t.Run("Refuses a message that inflates beyond the limit", func(t *testing.T) {
+		// Given: a megabyte of zeros compressed to a tiny message
+		comp, err := newCompressor(testCompression(), false)
+		require.NoError(t, err)
+		bomb, _, err := comp.compress(make([]byte, 1<<20))
+		require.NoError(t, err)
+		require.Less(t, len(bomb), 4096)
+
+		// When: inflating it with a small limit
+		_, err = (&decompressor{}).decompress(bomb, 4096)
+
+		// Then: inflating should stop at the limit with 1009
+		var closeErr *CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, closeMessageTooBig, closeErr.Code)
+		assert.ErrorIs(t, err, ErrMessageTooBig)
+	})
+
+	t.Run("Refuses data that is not deflate", func(t *testing.T) {
+		// When: inflating garbage
+		_, err := (&decompressor{}).decompress([]byte{0xff, 0xff, 0xff, 0xff}, 4096)
+
+		// Then: the message should be refused with 1007
+		var closeErr *CloseError
+		require.ErrorAs(t, err, &closeErr)
+		assert.Equal(t, closeInvalidPayload, closeErr.Code)
+		assert.ErrorIs(t, err, ErrInvalidCompressedData)
+	})
+}
+
+func TestServer_CompressedMessages(t *testing.T) {
+	// Given: a client that negotiated permessage-deflate
+	conf := testConfig()
+	conf.Compression = testCompression()
+	_, httpServer := newTestServer(t, conf, nil)
+	client, response := dialTestServer(t, httpServer, http.Header{headerSecWebSocketExtensions: {"permessage-deflate"}})
+	require.Equal(t, "permessage-deflate", response.Header.Get(headerSecWebSocketExtensions))
+
+	clientComp, err := newCompressor(testCompression(), false)
+	require.NoError(t, err)
+	request, _, err := clientComp.compress([]byte(`{"id":"1","action":"unknown:action"}`))
+	require.NoError(t, err)
+
+	// When: the client sends a compressed message
+	client.writeRaw(maskedFrame(opText, true, rsv1Bit, request))
+
+	// Then: the server should read it and compress its answer
+	f := client.readFrame()
+	require.True(t, f.compressed)
+
+	answer, err := (&decompressor{}).decompress(f.payload, 4096)
+	require.NoError(t, err)
+	assert.Contains(t, string(answer), `"action":"unknown:action"`)
+}
@@ -0,0 +1,182 @@
+package websocket
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+const (
+	// SlowConsumerDisconnect closes the connection whose send queue is full.
+	SlowConsumerDisconnect = "disconnect"
+	// SlowConsumerDrop drops the frame that does not fit into the send queue.
+	SlowConsumerDrop = "drop"
+)
+
+var (
+	ErrConnectionClosed = errors.New("connection is closed")
+	ErrSendQueueFull    = errors.New("send queue is full")
+)
+
+// connection wraps a hijacked socket. Frames are written only by its own writer goroutine,
+// everybody else puts them into the bounded send queue, so concurrent broadcasts never interleave bytes.
+type connection struct {
+	logger *slog.Logger
+	config config.Websocket
+
+	conn    net.Conn
+	bufRW   *bufio.ReadWriter
+	deflate *deflateExtension // nil unless permessage-deflate was negotiated
+
+	queue   chan frame
+	closing chan *frame // the last frame to write before the socket is closed, nil closes it right away
+	done    chan struct{}
+
+	closed    atomic.Bool
+	closeOnce sync.Once
+}
+
+func newConnection(logger *slog.Logger, conf config.Websocket, conn net.Conn, bufRW *bufio.ReadWriter, deflate *deflateExtension) *connection {
+	c := &connection{
+		logger: logger.With("remoteAddr", conn.RemoteAddr().String()),
+		config: conf,
+
+		conn:    conn,
+		bufRW:   bufRW,
+		deflate: deflate,
+
+		queue:   make(chan frame, conf.SendQueueSize),
+		closing: make(chan *frame, 1),
+		done:    make(chan struct{}),
+	}
+
+	go c.writeLoop()
+
+	return c
+}
+
+// send - puts the frame into the send queue without blocking the caller.
+// When the queue is full the slow consumer policy decides whether the frame is dropped or the connection is closed.
+func (that *connection) send(f frame) error {
+	if that.closed.Load() {
+		return ErrConnectionClosed
+	}
+
+	select {
+	case that.queue <- f:
+		return nil
+	default:
+	}
+
+	if that.config.SlowConsumerPolicy == SlowConsumerDrop {
+		that.logger.Warn("send queue is full, dropping frame", "opcode", f.opCode)
+		return ErrSendQueueFull
+	}
+
+	that.logger.Warn("send queue is full, disconnecting slow consumer")
+	that.close(closePolicyViolation, "slow consumer")
+
+	return ErrSendQueueFull
+}
+
+// close - asks the writer goroutine to send a close frame and to close the socket.
+func (that *connection) close(code uint16, reason string) {
+	f := closeFrame(code, reason)
+	that.shutdown(&f)
+}
+
+// terminate - closes the socket without the closing handshake, used when the peer is already gone.
+func (that *connection) terminate() {
+	that.shutdown(nil)
+}
+
+func (that *connection) shutdown(last *frame) {
+	that.closeOnce.Do(func() {
+		that.closed.Store(true)
+		that.closing <- last
+	})
+}
+
+// wait - blocks until the writer goroutine has closed the socket.
+func (that *connection) wait() {
+	<-that.done
+}
+
+func (that *connection) writeLoop() {
+	log := that.logger.With("method", "writeLoop")
+
+	defer close(that.done)
+	defer that.conn.Close()
+
+	for {
+		// the close request wins over frames that are still queued
+		select {
+		case last := <-that.closing:
+			that.writeLast(last)
+			return
+		default:
+		}
+
+		select {
+		case last := <-that.closing:
+			that.writeLast(last)
+			return
+		case f := <-that.queue:
+			if err := that.write(f); err != nil {
+				log.Error("failed to write frame, closing connection", "error", err)
+				that.closed.Store(true)
+				return
+			}
+		}
+	}
+}
+
+func (that *connection) writeLast(last *frame) {
+	if last == nil {
+		return
+	}
+
+	if err := that.write(*last); err != nil {
+		that.logger.Debug("failed to write close frame", "error", err)
+	}
+}
+
+// write - writes the frame to the socket within the write timeout.
+func (that *connection) write(f frame) error {
+	f, err := that.compress(f)
+	if err != nil {
+		return err
+	}
+
+	if err = that.conn.SetWriteDeadline(time.Now().Add(that.config.WriteTimeout)); err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+
+	return writeFrame(that.bufRW.Writer, f)
+}
+
+// compress - compresses a whole data message when permessage-deflate was negotiated.
+// Control frames are never compressed, neither are messages shorter than the configured minimum.
+func (that *connection) compress(f frame) (frame, error) {
+	if that.deflate == nil || f.isControl() || f.opCode == opContinuation || !f.isFin {
+		return f, nil
+	}
+
+	payload, ok, err := that.deflate.compressor.compress(f.payload)
+	if err != nil || !ok {
+		return f, err
+	}
+
+	f.compressed = true
+	f.payload = payload
+	f.length = uint64(len(payload))
+
+	return f, nil
+}
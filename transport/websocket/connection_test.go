@@ -0,0 +1,193 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textFrame - a single text frame with the payload.
+func textFrame(payload string) frame {
+	return frame{isFin: true, opCode: opText, length: uint64(len(payload)), payload: []byte(payload)}
+}
+
+// fillQueue - sends numbered frames to the connection whose client reads nothing until one does not fit.
+// Returns how many frames were accepted.
+func fillQueue(t *testing.T, conn *connection) int {
+	t.Helper()
+
+	// one frame is held by the blocked writer, the rest wait in the queue
+	for i := range conn.config.SendQueueSize + 2 {
+		if err := conn.send(textFrame(fmt.Sprintf("frame-%d", i))); err != nil {
+			require.ErrorIs(t, err, ErrSendQueueFull)
+			return i
+		}
+
+		// let the writer take the first frame off the queue, so the count does not depend on scheduling
+		if i == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	require.FailNow(t, "send queue never filled up")
+
+	return 0
+}
+
+func TestConnection_SlowConsumer(t *testing.T) {
+	t.Run("Drop policy drops the frame and keeps the connection", func(t *testing.T) {
+		// Given: a connection that drops frames of a slow consumer and a client that reads nothing yet
+		conf := testConfig()
+		conf.SendQueueSize = 2
+		conf.SlowConsumerPolicy = SlowConsumerDrop
+		conn, client := pipeConnection(t, conf)
+
+		// When: more frames are sent than the queue holds
+		accepted := fillQueue(t, conn)
+
+		// Then: the frames that fit should arrive in order and the connection should stay open
+		assert.Equal(t, conf.SendQueueSize+1, accepted)
+		assert.False(t, conn.closed.Load())
+
+		for i := range accepted {
+			f := client.readFrame()
+			assert.Equal(t, opText, f.opCode)
+			assert.Equal(t, fmt.Sprintf("frame-%d", i), string(f.payload))
+		}
+
+		require.NoError(t, conn.send(textFrame("after")))
+		assert.Equal(t, "after", string(client.readFrame().payload))
+	})
+
+	t.Run("Disconnect policy closes the connection with 1008 ahead of the queued frames", func(t *testing.T) {
+		// Given: a connection that disconnects a slow consumer and a client that reads nothing yet
+		conf := testConfig()
+		conf.SendQueueSize = 2
+		conf.SlowConsumerPolicy = SlowConsumerDisconnect
+		conn, client := pipeConnection(t, conf)
+
+		// When: more frames are sent than the queue holds
+		fillQueue(t, conn)
+
+		// Then: nothing more should be accepted
+		require.ErrorIs(t, conn.send(textFrame("late")), ErrConnectionClosed)
+
+		// And: after the frame the writer already held the close frame should win over the queued ones
+		assert.Equal(t, "frame-0", string(client.readFrame().payload))
+
+		closing := client.readFrame()
+		require.Equal(t, opClose, closing.opCode)
+		assert.Equal(t, closePolicyViolation, binary.BigEndian.Uint16(closing.payload))
+
+		_, err := client.tryReadFrame()
+		require.Error(t, err)
+
+		conn.wait()
+	})
+}
+
+func TestConnection_Close(t *testing.T) {
+	t.Run("Writes the close frame before the frames still queued", func(t *testing.T) {
+		// Given: a connection with frames waiting behind a blocked writer
+		conn, client := pipeConnection(t, testConfig())
+		require.NoError(t, conn.send(textFrame("in flight")))
+		time.Sleep(20 * time.Millisecond)
+		require.NoError(t, conn.send(textFrame("queued")))
+
+		// When: the connection is closed
+		conn.close(closeNormalClosure, "bye")
+
+		// Then: the close frame should follow the frame in flight and the queued one should never be written
+		assert.Equal(t, "in flight", string(client.readFrame().payload))
+
+		closing := client.readFrame()
+		require.Equal(t, opClose, closing.opCode)
+		assert.Equal(t, closeNormalClosure, binary.BigEndian.Uint16(closing.payload))
+		assert.Equal(t, "bye", string(closing.payload[2:]))
+
+		_, err := client.tryReadFrame()
+		require.Error(t, err)
+	})
+
+	t.Run("Closes only once", func(t *testing.T) {
+		// Given: an open connection
+		conn, client := pipeConnection(t, testConfig())
+
+		// When: it is closed twice with different codes and then terminated
+		conn.close(closeNormalClosure, "")
+		conn.close(closeProtocolError, "")
+		conn.terminate()
+
+		// Then: only the first close frame should be written
+		assert.Equal(t, closeNormalClosure, client.readClose())
+
+		_, err := client.tryReadFrame()
+		require.Error(t, err)
+	})
+}
+
+func TestConnection_WriteTimeout(t *testing.T) {
+	// Given: a connection with a short write timeout and a client that never reads
+	conf := testConfig()
+	conf.WriteTimeout = 50 * time.Millisecond
+	conn, _ := pipeConnection(t, conf)
+
+	// When: a frame is sent
+	require.NoError(t, conn.send(textFrame("stuck")))
+
+	// Then: the writer should give up and close the socket
+	select {
+	case <-conn.done:
+	case <-time.After(testTimeout):
+		require.FailNow(t, "writer did not give up on the stuck write")
+	}
+
+	assert.ErrorIs(t, conn.send(textFrame("late")), ErrConnectionClosed)
+}
+
+func TestConnection_ConcurrentSends(t *testing.T) {
+	const (
+		senders          = 8
+		framesPerSender  = 25
+		payloadByteCount = 300 // longer than 125 bytes, so frames use the extended length
+	)
+
+	// Given: a connection with room for every frame
+	conf := testConfig()
+	conf.SendQueueSize = senders * framesPerSender
+	conn, client := pipeConnection(t, conf)
+
+	// When: several goroutines send at once
+	var wg sync.WaitGroup
+	for sender := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range framesPerSender {
+				payload := fmt.Sprintf("%d:%03d:", sender, i)
+				payload += string(make([]byte, payloadByteCount-len(payload)))
+				assert.NoError(t, conn.send(textFrame(payload)))
+			}
+		}()
+	}
+
+	// Then: every frame should arrive whole and the frames of every sender in the order they were sent
+	next := make(map[int]int, senders)
+	for range senders * framesPerSender {
+		f := client.readFrame()
+		require.Len(t, f.payload, payloadByteCount)
+
+		var sender, i int
+		_, err := fmt.Sscanf(string(f.payload), "%d:%03d:", &sender, &i)
+		require.NoError(t, err)
+		assert.Equal(t, next[sender], i, "sender %d", sender)
+		next[sender] = i + 1
+	}
+
+	wg.Wait()
+}
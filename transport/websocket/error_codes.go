@@ -0,0 +1,149 @@
+package websocket
+
+import (
+	"errors"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/auth"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/i18n"
+)
+
+// ErrorCode is a stable machine readable error code, clients may rely on it to react to errors.
+type ErrorCode string
+
+const (
+	CodeInternal ErrorCode = "INTERNAL_ERROR"
+
+	CodeUnknownAction ErrorCode = "UNKNOWN_ACTION"
+	CodeNotConnected  ErrorCode = "NOT_CONNECTED"
+	CodeSessionBound  ErrorCode = "SESSION_ALREADY_BOUND"
+	CodeInvalidToken  ErrorCode = "INVALID_TOKEN"
+	CodeTokenExpired  ErrorCode = "TOKEN_EXPIRED"
+	CodeGameRequired  ErrorCode = "GAME_REQUIRED"
+	CodeCellRequired  ErrorCode = "CELL_REQUIRED"
+	CodeInvalidAnswer ErrorCode = "INVALID_ANSWER"
+	CodeNoOpponent    ErrorCode = "NO_OPPONENT"
+	CodeRateLimited   ErrorCode = "RATE_LIMITED"
+
+	CodePlayerNotFound    ErrorCode = "PLAYER_NOT_FOUND"
+	CodeGameNotFound      ErrorCode = "GAME_NOT_FOUND"
+	CodeGameFull          ErrorCode = "GAME_FULL"
+	CodeGameAlreadyExists ErrorCode = "GAME_ALREADY_EXISTS"
+	CodeGameNotStarted    ErrorCode = "GAME_NOT_STARTED"
+	CodeGameFinished      ErrorCode = "GAME_FINISHED"
+	CodeNotYourTurn       ErrorCode = "NOT_YOUR_TURN"
+	CodeCellOccupied      ErrorCode = "CELL_OCCUPIED"
+	CodeWrongSubBoard     ErrorCode = "WRONG_SUB_BOARD"
+	CodeInvalidPiece      ErrorCode = "INVALID_PIECE"
+	CodeInvalidStrength   ErrorCode = "INVALID_STRENGTH"
+	CodeInvalidCell       ErrorCode = "INVALID_CELL"
+	CodeInvalidSettings   ErrorCode = "INVALID_SETTINGS"
+
+	CodeReplayRequired ErrorCode = "REPLAY_REQUIRED"
+	CodeNoReplay       ErrorCode = "NO_REPLAY"
+	CodeInvalidReplay  ErrorCode = "INVALID_REPLAY"
+
+	CodeSpectatorsFull ErrorCode = "SPECTATORS_FULL"
+	CodeSpectating     ErrorCode = "SPECTATOR_CANNOT_MOVE"
+	CodeAlreadyInGame  ErrorCode = "ALREADY_IN_GAME"
+)
+
+// errorCodes maps the domain sentinel errors to the codes sent to clients.
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{err: auth.ErrInvalidToken, code: CodeInvalidToken},
+	{err: auth.ErrTokenExpired, code: CodeTokenExpired},
+	{err: apperror.ErrPlayerNotFound, code: CodePlayerNotFound},
+	{err: apperror.ErrGameNotFound, code: CodeGameNotFound},
+	{err: apperror.ErrGameFull, code: CodeGameFull},
+	{err: apperror.ErrGameAlreadyExists, code: CodeGameAlreadyExists},
+	{err: apperror.ErrGameIsNotStarted, code: CodeGameNotStarted},
+	{err: apperror.ErrGameFinished, code: CodeGameFinished},
+	{err: apperror.ErrNotYourTurn, code: CodeNotYourTurn},
+	// the move came too late, the clock or another move has taken the turn
+	{err: apperror.ErrGameChanged, code: CodeNotYourTurn},
+	{err: apperror.ErrCellOccupied, code: CodeCellOccupied},
+	{err: apperror.ErrWrongSubBoard, code: CodeWrongSubBoard},
+	{err: entity.ErrInvalidPiece, code: CodeInvalidPiece},
+	{err: entity.ErrInvalidStrength, code: CodeInvalidStrength},
+	{err: entity.ErrInvalidCell, code: CodeInvalidCell},
+	{err: entity.ErrInvalidSettings, code: CodeInvalidSettings},
+}
+
+// errorMessageKeys are the translation keys of the human readable messages sent along with the codes.
+var errorMessageKeys = map[ErrorCode]string{
+	CodeInternal: i18n.KeyErrorInternal,
+
+	CodeUnknownAction: i18n.KeyErrorUnknownAction,
+	CodeNotConnected:  i18n.KeyErrorNotConnected,
+	CodeSessionBound:  i18n.KeyErrorSessionBound,
+	CodeInvalidToken:  i18n.KeyErrorInvalidToken,
+	CodeTokenExpired:  i18n.KeyErrorTokenExpired,
+	CodeGameRequired:  i18n.KeyErrorGameRequired,
+	CodeCellRequired:  i18n.KeyErrorCellRequired,
+	CodeInvalidAnswer: i18n.KeyErrorInvalidAnswer,
+	CodeNoOpponent:    i18n.KeyErrorNoOpponent,
+	CodeRateLimited:   i18n.KeyErrorRateLimited,
+
+	CodePlayerNotFound:    i18n.KeyErrorPlayerNotFound,
+	CodeGameNotFound:      i18n.KeyErrorGameNotFound,
+	CodeGameFull:          i18n.KeyErrorGameFull,
+	CodeGameAlreadyExists: i18n.KeyErrorGameAlreadyExists,
+	CodeGameNotStarted:    i18n.KeyErrorGameNotStarted,
+	CodeGameFinished:      i18n.KeyErrorGameFinished,
+	CodeNotYourTurn:       i18n.KeyErrorNotYourTurn,
+	CodeCellOccupied:      i18n.KeyErrorCellOccupied,
+	CodeWrongSubBoard:     i18n.KeyErrorWrongSubBoard,
+	CodeInvalidPiece:      i18n.KeyErrorInvalidPiece,
+	CodeInvalidStrength:   i18n.KeyErrorInvalidStrength,
+	CodeInvalidCell:       i18n.KeyErrorInvalidCell,
+	CodeInvalidSettings:   i18n.KeyErrorInvalidSettings,
+
+	CodeReplayRequired: i18n.KeyErrorReplayRequired,
+	CodeNoReplay:       i18n.KeyErrorNoReplay,
+	CodeInvalidReplay:  i18n.KeyErrorInvalidReplay,
+
+	CodeSpectatorsFull: i18n.KeyErrorSpectatorsFull,
+	CodeSpectating:     i18n.KeyErrorSpectating,
+	CodeAlreadyInGame:  i18n.KeyErrorAlreadyInGame,
+}
+
+// errorCodeOf - finds the code of the error, errors that are not part of the catalog are reported as internal.
+func errorCodeOf(err error) ErrorCode {
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return known.code
+		}
+	}
+
+	return CodeInternal
+}
+
+// messageKey - returns the translation key of the human readable message of the code.
+func (that ErrorCode) messageKey() string {
+	if key, ok := errorMessageKeys[that]; ok {
+		return key
+	}
+
+	return i18n.KeyErrorInternal
+}
+
+// errorMessageParams - the values of the placeholders in the message of the code.
+// The limits come from the same constants and config they are enforced with, so the messages never go stale.
+func (that *Server) errorMessageParams(code ErrorCode) i18n.Params {
+	switch code { //nolint: exhaustive // the messages of the other codes have no placeholders
+	case CodeInvalidStrength:
+		return i18n.Params{"min": entity.MinStrength, "max": entity.MaxStrength}
+	case CodeInvalidSettings:
+		return i18n.Params{"min_size": entity.MinBoardSize, "max_size": entity.MaxBoardSize}
+	case CodeInvalidReplay:
+		return i18n.Params{"min_speed": replayMinSpeed, "max_speed": replayMaxSpeed}
+	case CodeSpectatorsFull:
+		return i18n.Params{"max": that.config.MaxSpectators}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/i18n"
+)
+
+func TestErrorCodeOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCode
+	}{
+		{err: fmt.Errorf("failed to make turn: %w", apperror.ErrNotYourTurn), want: CodeNotYourTurn},
+		{err: fmt.Errorf("failed to update game g1: %w", apperror.ErrGameChanged), want: CodeNotYourTurn},
+		{err: fmt.Errorf("%w: 11 is out of 1..10", entity.ErrInvalidStrength), want: CodeInvalidStrength},
+		{err: errors.New("redis is down"), want: CodeInternal},
+	}
+
+	for _, tt := range tests {
+		// When: looking up the code of the error
+		got := errorCodeOf(tt.err)
+
+		// Then: wrapped domain errors should keep their code, the rest should be internal
+		assert.Equal(t, tt.want, got, "error %v", tt.err)
+	}
+}
+
+func TestServer_ErrorMessages(t *testing.T) {
+	t.Run("Fills in every placeholder in every locale", func(t *testing.T) {
+		// Given: a server with a spectator limit
+		conf := testConfig()
+		conf.MaxSpectators = 50
+		server := &Server{logger: testLogger(), config: conf}
+
+		for code, key := range errorMessageKeys {
+			for _, locale := range []string{i18n.LocaleEN, i18n.LocaleRU} {
+				// When: the message of the code is translated with its parameters
+				message := i18n.Translate(locale, key, server.errorMessageParams(code))
+
+				// Then: no placeholder should be left
+				assert.NotContains(t, message, "{", "code %s locale %s: %s", code, locale, message)
+			}
+		}
+	})
+
+	t.Run("Names the limits the server enforces", func(t *testing.T) {
+		// Given: a server with a spectator limit
+		conf := testConfig()
+		conf.MaxSpectators = 7
+		server := &Server{logger: testLogger(), config: conf}
+
+		translate := func(code ErrorCode) string {
+			return i18n.Translate(i18n.LocaleEN, code.messageKey(), server.errorMessageParams(code))
+		}
+
+		// Then: the messages should carry the limits of the constants and the config
+		assert.Equal(t, "Bot strength must be from 1 to 10", translate(CodeInvalidStrength))
+		assert.True(t, strings.HasPrefix(translate(CodeInvalidSettings), "Board must be from 3x3 to 19x19,"))
+		assert.Equal(t, "Unknown replay command, or the speed is out of 0.25..8", translate(CodeInvalidReplay))
+		assert.Equal(t, "The game already has 7 spectators, as many as it may have", translate(CodeSpectatorsFull))
+	})
+}
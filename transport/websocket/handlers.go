@@ -1,7 +1,6 @@
 package websocket
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,6 +9,7 @@ import (
 
 	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/i18n"
 )
 
 const (
@@ -21,7 +21,7 @@ const (
 	answerRematchNo  = "no"
 )
 
-func (that *Server) handleConnect(ctx context.Context, msg *Message, bufrw *bufio.ReadWriter) error {
+func (that *Server) handleConnect(ctx context.Context, msg *Message, session *Session) error {
 	log := that.logger.With("method", "handleConnect")
 
 	var payloadReq Payload
@@ -30,33 +30,49 @@ func (that *Server) handleConnect(ctx context.Context, msg *Message, bufrw *bufi
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
-	if payloadReq.Player == nil {
-		log.Error("Player is missing in payload")
-		return that.sendErrorResponse(bufrw, msg.Action, "Player is required")
+	playerID, err := that.authenticate(session, &payloadReq)
+	if err != nil {
+		log.Error("failed to authenticate player", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
-	player, err := that.gameUseCase.GetOrCreatePlayer(ctx, payloadReq.Player.ID)
+	player, err := that.gameUseCase.GetOrCreatePlayer(ctx, playerID)
 	if err != nil {
 		log.Error("failed to create or get", "player", err)
 
-		return that.sendErrorResponse(bufrw, msg.Action, "failed to create a new player")
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
-	that.connectionsMutex.Lock()
-	that.connections[player.ID] = bufrw
-	that.connectionsMutex.Unlock()
+	if !that.bindSession(session, player.ID) {
+		log.Error("session is already bound to another player", "playerID", session.PlayerID())
+		return that.sendErrorResponse(session, msg, CodeSessionBound)
+	}
 
 	that.playerReconnected(player.ID)
 
+	// the locale asked for in connect wins over the Accept-Language of the handshake
+	if payloadReq.Locale != "" {
+		session.setLocale(i18n.Negotiate(payloadReq.Locale, session.Locale()))
+	}
+
+	// the token is rotated on every connect, so an active player never runs into the expiry
+	token, err := that.tokens.Issue(player.ID)
+	if err != nil {
+		log.Error("failed to issue token", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
+	}
+
 	if player.GameID != "" {
-		return that.handleExistingGame(ctx, bufrw, msg, player)
+		return that.handleExistingGame(ctx, session, msg, player, token)
 	}
 
 	payloadResp := Payload{
 		Player: maskPlayerDetails(player),
+		Locale: session.Locale(),
+		Token:  token,
 	}
 
-	if err = that.sendMessage(bufrw, msg.Action, payloadResp); err != nil {
+	if err = that.reply(session, msg, payloadResp); err != nil {
 		return fmt.Errorf("failed to send response: %w", err)
 	}
 
@@ -66,24 +82,59 @@ func (that *Server) handleConnect(ctx context.Context, msg *Message, bufrw *bufi
 }
 
 // handleExistingGame processes a player already in a game.
-func (that *Server) handleExistingGame(ctx context.Context, bufrw *bufio.ReadWriter, msg *Message, player *entity.Player) error {
+func (that *Server) handleExistingGame(ctx context.Context, session *Session, msg *Message, player *entity.Player, token string) error {
 	log := that.logger.With("method", "handleExistingGame")
 
 	game, err := that.gameUseCase.GetGameByPlayerID(ctx, player.ID)
 	if err != nil {
 		log.Error("failed to get game", "gameID", player.GameID, "error", err)
-		return that.sendErrorResponse(bufrw, msg.Action, "failed to get the game")
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
 	payload := Payload{
 		Player: maskPlayerDetails(player),
 		Game:   maskGameDetails(game),
+		Locale: session.Locale(),
+		Token:  token,
+	}
+
+	return that.reply(session, msg, payload)
+}
+
+// authenticate - returns the ID of the player the connect request proves to be.
+// The identity comes from the signed token only, a player ID sent in the payload is never trusted.
+// A request without a token starts a new player, unless the session is already bound.
+func (that *Server) authenticate(session *Session, payloadReq *Payload) (string, error) {
+	if payloadReq.Token != "" {
+		playerID, err := that.tokens.Verify(payloadReq.Token)
+		if err != nil {
+			return "", fmt.Errorf("failed to verify token: %w", err)
+		}
+
+		return playerID, nil
+	}
+
+	if payloadReq.Player != nil && payloadReq.Player.ID != "" {
+		that.logger.Warn("ignoring player ID sent without a token", "method", "authenticate", "playerID", payloadReq.Player.ID)
 	}
 
-	return that.sendMessage(bufrw, msg.Action, payload)
+	return session.PlayerID(), nil
 }
 
-func (that *Server) handleNewGame(ctx context.Context, msg *Message, bufrw *bufio.ReadWriter) error {
+// handleAuthRefresh - issues a fresh token for the player the session is bound to.
+func (that *Server) handleAuthRefresh(_ context.Context, msg *Message, session *Session) error {
+	log := that.logger.With("method", "handleAuthRefresh")
+
+	token, err := that.tokens.Issue(session.PlayerID())
+	if err != nil {
+		log.Error("failed to issue token", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
+	}
+
+	return that.reply(session, msg, Payload{Token: token})
+}
+
+func (that *Server) handleNewGame(ctx context.Context, msg *Message, session *Session) error {
 	log := that.logger.With("method", "handleNewGame")
 
 	var payloadReq Payload
@@ -92,71 +143,75 @@ func (that *Server) handleNewGame(ctx context.Context, msg *Message, bufrw *bufi
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
-	if payloadReq.Player == nil {
-		log.Error("Player is missing in payload")
-		return that.sendErrorResponse(bufrw, msg.Action, "Player is required")
-	}
-
 	if payloadReq.Game == nil {
 		log.Error("Game is missing in payload")
-		return that.sendErrorResponse(bufrw, msg.Action, "Game is required")
+		return that.sendErrorResponse(session, msg, CodeGameRequired)
 	}
 
-	that.connectionsMutex.Lock()
-	that.connections[payloadReq.Player.ID] = bufrw
-	that.connectionsMutex.Unlock()
-
 	var game *entity.Game
 	var err error
 
 	if payloadReq.Game.IsPublic() {
-		game, err = that.gameUseCase.CreateOrJoinToPublicGame(ctx, payloadReq.Player.ID, payloadReq.Game.Type)
+		game, err = that.gameUseCase.CreateOrJoinToPublicGame(ctx, session.PlayerID(), payloadReq.Game.Type, payloadReq.Game.GameSettings)
 		if err != nil {
-			log.Error("failed to create or join to public game", "game", payloadReq.Game.Type)
-			return that.sendErrorResponse(bufrw, msg.Action, "failed to create or join to public game")
+			log.Error("failed to create or join to public game", "game", payloadReq.Game.Type, "error", err)
+			return that.sendErrorResponse(session, msg, errorCodeOf(err))
 		}
 	}
 
 	if !payloadReq.Game.IsPublic() {
-		game, err = that.gameUseCase.GetOrCreateGame(ctx, payloadReq.Player.ID, payloadReq.Game.Type, payloadReq.Game.Difficulty)
+		game, err = that.gameUseCase.GetOrCreateGame(
+			ctx, session.PlayerID(), payloadReq.Game.Type, payloadReq.Game.Difficulty, payloadReq.Game.Strength,
+			payloadReq.Game.GameSettings,
+		)
 		if err != nil {
 			log.Error("failed to create or get", "player", err)
-			return that.sendErrorResponse(bufrw, msg.Action, "failed to create a new game")
+			return that.sendErrorResponse(session, msg, errorCodeOf(err))
 		}
 	}
 
 	log = log.With("gameID", game.ID)
 
+	// the bot that got the first move makes it once the player has the game
+	botTurn := isBotTurn(game)
+
 	for _, player := range game.Players {
 		if player.IsBot() {
 			continue
 		}
 
-		that.connectionsMutex.RLock()
-		conn, ok := that.connections[player.ID]
-		that.connectionsMutex.RUnlock()
+		playerSession, ok := that.sessionByPlayerID(player.ID)
 
 		if !ok {
 			log.Warn("connection not found for player", "playerID", player.ID)
 			continue
 		}
 
+		// a player who is in a game of their own does not watch another one
+		that.leaveSpectating(ctx, playerSession)
+
 		payloadResp := Payload{
 			Player: maskPlayerDetails(player),
 			Game:   maskGameDetails(game),
 		}
 
-		if err = that.sendMessage(conn, msg.Action, payloadResp); err != nil {
+		if err = that.replyOrNotify(session, playerSession, msg, payloadResp); err != nil {
 			log.Error("failed to send game update", "error", err)
 		}
 	}
 
+	that.notifySpectators(msg.Action, game, "")
+
+	if botTurn {
+		that.playBotTurn(ctx, game.ID)
+	}
+
 	log.Info("Player is already in game")
 
 	return nil
 }
 
-func (that *Server) handleJoinGame(ctx context.Context, msg *Message, bufrw *bufio.ReadWriter) error {
+func (that *Server) handleJoinGame(ctx context.Context, msg *Message, session *Session) error {
 	log := that.logger.With("method", "handleJoinGame")
 
 	var payloadReq Payload
@@ -165,26 +220,17 @@ func (that *Server) handleJoinGame(ctx context.Context, msg *Message, bufrw *buf
 		return fmt.Errorf("failed to unmarshal playload: %w", err)
 	}
 
-	if payloadReq.Player == nil {
-		log.Error("Player is missing in payload")
-		return that.sendErrorResponse(bufrw, msg.Action, "Player is required")
-	}
-
 	if payloadReq.Game == nil {
 		log.Error("Game is missing in payload")
-		return that.sendErrorResponse(bufrw, msg.Action, "Game is required")
+		return that.sendErrorResponse(session, msg, CodeGameRequired)
 	}
 
-	that.connectionsMutex.Lock()
-	that.connections[payloadReq.Player.ID] = bufrw
-	that.connectionsMutex.Unlock()
-
-	log = log.With("playerID", payloadReq.Player.ID)
+	log = log.With("playerID", session.PlayerID())
 
-	game, err := that.gameUseCase.JoinGameByID(ctx, payloadReq.Game.ID, payloadReq.Player.ID)
+	game, err := that.gameUseCase.JoinGameByID(ctx, payloadReq.Game.ID, session.PlayerID())
 	if err != nil {
 		log.Error("failed to join game", "error", err)
-		return that.sendErrorResponse(bufrw, msg.Action, fmt.Sprintf("game %s: %v", payloadReq.Game.ID, err))
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
 	log = log.With("gameID", game.ID)
@@ -194,31 +240,33 @@ func (that *Server) handleJoinGame(ctx context.Context, msg *Message, bufrw *buf
 			continue
 		}
 
-		that.connectionsMutex.RLock()
-		conn, ok := that.connections[player.ID]
-		that.connectionsMutex.RUnlock()
+		playerSession, ok := that.sessionByPlayerID(player.ID)
 
 		if !ok {
 			log.Error("failed to find connection")
 			continue
 		}
 
+		that.leaveSpectating(ctx, playerSession)
+
 		payloadResp := Payload{
 			Player: maskPlayerDetails(player),
 			Game:   maskGameDetails(game),
 		}
 
-		if err = that.sendMessage(conn, msg.Action, payloadResp); err != nil {
+		if err = that.replyOrNotify(session, playerSession, msg, payloadResp); err != nil {
 			log.Error("failed to send game update", "error", err)
 		}
 	}
 
+	that.notifySpectators(msg.Action, game, "")
+
 	log.Info("Player joined game")
 
 	return nil
 }
 
-func (that *Server) handleGameTurn(ctx context.Context, msg *Message, bufrw *bufio.ReadWriter) error {
+func (that *Server) handleGameTurn(ctx context.Context, msg *Message, session *Session) error {
 	log := that.logger.With("method", "handleGameTurn")
 
 	var payloadReq Payload
@@ -227,50 +275,43 @@ func (that *Server) handleGameTurn(ctx context.Context, msg *Message, bufrw *buf
 		return fmt.Errorf("failed to unmarshal playload: %w", err)
 	}
 
-	if payloadReq.Player == nil {
-		log.Error("Player is missing in payload")
-		return that.sendErrorResponse(bufrw, msg.Action, "Player is required")
-	}
-
 	if payloadReq.Cell == nil {
-		log.Error("Game is missing in payload")
-		return that.sendErrorResponse(bufrw, msg.Action, "Game is required")
+		log.Error("Cell is missing in payload")
+		return that.sendErrorResponse(session, msg, CodeCellRequired)
 	}
 
-	that.connectionsMutex.Lock()
-	that.connections[payloadReq.Player.ID] = bufrw
-	that.connectionsMutex.Unlock()
+	if session.Spectating() != "" {
+		return that.sendErrorResponse(session, msg, CodeSpectating)
+	}
 
-	log = log.With("playerID", payloadReq.Player.ID)
+	log = log.With("playerID", session.PlayerID())
 
-	game, err := that.gameUseCase.MakeTurn(ctx, payloadReq.Player.ID, *payloadReq.Cell)
-	if errors.Is(err, apperror.ErrGameFinished) {
-		if err = that.handleGameFinished(msg.Action, game); err != nil {
-			return that.sendErrorResponse(bufrw, msg.Action, fmt.Sprintf("failed to finish game %s: %v", game.ID, err))
+	game, err := that.gameUseCase.MakeTurn(ctx, session.PlayerID(), *payloadReq.Cell, payloadReq.Piece)
+	// the turn finished the game, the final state goes to both players
+	if errors.Is(err, apperror.ErrGameFinished) && game != nil {
+		if err = that.handleGameFinished(session, msg, game); err != nil {
+			log.Error("failed to finish game", "gameID", game.ID, "error", err)
+			return that.sendErrorResponse(session, msg, CodeInternal)
 		}
 
 		return nil
 	}
 
-	if errors.Is(err, apperror.ErrGameIsNotStarted) {
-		return that.sendErrorResponse(bufrw, msg.Action, fmt.Sprintf("game %s: %v", game.ID, err))
-	}
-
-	if errors.Is(err, apperror.ErrCellOccupied) {
-		return that.sendErrorResponse(bufrw, msg.Action, fmt.Sprintf("game %s: %v", game.ID, err))
-	}
-
 	if err != nil {
 		log.Error("failed to make turn", "error", err)
-		return that.sendErrorResponse(bufrw, msg.Action, fmt.Sprintf("failed to turn in game %v", err))
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
 	log = log.With("gameID", game.ID)
 
+	botTurn := isBotTurn(game)
+
 	for _, player := range game.Players {
-		that.connectionsMutex.RLock()
-		conn, ok := that.connections[player.ID]
-		that.connectionsMutex.RUnlock()
+		if player.IsBot() {
+			continue
+		}
+
+		playerSession, ok := that.sessionByPlayerID(player.ID)
 
 		if !ok {
 			log.Error("failed to find connection")
@@ -282,17 +323,23 @@ func (that *Server) handleGameTurn(ctx context.Context, msg *Message, bufrw *buf
 			Game:   maskGameDetails(game),
 		}
 
-		if err = that.sendMessage(conn, msg.Action, payloadResp); err != nil {
+		if err = that.replyOrNotify(session, playerSession, msg, payloadResp); err != nil {
 			log.Error("failed to send game update", "error", err)
 		}
 	}
 
+	that.notifySpectators(msg.Action, game, "")
+
+	if botTurn {
+		that.playBotTurn(ctx, game.ID)
+	}
+
 	log.Info("Player made a turn")
 
 	return nil
 }
 
-func (that *Server) handleGameLeave(ctx context.Context, msg *Message, bufRW *bufio.ReadWriter) error {
+func (that *Server) handleGameLeave(ctx context.Context, msg *Message, session *Session) error {
 	log := that.logger.With("method", "handleGameLeave")
 
 	var payloadReq Payload
@@ -301,25 +348,21 @@ func (that *Server) handleGameLeave(ctx context.Context, msg *Message, bufRW *bu
 		return fmt.Errorf("failed to unmarshal playload: %w", err)
 	}
 
-	if payloadReq.Player == nil {
-		log.Error("Player is missing in payload")
-		return that.sendErrorResponse(bufRW, msg.Action, "Player is required")
+	// a spectator leaves the game it watches, the game itself goes on
+	if session.Spectating() != "" {
+		return that.stopSpectating(ctx, msg, session)
 	}
 
-	that.connectionsMutex.Lock()
-	that.connections[payloadReq.Player.ID] = bufRW
-	that.connectionsMutex.Unlock()
-
-	game, err := that.gameUseCase.GetGameByPlayerID(ctx, payloadReq.Player.ID)
+	game, err := that.gameUseCase.GetGameByPlayerID(ctx, session.PlayerID())
 	if err != nil {
 		log.Error("failed to find game", "error", err)
-		return that.sendErrorResponse(bufRW, msg.Action, "game doesn't exist")
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
 	err = that.gameUseCase.EndGame(ctx, game)
 	if err != nil {
 		log.Error("failed to end game", "error", err)
-		return that.sendErrorResponse(bufRW, msg.Action, "game doesn't exist")
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
 	for _, player := range game.Players {
@@ -327,9 +370,7 @@ func (that *Server) handleGameLeave(ctx context.Context, msg *Message, bufRW *bu
 			continue
 		}
 
-		that.connectionsMutex.Lock()
-		conn, ok := that.connections[player.ID]
-		that.connectionsMutex.Unlock()
+		playerSession, ok := that.sessionByPlayerID(player.ID)
 
 		if !ok {
 			log.Info("failed to find connection")
@@ -343,7 +384,7 @@ func (that *Server) handleGameLeave(ctx context.Context, msg *Message, bufRW *bu
 
 		payloadResp.Game.Status = gameStatusLeave
 
-		if err = that.sendMessage(conn, payloadActionGameLeave, payloadResp); err != nil {
+		if err = that.replyOrNotify(session, playerSession, msg, payloadResp); err != nil {
 			log.Error("failed to send game update", "error", err)
 		}
 
@@ -368,12 +409,14 @@ func (that *Server) handleGameLeave(ctx context.Context, msg *Message, bufRW *bu
 		log.Info("Rematch request reset after game leave", "key", key)
 	}
 
+	that.endSpectating(msg.Action, game, gameStatusLeave)
+
 	log.Info("Player leaving")
 
 	return nil
 }
 
-func (that *Server) handleGameFinished(action string, game *entity.Game) error {
+func (that *Server) handleGameFinished(session *Session, msg *Message, game *entity.Game) error {
 	log := that.logger.With("method", "handleGameFinished")
 
 	for _, player := range game.Players {
@@ -381,9 +424,7 @@ func (that *Server) handleGameFinished(action string, game *entity.Game) error {
 			continue
 		}
 
-		that.connectionsMutex.RLock()
-		conn, ok := that.connections[player.ID]
-		that.connectionsMutex.RUnlock()
+		playerSession, ok := that.sessionByPlayerID(player.ID)
 
 		if !ok {
 			log.Error("failed to find connection", "player", player.ID)
@@ -395,11 +436,13 @@ func (that *Server) handleGameFinished(action string, game *entity.Game) error {
 			Game:   maskGameDetails(game),
 		}
 
-		if err := that.sendMessage(conn, action, payloadResp); err != nil {
+		if err := that.replyOrNotify(session, playerSession, msg, payloadResp); err != nil {
 			return fmt.Errorf("failed to send game finished message %s: %w", player.ID, err)
 		}
 	}
 
+	that.endSpectating(msg.Action, game, "")
+
 	log.Info("Game finished", "gameID", game.ID)
 
 	for _, player := range game.Players {
@@ -431,33 +474,72 @@ func (that *Server) handleGameFinished(action string, game *entity.Game) error {
 	return nil
 }
 
-func (that *Server) handleDisconnect(bufRW *bufio.ReadWriter) {
+func (that *Server) handleDisconnect(ctx context.Context, session *Session) {
 	log := that.logger.With("method", "handleDisconnect")
 
-	that.connectionsMutex.Lock()
-	var disconnectedPlayerID string
-	for playerID, connection := range that.connections {
-		if connection == bufRW {
-			disconnectedPlayerID = playerID
-			break
-		}
+	if replay := session.swapReplay(nil); replay != nil {
+		replay.stop()
 	}
 
+	// a spectator is in no game, nobody waits for it to come back
+	spectator := that.leaveSpectating(ctx, session) != ""
+
+	disconnectedPlayerID := session.PlayerID()
 	if disconnectedPlayerID == "" {
-		log.Error("disconnected player not found in connections")
-		that.connectionsMutex.Unlock()
+		log.Info("session closed before connect", "remoteAddr", session.RemoteAddr)
 		return
 	}
 
-	delete(that.connections, disconnectedPlayerID)
+	that.sessionsMutex.Lock()
+	if that.sessions[disconnectedPlayerID] != session {
+		// the player has already opened a new session, it is not a disconnect
+		that.sessionsMutex.Unlock()
+		log.Info("replaced session closed", "playerID", disconnectedPlayerID)
+		return
+	}
+
+	delete(that.sessions, disconnectedPlayerID)
 	log.Info("player disconnected", "playerID", disconnectedPlayerID)
-	that.connectionsMutex.Unlock()
+	that.sessionsMutex.Unlock()
+
+	if spectator {
+		return
+	}
 
 	that.disconnectedMutex.Lock()
 	that.disconnectedPlayers[disconnectedPlayerID] = time.Now()
 	that.disconnectedMutex.Unlock()
 }
 
+// bindSession binds the session to the player and makes it the player's only session.
+// A previous session of the same player is closed.
+func (that *Server) bindSession(session *Session, playerID string) bool {
+	if !session.bind(playerID) {
+		return false
+	}
+
+	that.sessionsMutex.Lock()
+	previous, ok := that.sessions[playerID]
+	that.sessions[playerID] = session
+	that.sessionsMutex.Unlock()
+
+	if ok && previous != session {
+		previous.conn.close(closePolicyViolation, "session replaced by a new connection")
+	}
+
+	return true
+}
+
+// sessionByPlayerID returns the session bound to the player.
+func (that *Server) sessionByPlayerID(playerID string) (*Session, bool) {
+	that.sessionsMutex.RLock()
+	defer that.sessionsMutex.RUnlock()
+
+	session, ok := that.sessions[playerID]
+
+	return session, ok
+}
+
 func (that *Server) handleOpponentOut(ctx context.Context, playerID string) {
 	log := that.logger.With("method", "handleOpponentOut")
 
@@ -483,9 +565,7 @@ func (that *Server) handleOpponentOut(ctx context.Context, playerID string) {
 			continue
 		}
 
-		that.connectionsMutex.RLock()
-		opponentConn, ok := that.connections[player.ID]
-		that.connectionsMutex.RUnlock()
+		opponentSession, ok := that.sessionByPlayerID(player.ID)
 
 		if !ok {
 			log.Warn("opponent connection not found", "playerID", player.ID)
@@ -497,15 +577,17 @@ func (that *Server) handleOpponentOut(ctx context.Context, playerID string) {
 		}
 		payloadResp.Game.Status = gameStatusOpponentOut
 
-		if err = that.sendMessage(opponentConn, payloadActionGameLeave, payloadResp); err != nil {
+		if err = that.sendEvent(opponentSession, payloadActionGameLeave, payloadResp); err != nil {
 			log.Error("failed to send game:leave message", "playerID", player.ID, "error", err)
 		}
 	}
 
+	that.endSpectating(payloadActionGameLeave, game, gameStatusOpponentOut)
+
 	log.Info("handled opponent out", "gameID", game.ID)
 }
 
-func (that *Server) handleRematch(ctx context.Context, msg *Message, bufRW *bufio.ReadWriter) error {
+func (that *Server) handleRematch(ctx context.Context, msg *Message, session *Session) error {
 	log := that.logger.With("method", "handleRematch")
 
 	var payloadReq Payload
@@ -513,48 +595,39 @@ func (that *Server) handleRematch(ctx context.Context, msg *Message, bufRW *bufi
 		log.Error("failed to unmarshal payload", "error", err)
 	}
 
-	if payloadReq.Player == nil {
-		log.Error("Player is missing in payload")
-		return that.sendErrorResponse(bufRW, msg.Action, "Player is required")
-	}
-
 	if payloadReq.Answer != answerRematchYes && payloadReq.Answer != answerRematchNo {
 		log.Error("invalid answer", "answer", payloadReq.Answer)
-		return that.sendErrorResponse(bufRW, msg.Action, "Answer must be 'yes' or 'no'")
+		return that.sendErrorResponse(session, msg, CodeInvalidAnswer)
 	}
 
-	player, err := that.gameUseCase.GetOrCreatePlayer(ctx, payloadReq.Player.ID)
+	player, err := that.gameUseCase.GetOrCreatePlayer(ctx, session.PlayerID())
 	if err != nil {
 		log.Error("failed to get player", "error", err)
-		return that.sendErrorResponse(bufRW, msg.Action, "Player not found")
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
-	that.connectionsMutex.Lock()
-	that.connections[player.ID] = bufRW
-	that.connectionsMutex.Unlock()
-
 	if player.LastOpponentID == "" {
 		log.Error("player has no last opponent", "player", player.ID)
-		return that.sendErrorResponse(bufRW, msg.Action, "No last opponent found")
+		return that.sendErrorResponse(session, msg, CodeNoOpponent)
 	}
 
 	opponent, err := that.gameUseCase.GetOrCreatePlayer(ctx, player.LastOpponentID)
 	if err != nil {
 		log.Error("failed to get player", "error", err)
-		return that.sendErrorResponse(bufRW, msg.Action, "failed to retrieve opponent player")
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
 	switch payloadReq.Answer {
 	case answerRematchYes:
-		return that.processRematchYes(ctx, msg, bufRW, player, opponent)
+		return that.processRematchYes(ctx, msg, session, player, opponent)
 	case answerRematchNo:
-		return that.processRematchNo(msg, bufRW, player, opponent)
+		return that.processRematchNo(msg, session, player, opponent)
 	}
 
-	return that.sendErrorResponse(bufRW, msg.Action, "Invalid answer")
+	return that.sendErrorResponse(session, msg, CodeInvalidAnswer)
 }
 
-func (that *Server) processRematchYes(ctx context.Context, msg *Message, bufRW *bufio.ReadWriter, player, opponent *entity.Player) error { //nolint: cyclop, lll // it's ok //ToDO: Need refactoring
+func (that *Server) processRematchYes(ctx context.Context, msg *Message, session *Session, player, opponent *entity.Player) error { //nolint: cyclop, lll // it's ok //ToDO: Need refactoring
 	log := that.logger.With("method", "processRematchYes")
 
 	key := makeRematchKey(player.ID, opponent.ID)
@@ -582,20 +655,20 @@ func (that *Server) processRematchYes(ctx context.Context, msg *Message, bufRW *
 		that.rematchRequests[key].Responses[player.ID] = true
 
 		ackPayload := Payload{
-			Message: "Rematch request created, waiting for opponent to confirm",
+			Message: session.translate(i18n.KeyRematchRequested, nil),
 		}
 
-		err := that.sendMessage(bufRW, msg.Action, ackPayload)
+		err := that.reply(session, msg, ackPayload)
 		if err != nil {
 			log.Error("failed to send rematch request", "error", err)
-			return that.sendErrorResponse(bufRW, msg.Action, "Failed to confirm opponent")
+			return that.sendErrorResponse(session, msg, CodeInternal)
 		}
 		log.Info("rematch request stored, waiting for second player", "key", key)
 
 		err = that.notifyOpponentRematchWanted(msg.Action, player, opponent)
 		if err != nil {
 			log.Error("failed to notify opponent rematch wanted", "error", err)
-			return that.sendErrorResponse(bufRW, msg.Action, "Failed to confirm opponent")
+			return that.sendErrorResponse(session, msg, CodeInternal)
 		}
 
 		return nil // exit - wait for the second “yes”.
@@ -603,24 +676,24 @@ func (that *Server) processRematchYes(ctx context.Context, msg *Message, bufRW *
 
 	if existingReq.Responses[player.ID] {
 		payloadReq := Payload{
-			Message: "player already responded to rematch request",
+			Message: session.translate(i18n.KeyRematchAlreadyResponded, nil),
 		}
 
 		log.Warn("player already responded to rematch request", "playerID", player.ID)
 
-		return that.sendMessage(bufRW, msg.Action, payloadReq)
+		return that.reply(session, msg, payloadReq)
 	}
 
 	existingReq.Responses[player.ID] = true
 
 	if len(existingReq.Responses) < 2 {
 		ackPayload := Payload{
-			Message: "Rematch confirmed, waiting for opponent to confirm",
+			Message: session.translate(i18n.KeyRematchConfirmed, nil),
 		}
-		err := that.sendMessage(bufRW, msg.Action, ackPayload)
+		err := that.reply(session, msg, ackPayload)
 		if err != nil {
 			log.Error("failed to send rematch confirmation", "error", err)
-			return that.sendErrorResponse(bufRW, msg.Action, "Failed to confirm opponent")
+			return that.sendErrorResponse(session, msg, CodeInternal)
 		}
 		log.Info("rematch confirmation sent, waiting for opponent", "key", key)
 		return nil
@@ -629,12 +702,12 @@ func (that *Server) processRematchYes(ctx context.Context, msg *Message, bufRW *
 	if player.GameID != "" || opponent.GameID != "" {
 		log.Info("One of the players is already in a game, cannot start rematch", "playerID", player.ID, "opponentID", opponent.ID)
 		notifyPayload := Payload{
-			Message: "Cannot start rematch: Opponent is currently in another game.",
+			Message: session.translate(i18n.KeyRematchOpponentBusy, nil),
 		}
-		err := that.sendMessage(bufRW, msg.Action, notifyPayload)
+		err := that.reply(session, msg, notifyPayload)
 		if err != nil {
 			log.Error("failed to send opponent busy message", "error", err)
-			return that.sendErrorResponse(bufRW, msg.Action, "Failed to notify player")
+			return that.sendErrorResponse(session, msg, CodeInternal)
 		}
 		return nil
 	}
@@ -647,73 +720,89 @@ func (that *Server) processRematchYes(ctx context.Context, msg *Message, bufRW *
 	newGame, err := that.createRematchGame(ctx, player, opponent)
 	if err != nil {
 		log.Error("failed to create rematch game", "error", err)
-		return that.sendErrorResponse(bufRW, msg.Action, "Failed to confirm opponent")
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
 	}
 
+	botTurn := isBotTurn(newGame)
+
 	for _, player = range []*entity.Player{player, opponent} {
-		that.connectionsMutex.RLock()
-		conn, hasConn := that.connections[player.ID]
-		that.connectionsMutex.RUnlock()
+		playerSession, hasConn := that.sessionByPlayerID(player.ID)
 		if !hasConn {
 			log.Warn("connection not found", "player", player.ID)
 			continue
 		}
 
+		// both players were out of a game before the rematch, either may have been watching one
+		that.leaveSpectating(ctx, playerSession)
+
 		resp := Payload{
 			Player:  maskPlayerDetails(player),
 			Game:    maskGameDetails(newGame),
-			Message: "Rematch confirmed. New game has started!",
+			Message: playerSession.translate(i18n.KeyRematchStarted, nil),
 		}
 
-		err = that.sendMessage(conn, msg.Action, resp)
+		err = that.replyOrNotify(session, playerSession, msg, resp)
 		if err != nil {
 			log.Error("failed to send rematch request", "error", err)
-			return that.sendErrorResponse(bufRW, msg.Action, "Failed to confirm opponent")
+			return that.sendErrorResponse(session, msg, CodeInternal)
 		}
 		log.Info("rematch request stored, waiting for second player", "key", key)
 	}
 
+	if botTurn {
+		that.playBotTurn(ctx, newGame.ID)
+	}
+
 	return nil
 }
 
 func (that *Server) notifyOpponentRematchWanted(action string, player, opponent *entity.Player) error {
 	log := that.logger.With("method", "notifyOpponentRematchWanted")
 
-	that.connectionsMutex.RLock()
-	conn, exists := that.connections[opponent.ID]
-	that.connectionsMutex.RUnlock()
+	opponentSession, exists := that.sessionByPlayerID(opponent.ID)
 
 	if !exists {
 		log.Error("Opponent is offline or no connection", "opponentID", opponent.ID)
+		return nil
 	}
 
 	if player.GameID != "" {
 		payloadResp := Payload{
-			Message: "Cannot send rematch request: Opponent is currently in another game.",
+			Message: opponentSession.translate(i18n.KeyRematchRequestBlocked, nil),
 		}
 
 		log.Info("Opponent is already in a game, cannot send rematch request", "opponentID", opponent.ID)
 
-		return that.sendMessage(conn, action, payloadResp)
+		return that.sendEvent(opponentSession, action, payloadResp)
 	}
 
 	payloadResp := Payload{
-		Message: "Your opponent wants a rematch.",
+		Message: opponentSession.translate(i18n.KeyRematchOpponentWants, nil),
 	}
 
-	return that.sendMessage(conn, action, payloadResp)
+	return that.sendEvent(opponentSession, action, payloadResp)
 }
 
+// createRematchGame - starts the rematch on the terms of the game the players finished.
+// Players who finished their game before the terms were kept get a classic game against an easy bot.
 func (that *Server) createRematchGame(ctx context.Context, player1, player2 *entity.Player) (*entity.Game, error) {
+	var terms entity.GameTerms
+	if player1.LastGame != nil {
+		terms = *player1.LastGame
+	}
+	if terms.Difficulty == "" {
+		terms.Difficulty = entity.EasyDifficulty
+	}
+
 	if player2.IsBot() {
-		game, err := that.gameUseCase.GetOrCreateGame(ctx, player1.ID, entity.WithBotType, entity.EasyDifficulty)
+		game, err := that.gameUseCase.GetOrCreateGame(ctx, player1.ID, entity.WithBotType, terms.Difficulty, terms.Strength, terms.GameSettings)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create rematch game with bot: %w", err)
 		}
 		return game, nil
 	}
 
-	game, err := that.gameUseCase.CreatePrivateGameWithTwoPlayers(ctx, player1, player2)
+	game, err := that.gameUseCase.CreatePrivateGameWithTwoPlayers(ctx, player1, player2, terms.GameSettings)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create rematch game with two players: %w", err)
 	}
@@ -721,7 +810,7 @@ func (that *Server) createRematchGame(ctx context.Context, player1, player2 *ent
 	return game, nil
 }
 
-func (that *Server) processRematchNo(msg *Message, bufRW *bufio.ReadWriter, player, opponent *entity.Player) error {
+func (that *Server) processRematchNo(msg *Message, session *Session, player, opponent *entity.Player) error {
 	log := that.logger.With("method", "processRematchNo")
 
 	key := makeRematchKey(player.ID, opponent.ID)
@@ -735,20 +824,18 @@ func (that *Server) processRematchNo(msg *Message, bufRW *bufio.ReadWriter, play
 	}
 
 	for _, pl := range []*entity.Player{player, opponent} {
-		that.connectionsMutex.RLock()
-		conn, hasConn := that.connections[pl.ID]
-		that.connectionsMutex.RUnlock()
+		playerSession, hasConn := that.sessionByPlayerID(pl.ID)
 		if !hasConn {
 			continue
 		}
 
 		ackPayload := Payload{
-			Message: "Rematch request was declined",
+			Message: playerSession.translate(i18n.KeyRematchDeclined, nil),
 		}
-		err := that.sendMessage(conn, msg.Action, ackPayload)
+		err := that.replyOrNotify(session, playerSession, msg, ackPayload)
 		if err != nil {
 			log.Error("failed to send rematch request", "error", err)
-			return that.sendErrorResponse(bufRW, msg.Action, "Failed to confirm opponent")
+			return that.sendErrorResponse(session, msg, CodeInternal)
 		}
 	}
 
@@ -765,6 +852,35 @@ func makeRematchKey(p1, p2 string) string {
 	return arr[0] + "|" + arr[1]
 }
 
+// handleGameHistory - replies with a page of the finished games of the player, the latest first.
+// The page is asked for by offset and limit, a request without them gets the latest games.
+func (that *Server) handleGameHistory(ctx context.Context, msg *Message, session *Session) error {
+	log := that.logger.With("method", "handleGameHistory")
+
+	var payloadReq Payload
+
+	if err := json.Unmarshal(msg.Payload, &payloadReq); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	page := HistoryPage{}
+	if payloadReq.History != nil {
+		page.Offset = payloadReq.History.Offset
+		page.Limit = payloadReq.History.Limit
+	}
+
+	games, total, err := that.gameUseCase.GetHistory(ctx, session.PlayerID(), page.Offset, page.Limit)
+	if err != nil {
+		log.Error("failed to get history", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
+	}
+
+	page.Games = games
+	page.Total = total
+
+	return that.reply(session, msg, Payload{History: &page})
+}
+
 func sortPair(a, b string) [2]string {
 	if a < b {
 		return [2]string{a, b}
@@ -778,21 +894,30 @@ func (that *Server) playerReconnected(playerID string) {
 	delete(that.disconnectedPlayers, playerID)
 }
 
+// maskPlayerDetails - returns the part of the player that is safe to send, the private ID never leaves the server.
 func maskPlayerDetails(player *entity.Player) *entity.Player {
-	player.LastOpponentID = ""
-	return player
+	return &entity.Player{
+		PublicID: player.PublicID,
+		Mark:     player.Mark,
+		GameID:   player.GameID,
+	}
 }
 
 // maskGameDetails hides sensitive details from the game payload.
 func maskGameDetails(game *entity.Game) *entity.Game {
 	game.Players = nil
 	game.Difficulty = ""
+	game.Strength = 0
 	return game
 }
 
-func (that *Server) sendErrorResponse(bufrw *bufio.ReadWriter, action, errorMsg string) error {
-	payload := Payload{Error: errorMsg}
-	if err := that.sendMessage(bufrw, action, payload); err != nil {
+// sendErrorResponse - answers the request with the error code and its message, the request ID is echoed back.
+// Only catalog messages reach the client, the internal error chain stays in the logs.
+func (that *Server) sendErrorResponse(session *Session, request *Message, code ErrorCode) error {
+	payload := Payload{Error: session.translate(code.messageKey(), that.errorMessageParams(code)), ErrorCode: code}
+	envelope := Message{ID: request.ID, Type: messageTypeError, Action: request.Action}
+
+	if err := that.sendMessage(session, envelope, payload); err != nil {
 		return fmt.Errorf("failed to send error response: %w", err)
 	}
 
@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+func TestServer_CreateRematchGame(t *testing.T) {
+	timed := entity.GameSettings{Rows: 5, Cols: 5, WinLength: 4, TimeControl: entity.TimeControl{BankMs: 60000}}
+
+	t.Run("Plays the bot on the terms of the last game", func(t *testing.T) {
+		// Given: a player who lost a misere game to a hard bot of strength 7
+		var gotType, gotDifficulty string
+		var gotStrength int
+		var gotSettings entity.GameSettings
+
+		uc := &stubGameUseCase{
+			getOrCreateGame: func(
+				_ context.Context, _, gameType, difficulty string, strength int, settings entity.GameSettings,
+			) (*entity.Game, error) {
+				gotType, gotDifficulty, gotStrength, gotSettings = gameType, difficulty, strength, settings
+				return &entity.Game{ID: "rematch"}, nil
+			},
+		}
+		server, _ := newTestServer(t, testConfig(), uc)
+
+		player := &entity.Player{ID: "p1", LastGame: &entity.GameTerms{
+			GameSettings: entity.GameSettings{Variant: entity.MisereVariant},
+			Difficulty:   entity.HardDifficulty,
+			Strength:     7,
+		}}
+
+		// When: the rematch is created
+		game, err := server.createRematchGame(context.Background(), player, entity.NewBotPlayer("old", entity.PlayerO))
+
+		// Then: the bot game should be created on the same terms
+		require.NoError(t, err)
+		assert.Equal(t, "rematch", game.ID)
+		assert.Equal(t, entity.WithBotType, gotType)
+		assert.Equal(t, entity.HardDifficulty, gotDifficulty)
+		assert.Equal(t, 7, gotStrength)
+		assert.Equal(t, entity.GameSettings{Variant: entity.MisereVariant}, gotSettings)
+	})
+
+	t.Run("Plays an easy bot without the terms of the last game", func(t *testing.T) {
+		// Given: a player who finished the game before the terms were kept
+		var gotDifficulty string
+		var gotSettings entity.GameSettings
+
+		uc := &stubGameUseCase{
+			getOrCreateGame: func(
+				_ context.Context, _, _, difficulty string, _ int, settings entity.GameSettings,
+			) (*entity.Game, error) {
+				gotDifficulty, gotSettings = difficulty, settings
+				return &entity.Game{ID: "rematch"}, nil
+			},
+		}
+		server, _ := newTestServer(t, testConfig(), uc)
+
+		// When: the rematch is created
+		_, err := server.createRematchGame(context.Background(), &entity.Player{ID: "p1"}, entity.NewBotPlayer("old", entity.PlayerO))
+
+		// Then: a classic game against an easy bot should be created
+		require.NoError(t, err)
+		assert.Equal(t, entity.EasyDifficulty, gotDifficulty)
+		assert.Equal(t, entity.GameSettings{}, gotSettings)
+	})
+
+	t.Run("Plays the opponent on the settings of the last game", func(t *testing.T) {
+		// Given: two players who finished a timed 5x5 game
+		var gotSettings entity.GameSettings
+
+		uc := &stubGameUseCase{
+			createPrivateGameWithTwoPlayers: func(
+				_ context.Context, _, _ *entity.Player, settings entity.GameSettings,
+			) (*entity.Game, error) {
+				gotSettings = settings
+				return &entity.Game{ID: "rematch"}, nil
+			},
+		}
+		server, _ := newTestServer(t, testConfig(), uc)
+
+		player1 := &entity.Player{ID: "p1", LastGame: &entity.GameTerms{GameSettings: timed}}
+		player2 := &entity.Player{ID: "p2", LastGame: &entity.GameTerms{GameSettings: timed}}
+
+		// When: the rematch is created
+		_, err := server.createRematchGame(context.Background(), player1, player2)
+
+		// Then: the private game should be created on the same settings
+		require.NoError(t, err)
+		assert.Equal(t, timed, gotSettings)
+	})
+}
@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	supportedWebSocketVersion = "13"
+	// websocketKeyLength is the length of the decoded Sec-WebSocket-Key nonce.
+	websocketKeyLength = 16
+	// anyOrigin allows every origin when it is present in the allow-list.
+	anyOrigin = "*"
+)
+
+var (
+	ErrMethodNotAllowed      = errors.New("websocket handshake must use GET")
+	ErrNotWebSocketUpgrade   = errors.New("not a websocket upgrade")
+	ErrMissingConnection     = errors.New("connection header must contain upgrade")
+	ErrUnsupportedVersion    = errors.New("unsupported websocket version")
+	ErrInvalidWebSocketKey   = errors.New("invalid Sec-WebSocket-Key")
+	ErrOriginNotAllowed      = errors.New("origin is not allowed")
+	ErrMalformedOriginHeader = errors.New("malformed origin header")
+)
+
+// handshakeError is a failed handshake together with the HTTP status it is answered with.
+type handshakeError struct {
+	status int
+	err    error
+}
+
+func (that *handshakeError) Error() string {
+	return that.err.Error()
+}
+
+func (that *handshakeError) Unwrap() error {
+	return that.err
+}
+
+// validateHandshake - checks the opening handshake request as required by RFC 6455, section 4.2.1.
+func (that *Server) validateHandshake(r *http.Request) *handshakeError {
+	if r.Method != http.MethodGet {
+		return &handshakeError{status: http.StatusMethodNotAllowed, err: ErrMethodNotAllowed}
+	}
+
+	if !headerContainsToken(r.Header, headerUpgrade, headerWebSocket) {
+		return &handshakeError{status: http.StatusBadRequest, err: ErrNotWebSocketUpgrade}
+	}
+
+	if !headerContainsToken(r.Header, headerConnection, "upgrade") {
+		return &handshakeError{status: http.StatusBadRequest, err: ErrMissingConnection}
+	}
+
+	if version := r.Header.Get(headerSecWebSocketVersion); version != supportedWebSocketVersion {
+		return &handshakeError{status: http.StatusUpgradeRequired, err: fmt.Errorf("%w: %q", ErrUnsupportedVersion, version)}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(r.Header.Get(headerSecWebSocketKey))
+	if err != nil || len(key) != websocketKeyLength {
+		return &handshakeError{status: http.StatusBadRequest, err: ErrInvalidWebSocketKey}
+	}
+
+	if err = that.checkOrigin(r); err != nil {
+		return &handshakeError{status: http.StatusForbidden, err: err}
+	}
+
+	return nil
+}
+
+// checkOrigin - checks the Origin header against the allow-list.
+// Requests without Origin come from non-browser clients and are accepted.
+// With an empty allow-list only same-origin browser requests are accepted.
+func (that *Server) checkOrigin(r *http.Request) error {
+	origin := r.Header.Get(headerOrigin)
+	if origin == "" {
+		return nil
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil || originURL.Host == "" {
+		return fmt.Errorf("%w: %q", ErrMalformedOriginHeader, origin)
+	}
+
+	if len(that.config.AllowedOrigins) == 0 {
+		if strings.EqualFold(originURL.Host, r.Host) {
+			return nil
+		}
+
+		return fmt.Errorf("%w: %s", ErrOriginNotAllowed, origin)
+	}
+
+	for _, allowed := range that.config.AllowedOrigins {
+		if allowed == anyOrigin || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrOriginNotAllowed, origin)
+}
+
+// headerContainsToken - reports whether the comma separated header contains the token, ignoring case.
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,167 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+// handshakeRequest - a valid opening handshake request to example.com.
+func handshakeRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+	r.Header.Set(headerUpgrade, headerWebSocket)
+	r.Header.Set(headerConnection, headerUpgrade)
+	r.Header.Set(headerSecWebSocketVersion, supportedWebSocketVersion)
+	r.Header.Set(headerSecWebSocketKey, "dGhlIHNhbXBsZSBub25jZQ==")
+
+	return r
+}
+
+func TestServer_RejectsHandshake(t *testing.T) {
+	tests := []struct {
+		name       string
+		origins    []string
+		modify     func(r *http.Request)
+		wantStatus int
+		wantHeader map[string]string
+	}{
+		{
+			name:       "Method other than GET",
+			modify:     func(r *http.Request) { r.Method = http.MethodPost },
+			wantStatus: http.StatusMethodNotAllowed,
+			wantHeader: map[string]string{"Allow": http.MethodGet},
+		},
+		{
+			name:       "No upgrade to websocket",
+			modify:     func(r *http.Request) { r.Header.Set(headerUpgrade, "h2c") },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Connection header without upgrade",
+			modify:     func(r *http.Request) { r.Header.Set(headerConnection, "keep-alive") },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unsupported websocket version",
+			modify:     func(r *http.Request) { r.Header.Set(headerSecWebSocketVersion, "8") },
+			wantStatus: http.StatusUpgradeRequired,
+			wantHeader: map[string]string{headerSecWebSocketVersion: supportedWebSocketVersion},
+		},
+		{
+			name:       "Missing websocket version",
+			modify:     func(r *http.Request) { r.Header.Del(headerSecWebSocketVersion) },
+			wantStatus: http.StatusUpgradeRequired,
+			wantHeader: map[string]string{headerSecWebSocketVersion: supportedWebSocketVersion},
+		},
+		{
+			name:       "Key that is not base64",
+			modify:     func(r *http.Request) { r.Header.Set(headerSecWebSocketKey, "not base64!") },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Key of the wrong length",
+			modify:     func(r *http.Request) { r.Header.Set(headerSecWebSocketKey, "c2hvcnQ=") },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Origin that is not allowed",
+			origins:    []string{"http://localhost:3000"},
+			modify:     func(r *http.Request) { r.Header.Set(headerOrigin, "http://evil.example") },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Cross origin without an allow-list",
+			modify:     func(r *http.Request) { r.Header.Set(headerOrigin, "http://evil.example") },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Malformed origin",
+			modify:     func(r *http.Request) { r.Header.Set(headerOrigin, "null") },
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: a server and a handshake request broken in one way
+			conf := testConfig()
+			conf.AllowedOrigins = tt.origins
+			server := &Server{logger: testLogger(), config: conf}
+
+			r := handshakeRequest()
+			tt.modify(r)
+			w := httptest.NewRecorder()
+
+			// When: the server handles the handshake
+			server.ServeHTTP(w, r)
+
+			// Then: it should be refused with the status and the headers telling the client what is expected
+			assert.Equal(t, tt.wantStatus, w.Code)
+			for name, value := range tt.wantHeader {
+				assert.Equal(t, value, w.Header().Get(name))
+			}
+		})
+	}
+}
+
+func TestServer_CheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		wantErr error
+	}{
+		{name: "No origin from a non-browser client", origin: ""},
+		{name: "Same origin by default", origin: "http://example.com"},
+		{name: "Same origin ignores case", origin: "http://EXAMPLE.com"},
+		{name: "Other origin by default", origin: "http://example.org", wantErr: ErrOriginNotAllowed},
+		{name: "Other port by default", origin: "http://example.com:8080", wantErr: ErrOriginNotAllowed},
+		{name: "Allowed origin", origins: []string{"http://localhost:3000"}, origin: "http://localhost:3000"},
+		{name: "Allowed origin with a trailing slash", origins: []string{"http://localhost:3000/"}, origin: "http://localhost:3000"},
+		{name: "Same origin outside the allow-list", origins: []string{"http://localhost:3000"}, origin: "http://example.com", wantErr: ErrOriginNotAllowed},
+		{name: "Any origin", origins: []string{anyOrigin}, origin: "http://anything.example"},
+		{name: "Origin without a host", origin: "file://", wantErr: ErrMalformedOriginHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: a server with the allow-list and a request from the origin
+			server := &Server{logger: testLogger(), config: config.Websocket{AllowedOrigins: tt.origins}}
+
+			r := handshakeRequest()
+			if tt.origin != "" {
+				r.Header.Set(headerOrigin, tt.origin)
+			}
+
+			// When: checking the origin
+			err := server.checkOrigin(r)
+
+			// Then: only the allowed origins should pass
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestServer_AcceptsHandshake(t *testing.T) {
+	// Given: a running server
+	_, httpServer := newTestServer(t, testConfig(), nil)
+
+	// When: a client connects with the sample nonce of RFC 6455 and a Connection header listing several tokens
+	_, response := dialTestServer(t, httpServer, http.Header{headerConnection: {"keep-alive, Upgrade"}})
+
+	// Then: the connection should be upgraded with the accept key RFC 6455 gives for the nonce
+	assert.Equal(t, http.StatusSwitchingProtocols, response.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", response.Header.Get(headerSecWebSocketAccept))
+	assert.True(t, headerContainsToken(response.Header, headerUpgrade, headerWebSocket))
+	assert.True(t, headerContainsToken(response.Header, headerConnection, "upgrade"))
+}
@@ -0,0 +1,383 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+// testTimeout - how long a test waits for a frame or for the server to react before it fails.
+const testTimeout = 2 * time.Second
+
+// testMaskingKey - the key the test client masks its frames with.
+var testMaskingKey = []byte{0x1f, 0x2e, 0x3d, 0x4c}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// testConfig - the websocket settings with limits small enough for the tests to reach them.
+func testConfig() config.Websocket {
+	return config.Websocket{
+		MaxFrameSize:       64,
+		MaxMessageSize:     128,
+		SendQueueSize:      8,
+		WriteTimeout:       testTimeout,
+		PingInterval:       testTimeout,
+		PongWait:           testTimeout,
+		SlowConsumerPolicy: SlowConsumerDisconnect,
+	}
+}
+
+// testClient - the client end of a connection, it writes masked frames and reads the frames of the server.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newTestClient(t *testing.T, conn net.Conn, r *bufio.Reader) *testClient {
+	t.Helper()
+
+	t.Cleanup(func() { conn.Close() })
+
+	return &testClient{t: t, conn: conn, r: r}
+}
+
+// pipeConnection - a connection over net.Pipe and the client on the other end of it.
+// The pipe has no buffer, a frame the client does not read keeps the writer goroutine blocked.
+func pipeConnection(t *testing.T, conf config.Websocket) (*connection, *testClient) {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+
+	conn := newConnection(testLogger(), conf, serverConn, bufio.NewReadWriter(bufio.NewReader(serverConn), bufio.NewWriter(serverConn)), nil)
+	t.Cleanup(func() {
+		conn.terminate()
+		conn.wait()
+	})
+
+	// cleanups run in reverse, the client end is closed first and a writer blocked on it gives up
+	return conn, newTestClient(t, clientConn, bufio.NewReader(clientConn))
+}
+
+// pipeSession - a JSON session of protocol version 1 over net.Pipe and the client on the other end of it.
+func pipeSession(t *testing.T, conf config.Websocket) (*Session, *testClient) {
+	t.Helper()
+
+	conn, client := pipeConnection(t, conf)
+
+	return newSession(conn, "pipe", wireProtocol{version: protocolVersion1, codec: jsonCodec{}}, "en"), client
+}
+
+// boundSession - a pipe session bound to the player on the server, as if the player had connected.
+func boundSession(t *testing.T, server *Server, playerID string) (*Session, *testClient) {
+	t.Helper()
+
+	session, client := pipeSession(t, server.config)
+	require.True(t, server.bindSession(session, playerID))
+
+	return session, client
+}
+
+// dialTestServer - opens a websocket connection to the test server, the header adds to or overrides the handshake one.
+// The handshake must succeed, the response is returned to check the negotiated headers.
+func dialTestServer(t *testing.T, server *httptest.Server, header http.Header) (*testClient, *http.Response) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+	require.NoError(t, err)
+
+	request.Header.Set(headerUpgrade, headerWebSocket)
+	request.Header.Set(headerConnection, headerUpgrade)
+	request.Header.Set(headerSecWebSocketVersion, supportedWebSocketVersion)
+	request.Header.Set(headerSecWebSocketKey, "dGhlIHNhbXBsZSBub25jZQ==")
+	for name, values := range header {
+		request.Header[http.CanonicalHeaderKey(name)] = values
+	}
+
+	require.NoError(t, conn.SetDeadline(time.Now().Add(testTimeout)))
+	require.NoError(t, request.Write(conn))
+
+	r := bufio.NewReader(conn)
+	response, err := http.ReadResponse(r, request)
+	require.NoError(t, err)
+	response.Body.Close()
+
+	require.Equal(t, http.StatusSwitchingProtocols, response.StatusCode)
+
+	return newTestClient(t, conn, r), response
+}
+
+// newTestServer - starts the websocket server on a local port, the game use case is a bare stub unless the test needs it.
+func newTestServer(t *testing.T, conf config.Websocket, gameUseCase gameUseCase) (*Server, *httptest.Server) {
+	t.Helper()
+
+	if gameUseCase == nil {
+		gameUseCase = &stubGameUseCase{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	server := New(ctx, testLogger(), conf, gameUseCase, nil)
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	return server, httpServer
+}
+
+// stubGameUseCase - the game use case of the handler tests, every method the test does not set panics.
+type stubGameUseCase struct {
+	gameUseCase
+
+	getOrCreateGame func(
+		ctx context.Context, playerID, gameType, difficulty string, strength int, settings entity.GameSettings,
+	) (*entity.Game, error)
+	createPrivateGameWithTwoPlayers func(
+		ctx context.Context, player1, player2 *entity.Player, settings entity.GameSettings,
+	) (*entity.Game, error)
+	timeOutGames func(ctx context.Context, now time.Time) ([]*entity.Game, error)
+	makeTurn     func(ctx context.Context, playerID string, cell int, piece string) (*entity.Game, error)
+	makeBotTurn  func(ctx context.Context, gameID string) (*entity.Game, error)
+
+	getArchivedGame func(ctx context.Context, playerID, gameID string) (*entity.ArchivedGame, error)
+
+	getOrCreatePlayer func(ctx context.Context, playerID string) (*entity.Player, error)
+	getGameByID       func(ctx context.Context, gameID string) (*entity.Game, error)
+	joinGameByID      func(ctx context.Context, gameID, playerID string) (*entity.Game, error)
+}
+
+func (that *stubGameUseCase) MakeTurn(ctx context.Context, playerID string, cell int, piece string) (*entity.Game, error) {
+	return that.makeTurn(ctx, playerID, cell, piece)
+}
+
+func (that *stubGameUseCase) MakeBotTurn(ctx context.Context, gameID string) (*entity.Game, error) {
+	return that.makeBotTurn(ctx, gameID)
+}
+
+// TimeOutGames - the clock monitor of every test server calls it, so no game times out unless the test says so.
+func (that *stubGameUseCase) TimeOutGames(ctx context.Context, now time.Time) ([]*entity.Game, error) {
+	if that.timeOutGames == nil {
+		return nil, nil
+	}
+
+	return that.timeOutGames(ctx, now)
+}
+
+func (that *stubGameUseCase) GetArchivedGame(ctx context.Context, playerID, gameID string) (*entity.ArchivedGame, error) {
+	return that.getArchivedGame(ctx, playerID, gameID)
+}
+
+func (that *stubGameUseCase) GetOrCreatePlayer(ctx context.Context, playerID string) (*entity.Player, error) {
+	return that.getOrCreatePlayer(ctx, playerID)
+}
+
+func (that *stubGameUseCase) GetGameByID(ctx context.Context, gameID string) (*entity.Game, error) {
+	return that.getGameByID(ctx, gameID)
+}
+
+func (that *stubGameUseCase) JoinGameByID(ctx context.Context, gameID, playerID string) (*entity.Game, error) {
+	return that.joinGameByID(ctx, gameID, playerID)
+}
+
+func (that *stubGameUseCase) GetOrCreateGame(
+	ctx context.Context, playerID, gameType, difficulty string, strength int, settings entity.GameSettings,
+) (*entity.Game, error) {
+	return that.getOrCreateGame(ctx, playerID, gameType, difficulty, strength, settings)
+}
+
+func (that *stubGameUseCase) CreatePrivateGameWithTwoPlayers(
+	ctx context.Context, player1, player2 *entity.Player, settings entity.GameSettings,
+) (*entity.Game, error) {
+	return that.createPrivateGameWithTwoPlayers(ctx, player1, player2, settings)
+}
+
+// maskedFrame - encodes a client frame, the header bits are the ones a frame of the opcode would have plus rsv.
+func maskedFrame(opCode byte, fin bool, rsv byte, payload []byte) []byte {
+	first := opCode | rsv
+	if fin {
+		first |= 0x80
+	}
+
+	buf := []byte{first}
+
+	switch {
+	case len(payload) < 126:
+		buf = append(buf, 0x80|byte(len(payload)))
+	case len(payload) < 1<<16:
+		buf = append(buf, 0x80|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(payload)))
+	default:
+		buf = append(buf, 0x80|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(len(payload)))
+	}
+
+	buf = append(buf, testMaskingKey...)
+	for i, b := range payload {
+		buf = append(buf, b^testMaskingKey[i%4])
+	}
+
+	return buf
+}
+
+// closePayload - the payload of a close frame with the status code and the reason.
+func closePayload(code uint16, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, code), reason...)
+}
+
+// writeRaw - writes the bytes as they are, the server may stop reading halfway, so write errors are ignored.
+func (that *testClient) writeRaw(raw []byte) {
+	that.t.Helper()
+
+	require.NoError(that.t, that.conn.SetWriteDeadline(time.Now().Add(testTimeout)))
+	_, _ = that.conn.Write(raw)
+}
+
+// writeFrame - writes a single masked frame.
+func (that *testClient) writeFrame(opCode byte, fin bool, payload []byte) {
+	that.t.Helper()
+
+	that.writeRaw(maskedFrame(opCode, fin, 0, payload))
+}
+
+// writeText - writes the text as a single text frame.
+func (that *testClient) writeText(text string) {
+	that.t.Helper()
+
+	that.writeFrame(opText, true, []byte(text))
+}
+
+// readFrame - reads a single unmasked server frame.
+func (that *testClient) readFrame() frame {
+	that.t.Helper()
+
+	f, err := that.tryReadFrame()
+	require.NoError(that.t, err)
+
+	return f
+}
+
+// tryReadFrame - reads a single unmasked server frame, reporting a failure instead of failing the test.
+func (that *testClient) tryReadFrame() (frame, error) {
+	if err := that.conn.SetReadDeadline(time.Now().Add(testTimeout)); err != nil {
+		return frame{}, fmt.Errorf("failed to set read deadline: %w", err)
+	}
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(that.r, header); err != nil {
+		return frame{}, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(that.r, extended); err != nil {
+			return frame{}, fmt.Errorf("failed to read extended length: %w", err)
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(that.r, extended); err != nil {
+			return frame{}, fmt.Errorf("failed to read extended length: %w", err)
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(that.r, payload); err != nil {
+		return frame{}, fmt.Errorf("failed to read payload: %w", err)
+	}
+
+	return frame{
+		isFin:   header[0]&0x80 != 0,
+		opCode:  header[0] & 0x0F,
+		length:  length,
+		payload: payload,
+
+		compressed: header[0]&rsv1Bit != 0,
+	}, nil
+}
+
+// readMessage - reads a JSON message of the server, the payload is decoded into the map.
+func (that *testClient) readMessage() (Message, map[string]any) {
+	that.t.Helper()
+
+	f := that.readFrame()
+	require.Equal(that.t, opText, f.opCode)
+
+	var message Message
+	require.NoError(that.t, json.Unmarshal(f.payload, &message))
+
+	var payload map[string]any
+	require.NoError(that.t, json.Unmarshal(message.Payload, &payload))
+
+	return message, payload
+}
+
+// readClose - reads frames until the close frame and returns its status code, the data frames before it are skipped.
+func (that *testClient) readClose() uint16 {
+	that.t.Helper()
+
+	for {
+		f := that.readFrame()
+		if f.opCode != opClose {
+			continue
+		}
+
+		require.GreaterOrEqual(that.t, len(f.payload), 2, "close frame without a status code")
+
+		return binary.BigEndian.Uint16(f.payload)
+	}
+}
+
+// readResult - the outcome of readRequest that ran in the background.
+type readResult struct {
+	message []byte
+	err     error
+}
+
+// readInBackground - runs readRequest of the session in a goroutine, the client writes while the server reads.
+func readInBackground(server *Server, session *Session) <-chan readResult {
+	results := make(chan readResult, 1)
+
+	go func() {
+		message, err := server.readRequest(session)
+		results <- readResult{message: message, err: err}
+	}()
+
+	return results
+}
+
+// awaitResult - waits for readRequest that ran in the background to return.
+func awaitResult(t *testing.T, results <-chan readResult) readResult {
+	t.Helper()
+
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(testTimeout):
+		require.FailNow(t, "readRequest did not return in time")
+		return readResult{}
+	}
+}
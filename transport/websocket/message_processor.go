@@ -7,13 +7,49 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"time"
+	"unicode/utf8"
 
 	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
 )
 
+// Opcodes defined in RFC 6455, section 5.2.
+const (
+	opContinuation byte = 0x0
+	opText         byte = 0x1
+	opBinary       byte = 0x2
+	opClose        byte = 0x8
+	opPing         byte = 0x9
+	opPong         byte = 0xA
+)
+
+// Close status codes defined in RFC 6455, section 7.4.1.
+const (
+	closeNormalClosure    uint16 = 1000
+	closeProtocolError    uint16 = 1002
+	closeUnsupportedData  uint16 = 1003
+	closeNoStatusReceived uint16 = 1005
+	closeInvalidPayload   uint16 = 1007
+	closePolicyViolation  uint16 = 1008
+	closeMessageTooBig    uint16 = 1009
+
+	// maxControlPayload is the largest payload a control frame may carry.
+	maxControlPayload = 125
+)
+
 var (
-	ErrUnsupportedOpcode              = errors.New("unsupported opcode")
-	ErrFragmentedMessagesNotSupported = errors.New("fragmented messages are not supported")
+	ErrUnsupportedOpcode      = errors.New("unsupported opcode")
+	ErrUnexpectedContinuation = errors.New("continuation frame without a message to continue")
+	ErrInterleavedMessage     = errors.New("new message started before the fragmented one was finished")
+	ErrMessageTooBig          = errors.New("message is too big")
+	ErrFrameTooBig            = errors.New("frame is too big")
+	ErrUnmaskedFrame          = errors.New("client frame is not masked")
+	ErrReservedBitsSet        = errors.New("reserved bits are set without a negotiated extension")
+	ErrInvalidUTF8            = errors.New("text message is not valid UTF-8")
+	ErrInvalidControlFrame    = errors.New("invalid control frame")
+	ErrInvalidCloseFrame      = errors.New("invalid close frame")
+	ErrPongTimeout            = errors.New("pong was not received in time")
 )
 
 // frame represents a WebSocket frame and its metadata.
@@ -22,14 +58,62 @@ type frame struct {
 	opCode  byte   // Код операции, указывающий тип данных (например, текстовое сообщение, бинарные данные и т.д.)
 	length  uint64 // Длина полезной нагрузки (payload) фрейма
 	payload []byte // Данные, передаваемые в фрейме
+
+	compressed bool // RSV1: the message starting with this frame is compressed with permessage-deflate
+}
+
+func (that frame) isControl() bool {
+	return that.opCode&0x8 != 0
+}
+
+// CloseError is returned when the connection has to be closed with a specific status code.
+type CloseError struct {
+	Code   uint16
+	Reason string
+	Err    error
+}
+
+func newCloseError(code uint16, err error) *CloseError {
+	return &CloseError{
+		Code:   code,
+		Reason: err.Error(),
+		Err:    err,
+	}
+}
+
+func (that *CloseError) Error() string {
+	return fmt.Sprintf("close %d: %v", that.Code, that.Err)
+}
+
+func (that *CloseError) Unwrap() error {
+	return that.Err
 }
 
+// Message types tell direct replies apart from messages the server pushes on its own.
+const (
+	messageTypeResponse = "response"
+	messageTypeEvent    = "event"
+	messageTypeError    = "error"
+)
+
 // Message represents a WebSocket message with an action type and a payload.
+// ID is supplied by the client and echoed on the direct response, Type is set by the server only.
 type Message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type,omitempty"`
 	Action  string          `json:"action"`
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
+// outboundMessage - the message the server sends. The payload stays a value until the codec of the session
+// encodes the whole message, so it is encoded once and straight into the negotiated format.
+type outboundMessage struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Action  string `json:"action"`
+	Payload any    `json:"payload,omitempty"`
+}
+
 type Payload struct {
 	Player  *entity.Player `json:"player,omitempty"`
 	Game    *entity.Game   `json:"game,omitempty"`
@@ -37,41 +121,80 @@ type Payload struct {
 	Cell    *int           `json:"cell,omitempty"`
 	Answer  string         `json:"answer,omitempty"`
 	Message string         `json:"message,omitempty"`
+
+	// ErrorCode - the machine readable code of Error, version 2 clients get it inside the error object.
+	ErrorCode ErrorCode `json:"error_code,omitempty"`
+	// Locale - the language the client wants server messages in, it is sent with connect and echoed in the reply.
+	Locale string `json:"locale,omitempty"`
+	// Token - the signed session token, the server issues a fresh one on every connect and auth:refresh.
+	Token string `json:"token,omitempty"`
+	// Piece - the mark game:turn puts on the board in wild games, empty for the own mark of the player.
+	Piece string `json:"piece,omitempty"`
+	// History - the page of the finished games game:history asks for and gets back.
+	History *HistoryPage `json:"history,omitempty"`
+	// Replay - the command game:replay sends and the state of the replay the server answers and pushes.
+	Replay *Replay `json:"replay,omitempty"`
+	// Spectators - how many connections watch the game, game:spectate answers with it and game:spectators pushes it.
+	// It is left out when nobody watches.
+	Spectators int `json:"spectators,omitempty"`
+}
+
+// HistoryPage - a page of the archived games of the player. The request sets Offset and Limit,
+// the response echoes them with the games of the page and the number of games in the whole history.
+type HistoryPage struct {
+	Offset int                    `json:"offset"`
+	Limit  int                    `json:"limit,omitempty"`
+	Total  int                    `json:"total"`
+	Games  []*entity.ArchivedGame `json:"games"`
+}
+
+// reply - sends the direct response to the request, the request ID is echoed back.
+func (that *Server) reply(session *Session, request *Message, payload Payload) error {
+	return that.sendMessage(session, Message{ID: request.ID, Type: messageTypeResponse, Action: request.Action}, payload)
+}
+
+// sendEvent - pushes a message the session did not ask for, such as an opponent move.
+func (that *Server) sendEvent(session *Session, action string, payload Payload) error {
+	return that.sendMessage(session, Message{Type: messageTypeEvent, Action: action}, payload)
+}
+
+// replyOrNotify - replies to the requesting session, every other session receives the payload as an event.
+func (that *Server) replyOrNotify(session, recipient *Session, request *Message, payload Payload) error {
+	if recipient == session {
+		return that.reply(session, request, payload)
+	}
+
+	return that.sendEvent(recipient, request.Action, payload)
 }
 
-func (that *Server) sendMessage(bufrw *bufio.ReadWriter, action string, payload Payload) error {
-	response := Message{
-		Action:  action,
-		Payload: json.RawMessage(mustMarshal(payload)),
+func (that *Server) sendMessage(session *Session, envelope Message, payload Payload) error {
+	response := outboundMessage{
+		ID:      envelope.ID,
+		Type:    envelope.Type,
+		Action:  envelope.Action,
+		Payload: versionedPayload(session.ProtocolVersion, payload),
 	}
-	responseBytes, err := json.Marshal(response)
+
+	responseBytes, err := session.codec.Marshal(response)
 	if err != nil {
 		return fmt.Errorf("failed to marshal response: %w", err)
 	}
 
 	f := frame{
 		isFin:   true,
-		opCode:  1, // текстовое сообщение
+		opCode:  session.codec.OpCode(), // текстовое сообщение для JSON, бинарное для MessagePack
 		length:  uint64(len(responseBytes)),
 		payload: responseBytes,
 	}
 
-	if err = writeFrame(bufrw, f); err != nil {
-		return fmt.Errorf("failed to write frame: %w", err)
+	if err = session.conn.send(f); err != nil {
+		return fmt.Errorf("failed to send frame: %w", err)
 	}
 
 	return nil
 }
 
-func mustMarshal(v interface{}) []byte {
-	b, err := json.Marshal(v)
-	if err != nil {
-		panic(err)
-	}
-	return b
-}
-
-func writeFrame(bufrw *bufio.ReadWriter, frameData frame) error {
+func writeFrame(w *bufio.Writer, frameData frame) error {
 	buf := make([]byte, 2)
 	buf[0] |= frameData.opCode
 
@@ -79,6 +202,10 @@ func writeFrame(bufrw *bufio.ReadWriter, frameData frame) error {
 		buf[0] |= 0x80
 	}
 
+	if frameData.compressed {
+		buf[0] |= rsv1Bit
+	}
+
 	switch {
 	case frameData.length < 126:
 		buf[1] |= byte(frameData.length)
@@ -96,100 +223,325 @@ func writeFrame(bufrw *bufio.ReadWriter, frameData frame) error {
 
 	buf = append(buf, frameData.payload...) //nolint: makezero // idk how to rewrite this
 
-	_, err := bufrw.Write(buf)
+	_, err := w.Write(buf)
 	if err != nil {
 		return fmt.Errorf("failed to write frame: %w", err)
 	}
 
-	if err = bufrw.Flush(); err != nil {
+	if err = w.Flush(); err != nil {
 		return fmt.Errorf("failed to flush buffer: %w", err)
 	}
 
 	return nil
 }
 
-func (that *Server) readRequest(bufrw *bufio.ReadWriter) ([]byte, error) {
-	header, err := readHeader(bufrw)
+// controlFrame - builds a single control frame (close, ping or pong).
+func controlFrame(opCode byte, payload []byte) frame {
+	return frame{
+		isFin:   true,
+		opCode:  opCode,
+		length:  uint64(len(payload)),
+		payload: payload,
+	}
+}
+
+// closeFrame - builds a close frame with the status code and the reason.
+func closeFrame(code uint16, reason string) frame {
+	// the reason must fit into a control frame together with the two-byte status code
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, code)
+	payload = append(payload, reason...)
+
+	return controlFrame(opClose, payload)
+}
+
+// readRequest - reads frames until a complete data message arrives.
+// Fragmented messages are reassembled from their continuation frames, control frames may be interleaved between them.
+// Control frames are answered on the way: pings get a pong, a close frame ends reading with a CloseError wrapping io.EOF.
+// When the peer stays silent for the ping interval the server sends a ping and expects any frame back within the pong wait.
+// Data messages must use the opcode of the codec negotiated for the session.
+func (that *Server) readRequest(session *Session) ([]byte, error) {
+	conn := session.conn
+
+	var (
+		awaitingPong bool
+		message      []byte
+		messageOp    byte // opcode of the message being assembled, zero while there is none
+		compressed   bool // the message being assembled has RSV1 set on its first frame
+	)
+
+	for {
+		wait := that.config.PingInterval
+		if awaitingPong {
+			wait = that.config.PongWait
+		}
+
+		if err := conn.conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+			return nil, fmt.Errorf("failed to set read deadline: %w", err)
+		}
+
+		// Peek does not consume anything, so a timeout here leaves the stream in a consistent state.
+		if _, err := conn.bufRW.Peek(1); err != nil {
+			if !errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil, fmt.Errorf("failed to read header: %w", err)
+			}
+
+			if awaitingPong {
+				return nil, ErrPongTimeout
+			}
+
+			if err = conn.send(controlFrame(opPing, nil)); err != nil {
+				return nil, fmt.Errorf("failed to send ping: %w", err)
+			}
+
+			awaitingPong = true
+			continue
+		}
+
+		// the frame has started, the rest of it must arrive within the pong wait
+		if err := conn.conn.SetReadDeadline(time.Now().Add(that.config.PongWait)); err != nil {
+			return nil, fmt.Errorf("failed to set read deadline: %w", err)
+		}
+
+		f, err := readFrame(conn.bufRW.Reader, uint64(that.config.MaxFrameSize), conn.deflate != nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// any frame proves that the peer is alive
+		awaitingPong = false
+
+		if f.isControl() {
+			if err = handleControlFrame(conn, f); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case f.opCode == opContinuation && messageOp == 0:
+			return nil, newCloseError(closeProtocolError, ErrUnexpectedContinuation)
+		case f.opCode != opContinuation && messageOp != 0:
+			return nil, newCloseError(closeProtocolError, ErrInterleavedMessage)
+		case f.opCode != opContinuation:
+			if err = validateDataOpcode(f.opCode, session.codec.OpCode()); err != nil {
+				return nil, err
+			}
+			messageOp = f.opCode
+			compressed = f.compressed
+		}
+
+		if uint64(len(message))+f.length > uint64(that.config.MaxMessageSize) {
+			return nil, newCloseError(closeMessageTooBig, fmt.Errorf("%w: limit is %d bytes", ErrMessageTooBig, that.config.MaxMessageSize))
+		}
+
+		message = append(message, f.payload...)
+
+		if !f.isFin {
+			continue
+		}
+
+		if compressed {
+			if message, err = conn.deflate.decompressor.decompress(message, that.config.MaxMessageSize); err != nil {
+				return nil, err
+			}
+		}
+
+		if messageOp == opText && !utf8.Valid(message) {
+			return nil, newCloseError(closeInvalidPayload, ErrInvalidUTF8)
+		}
+
+		return message, nil
+	}
+}
+
+// handleControlFrame - answers a control frame.
+// A close frame is reported as a CloseError carrying the status code to echo back, it wraps io.EOF.
+func handleControlFrame(conn *connection, f frame) error {
+	switch f.opCode {
+	case opPing:
+		if err := conn.send(controlFrame(opPong, f.payload)); err != nil {
+			return fmt.Errorf("failed to send pong: %w", err)
+		}
+		return nil
+	case opPong:
+		return nil
+	case opClose:
+		code, reason, err := parseClosePayload(f.payload)
+		if err != nil {
+			return err
+		}
+
+		// the close frame without a status code is answered with a normal closure
+		if code == closeNoStatusReceived {
+			code = closeNormalClosure
+		}
+
+		return &CloseError{
+			Code: code,
+			Err:  fmt.Errorf("%w: peer closed connection with code %d %q", io.EOF, code, reason),
+		}
+	default:
+		return newCloseError(closeProtocolError, fmt.Errorf("%w: %d", ErrUnsupportedOpcode, f.opCode))
+	}
+}
+
+// validateDataOpcode - checks that the message opcode matches the one of the codec in use.
+func validateDataOpcode(opCode, codecOpCode byte) error {
+	switch opCode {
+	case codecOpCode:
+		return nil
+	case opText, opBinary:
+		return newCloseError(closeUnsupportedData, fmt.Errorf("%w: %d", ErrUnsupportedOpcode, opCode))
+	default:
+		return newCloseError(closeProtocolError, fmt.Errorf("%w: %d", ErrUnsupportedOpcode, opCode))
+	}
+}
+
+// parseClosePayload - extracts the status code and the reason from the close frame payload.
+func parseClosePayload(payload []byte) (uint16, string, error) {
+	if len(payload) == 0 {
+		return closeNoStatusReceived, "", nil
+	}
+
+	if len(payload) == 1 {
+		return 0, "", newCloseError(closeProtocolError, fmt.Errorf("%w: truncated status code", ErrInvalidCloseFrame))
+	}
+
+	code := binary.BigEndian.Uint16(payload)
+	if !isValidCloseCode(code) {
+		return 0, "", newCloseError(closeProtocolError, fmt.Errorf("%w: status code %d", ErrInvalidCloseFrame, code))
+	}
+
+	reason := payload[2:]
+	if !utf8.Valid(reason) {
+		return 0, "", newCloseError(closeInvalidPayload, fmt.Errorf("%w: reason is not valid UTF-8", ErrInvalidCloseFrame))
+	}
+
+	return code, string(reason), nil
+}
+
+// isValidCloseCode - reports whether the peer is allowed to send the status code (RFC 6455, section 7.4).
+func isValidCloseCode(code uint16) bool {
+	switch {
+	case code >= 1000 && code <= 1003:
+		return true
+	case code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	default:
+		return false
+	}
+}
+
+// readFrame - reads a single client frame, payloads longer than maxPayload are rejected before they are read.
+// RSV1 is accepted only when permessage-deflate was negotiated for the connection.
+func readFrame(r *bufio.Reader, maxPayload uint64, deflate bool) (frame, error) {
+	header, err := readHeader(r)
 	if err != nil {
-		return nil, err
+		return frame{}, err
 	}
 
-	payload, err := readPayload(bufrw, header)
+	f, err := readPayload(r, header, maxPayload, deflate)
 	if err != nil {
-		return nil, err
+		return frame{}, err
 	}
 
-	return payload, nil
+	return f, nil
 }
 
-func readHeader(bufrw *bufio.ReadWriter) ([]byte, error) {
+func readHeader(r *bufio.Reader) ([]byte, error) {
 	header := make([]byte, 2)
-	_, err := bufrw.Read(header)
+	_, err := io.ReadFull(r, header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read header: %w", err)
 	}
 	return header, nil
 }
 
-func readPayload(bufrw *bufio.ReadWriter, header []byte) ([]byte, error) {
+func readPayload(r *bufio.Reader, header []byte, maxPayload uint64, deflate bool) (frame, error) {
 	fin := (header[0] & 0x80) != 0
+	rsv := header[0] & 0x70
 	opcode := header[0] & 0x0F
 	mask := (header[1] & 0x80) != 0
 	payloadLen := uint64(header[1] & 0x7F)
+	compressed := rsv&rsv1Bit != 0
+
+	// Биты RSV1-3 используются только расширениями, из них сервер согласовывает лишь RSV1 для permessage-deflate
+	if deflate {
+		rsv &^= rsv1Bit
+	}
+
+	if rsv != 0 {
+		return frame{}, newCloseError(closeProtocolError, fmt.Errorf("%w: 0x%x", ErrReservedBitsSet, rsv))
+	}
+
+	// RSV1 ставится только на первый фрейм сжатого сообщения с данными
+	if compressed && (opcode == opContinuation || opcode&0x8 != 0) {
+		return frame{}, newCloseError(closeProtocolError, fmt.Errorf("%w: RSV1 on opcode %d", ErrReservedBitsSet, opcode))
+	}
+
+	// Клиент обязан маскировать каждый фрейм (RFC 6455, раздел 5.1)
+	if !mask {
+		return frame{}, newCloseError(closeProtocolError, ErrUnmaskedFrame)
+	}
+
+	// Управляющие фреймы не фрагментируются и не превышают 125 байт
+	if opcode&0x8 != 0 && (!fin || payloadLen > maxControlPayload) {
+		return frame{}, newCloseError(closeProtocolError, fmt.Errorf("%w: opcode %d", ErrInvalidControlFrame, opcode))
+	}
 
 	// Чтение расширенной длины полезной нагрузки
 	if payloadLen == 126 {
 		extended := make([]byte, 2)
-		_, err := io.ReadFull(bufrw, extended)
+		_, err := io.ReadFull(r, extended)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read extended payload length: %w", err)
+			return frame{}, fmt.Errorf("failed to read extended payload length: %w", err)
 		}
 		payloadLen = uint64(binary.BigEndian.Uint16(extended))
 	} else if payloadLen == 127 {
 		extended := make([]byte, 8)
-		_, err := io.ReadFull(bufrw, extended)
+		_, err := io.ReadFull(r, extended)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read extended payload length: %w", err)
+			return frame{}, fmt.Errorf("failed to read extended payload length: %w", err)
 		}
 		payloadLen = binary.BigEndian.Uint64(extended)
 	}
 
+	// Длина проверяется до выделения памяти под полезную нагрузку
+	if payloadLen > maxPayload {
+		return frame{}, newCloseError(closeMessageTooBig, fmt.Errorf("%w: %d bytes, limit is %d", ErrFrameTooBig, payloadLen, maxPayload))
+	}
+
 	// Чтение маскирующего ключа
-	var maskingKey []byte
-	if mask {
-		maskingKey = make([]byte, 4)
-		_, err := io.ReadFull(bufrw, maskingKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read masking key: %w", err)
-		}
+	maskingKey := make([]byte, 4)
+	if _, err := io.ReadFull(r, maskingKey); err != nil {
+		return frame{}, fmt.Errorf("failed to read masking key: %w", err)
 	}
 
 	// Чтение полезной нагрузки
 	payload := make([]byte, payloadLen)
-	_, err := io.ReadFull(bufrw, payload)
+	_, err := io.ReadFull(r, payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read payload: %w", err)
+		return frame{}, fmt.Errorf("failed to read payload: %w", err)
 	}
 
 	// Применение маски
-	if mask {
-		for i := range payload {
-			payload[i] ^= maskingKey[i%4]
-		}
-	}
-
-	// Обработка опкодов
-	if opcode == 8 {
-		// Фрейм закрытия соединения
-		return nil, io.EOF
-	} else if opcode != 1 {
-		return nil, fmt.Errorf("%w: %d", ErrUnsupportedOpcode, opcode)
+	for i := range payload {
+		payload[i] ^= maskingKey[i%4]
 	}
 
-	if !fin {
-		// Обработка фрагментированных сообщений, если необходимо
-		return nil, ErrFragmentedMessagesNotSupported
-	}
+	return frame{
+		isFin:   fin,
+		opCode:  opcode,
+		length:  payloadLen,
+		payload: payload,
 
-	return payload, nil
+		compressed: compressed,
+	}, nil
 }
@@ -0,0 +1,484 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRequest_ControlFrames(t *testing.T) {
+	t.Run("Answers a ping with a pong carrying the same payload", func(t *testing.T) {
+		// Given: a session the server reads from
+		server := &Server{logger: testLogger(), config: testConfig()}
+		session, client := pipeSession(t, server.config)
+		results := readInBackground(server, session)
+
+		// When: the client pings in the middle of waiting for a message
+		client.writeFrame(opPing, true, []byte("are you there"))
+		pong := client.readFrame()
+		client.writeText(`{"action":"connect"}`)
+
+		// Then: the pong should echo the payload and the message should still be read
+		assert.Equal(t, opPong, pong.opCode)
+		assert.True(t, pong.isFin)
+		assert.Equal(t, []byte("are you there"), pong.payload)
+
+		result := awaitResult(t, results)
+		require.NoError(t, result.err)
+		assert.Equal(t, `{"action":"connect"}`, string(result.message))
+	})
+
+	t.Run("Ignores an unsolicited pong", func(t *testing.T) {
+		// Given: a session the server reads from
+		server := &Server{logger: testLogger(), config: testConfig()}
+		session, client := pipeSession(t, server.config)
+		results := readInBackground(server, session)
+
+		// When: the client sends a pong nobody asked for and then a message
+		client.writeFrame(opPong, true, []byte("heartbeat"))
+		client.writeText(`{"action":"connect"}`)
+
+		// Then: the message should be read
+		result := awaitResult(t, results)
+		require.NoError(t, result.err)
+		assert.Equal(t, `{"action":"connect"}`, string(result.message))
+	})
+}
+
+func TestReadRequest_CloseFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  []byte
+		wantCode uint16
+		wantEOF  bool
+	}{
+		{name: "Normal closure", payload: closePayload(closeNormalClosure, "bye"), wantCode: closeNormalClosure, wantEOF: true},
+		{name: "Going away", payload: closePayload(1001, ""), wantCode: 1001, wantEOF: true},
+		{name: "Application code", payload: closePayload(4000, "done"), wantCode: 4000, wantEOF: true},
+		{name: "No status code is answered with a normal closure", payload: nil, wantCode: closeNormalClosure, wantEOF: true},
+		{name: "Truncated status code", payload: []byte{0x03}, wantCode: closeProtocolError},
+		{name: "Status code the peer may not send", payload: closePayload(closeNoStatusReceived, ""), wantCode: closeProtocolError},
+		{name: "Reason that is not UTF-8", payload: closePayload(closeNormalClosure, "\xff\xfe"), wantCode: closeInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: a session the server reads from
+			server := &Server{logger: testLogger(), config: testConfig()}
+			session, client := pipeSession(t, server.config)
+			results := readInBackground(server, session)
+
+			// When: the client sends the close frame
+			client.writeFrame(opClose, true, tt.payload)
+
+			// Then: reading should end with the status code to close the connection with
+			result := awaitResult(t, results)
+
+			var closeErr *CloseError
+			require.ErrorAs(t, result.err, &closeErr)
+			assert.Equal(t, tt.wantCode, closeErr.Code)
+			assert.Equal(t, tt.wantEOF, errors.Is(result.err, io.EOF))
+		})
+	}
+}
+
+func TestReadRequest_Heartbeat(t *testing.T) {
+	t.Run("Pings a silent peer and goes on reading once it answers", func(t *testing.T) {
+		// Given: a server that pings after a short silence
+		conf := testConfig()
+		conf.PingInterval = 50 * time.Millisecond
+		server := &Server{logger: testLogger(), config: conf}
+		session, client := pipeSession(t, conf)
+		results := readInBackground(server, session)
+
+		// When: the client stays silent until pinged, then answers and sends a message
+		ping := client.readFrame()
+		client.writeFrame(opPong, true, ping.payload)
+		client.writeText(`{"action":"connect"}`)
+
+		// Then: the server should have pinged and the message should be read
+		assert.Equal(t, opPing, ping.opCode)
+
+		result := awaitResult(t, results)
+		require.NoError(t, result.err)
+		assert.Equal(t, `{"action":"connect"}`, string(result.message))
+	})
+
+	t.Run("Gives up on a peer that does not answer the ping", func(t *testing.T) {
+		// Given: a server that pings after a short silence and waits briefly for the pong
+		conf := testConfig()
+		conf.PingInterval = 50 * time.Millisecond
+		conf.PongWait = 50 * time.Millisecond
+		server := &Server{logger: testLogger(), config: conf}
+		session, client := pipeSession(t, conf)
+		results := readInBackground(server, session)
+
+		// When: the client reads the ping but never answers
+		ping := client.readFrame()
+
+		// Then: reading should fail with the pong timeout
+		assert.Equal(t, opPing, ping.opCode)
+
+		result := awaitResult(t, results)
+		require.ErrorIs(t, result.err, ErrPongTimeout)
+	})
+}
+
+func TestServer_EchoesCloseCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  []byte
+		wantCode uint16
+	}{
+		{name: "Status code of the peer", payload: closePayload(1001, "going away"), wantCode: 1001},
+		{name: "Normal closure for a close frame without a status code", payload: nil, wantCode: closeNormalClosure},
+		{name: "Protocol error for an invalid status code", payload: closePayload(1006, ""), wantCode: closeProtocolError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: a client connected to the server
+			_, httpServer := newTestServer(t, testConfig(), nil)
+			client, _ := dialTestServer(t, httpServer, http.Header{})
+
+			// When: the client starts the closing handshake
+			client.writeFrame(opClose, true, tt.payload)
+
+			// Then: the server should answer with the close frame and close the socket
+			assert.Equal(t, tt.wantCode, client.readClose())
+
+			_, err := client.tryReadFrame()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestParseClosePayload(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    []byte
+		wantCode   uint16
+		wantReason string
+		wantClose  uint16 // the status code of the CloseError, zero when the payload is valid
+	}{
+		{name: "Empty payload", payload: nil, wantCode: closeNoStatusReceived},
+		{name: "Status code only", payload: closePayload(closeNormalClosure, ""), wantCode: closeNormalClosure},
+		{name: "Status code and reason", payload: closePayload(3000, "поражение"), wantCode: 3000, wantReason: "поражение"},
+		{name: "Single byte", payload: []byte{0x03}, wantClose: closeProtocolError},
+		{name: "Reserved status code", payload: closePayload(1004, ""), wantClose: closeProtocolError},
+		{name: "Invalid UTF-8 reason", payload: closePayload(closeNormalClosure, "\xc3\x28"), wantClose: closeInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: parsing the close payload
+			code, reason, err := parseClosePayload(tt.payload)
+
+			// Then: the status code and the reason or the close error should be returned
+			if tt.wantClose != 0 {
+				var closeErr *CloseError
+				require.ErrorAs(t, err, &closeErr)
+				assert.Equal(t, tt.wantClose, closeErr.Code)
+				assert.ErrorIs(t, err, ErrInvalidCloseFrame)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func TestIsValidCloseCode(t *testing.T) {
+	tests := []struct {
+		code uint16
+		want bool
+	}{
+		{code: 999, want: false},
+		{code: 1000, want: true},
+		{code: 1003, want: true},
+		{code: 1004, want: false},
+		{code: 1005, want: false},
+		{code: 1006, want: false},
+		{code: 1007, want: true},
+		{code: 1011, want: true},
+		{code: 1012, want: false},
+		{code: 1015, want: false},
+		{code: 2999, want: false},
+		{code: 3000, want: true},
+		{code: 4999, want: true},
+		{code: 5000, want: false},
+	}
+
+	for _, tt := range tests {
+		// When: checking whether the peer may send the status code
+		got := isValidCloseCode(tt.code)
+
+		// Then: only the codes RFC 6455 lets the peer send should be valid
+		assert.Equal(t, tt.want, got, "code %d", tt.code)
+	}
+}
+
+func TestReadRequest_Fragmentation(t *testing.T) {
+	t.Run("Reassembles a message with control frames between its fragments", func(t *testing.T) {
+		// Given: a session the server reads from
+		server := &Server{logger: testLogger(), config: testConfig()}
+		session, client := pipeSession(t, server.config)
+		results := readInBackground(server, session)
+
+		// When: the client sends a message in three fragments with a ping and a pong between them
+		client.writeFrame(opText, false, []byte(`{"action":`))
+		client.writeFrame(opPing, true, []byte("p"))
+		pong := client.readFrame()
+		client.writeFrame(opContinuation, false, []byte(`"connect",`))
+		client.writeFrame(opPong, true, nil)
+		client.writeFrame(opContinuation, true, []byte(`"payload":{}}`))
+
+		// Then: the ping should be answered and the message should be read whole
+		assert.Equal(t, opPong, pong.opCode)
+
+		result := awaitResult(t, results)
+		require.NoError(t, result.err)
+		assert.Equal(t, `{"action":"connect","payload":{}}`, string(result.message))
+	})
+
+	t.Run("Accepts a message as large as the limit", func(t *testing.T) {
+		// Given: a session the server reads from
+		server := &Server{logger: testLogger(), config: testConfig()}
+		session, client := pipeSession(t, server.config)
+		results := readInBackground(server, session)
+
+		// When: the client sends the largest message the server accepts in two fragments
+		half := int(server.config.MaxMessageSize / 2)
+		client.writeFrame(opText, false, []byte(strings.Repeat("a", half)))
+		client.writeFrame(opContinuation, true, []byte(strings.Repeat("b", half)))
+
+		// Then: the message should be read
+		result := awaitResult(t, results)
+		require.NoError(t, result.err)
+		assert.Len(t, result.message, int(server.config.MaxMessageSize))
+	})
+
+	tests := []struct {
+		name     string
+		frames   [][]byte
+		wantCode uint16
+		wantErr  error
+	}{
+		{
+			name:     "Continuation without a message to continue",
+			frames:   [][]byte{maskedFrame(opContinuation, true, 0, []byte("x"))},
+			wantCode: closeProtocolError,
+			wantErr:  ErrUnexpectedContinuation,
+		},
+		{
+			name: "New data frame before the fragmented message is finished",
+			frames: [][]byte{
+				maskedFrame(opText, false, 0, []byte("first")),
+				maskedFrame(opText, true, 0, []byte("second")),
+			},
+			wantCode: closeProtocolError,
+			wantErr:  ErrInterleavedMessage,
+		},
+		{
+			name: "Fragments together larger than the message limit",
+			frames: [][]byte{
+				maskedFrame(opText, false, 0, make([]byte, 64)),
+				maskedFrame(opContinuation, false, 0, make([]byte, 64)),
+				maskedFrame(opContinuation, true, 0, []byte("x")),
+			},
+			wantCode: closeMessageTooBig,
+			wantErr:  ErrMessageTooBig,
+		},
+		{
+			name: "Fragmented text that is not UTF-8 once reassembled",
+			frames: [][]byte{
+				maskedFrame(opText, false, 0, []byte("\xd0")),
+				maskedFrame(opContinuation, true, 0, []byte("\x28")),
+			},
+			wantCode: closeInvalidPayload,
+			wantErr:  ErrInvalidUTF8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: a session the server reads from
+			server := &Server{logger: testLogger(), config: testConfig()}
+			session, client := pipeSession(t, server.config)
+			results := readInBackground(server, session)
+
+			// When: the client sends the frames
+			for _, raw := range tt.frames {
+				client.writeRaw(raw)
+			}
+
+			// Then: reading should fail with the status code to close the connection with
+			result := awaitResult(t, results)
+
+			var closeErr *CloseError
+			require.ErrorAs(t, result.err, &closeErr)
+			assert.Equal(t, tt.wantCode, closeErr.Code)
+			assert.ErrorIs(t, result.err, tt.wantErr)
+		})
+	}
+}
+
+func TestServer_ClosesOversizedMessage(t *testing.T) {
+	// Given: a client connected to the server
+	conf := testConfig()
+	_, httpServer := newTestServer(t, conf, nil)
+	client, _ := dialTestServer(t, httpServer, http.Header{})
+
+	// When: the client sends fragments that together exceed the message limit
+	client.writeFrame(opText, false, make([]byte, conf.MaxFrameSize))
+	client.writeFrame(opContinuation, false, make([]byte, conf.MaxFrameSize))
+	client.writeFrame(opContinuation, true, []byte("x"))
+
+	// Then: the server should close the connection with 1009
+	assert.Equal(t, closeMessageTooBig, client.readClose())
+}
+
+func TestReadFrame_Rejects(t *testing.T) {
+	const maxPayload = 64
+
+	unmasked := maskedFrame(opText, true, 0, []byte("hi"))
+	unmasked[1] &^= 0x80
+	unmasked = append(unmasked[:2], []byte("hi")...)
+
+	tests := []struct {
+		name     string
+		raw      []byte
+		deflate  bool
+		wantCode uint16
+		wantErr  error
+	}{
+		{
+			name:     "Unmasked frame",
+			raw:      unmasked,
+			wantCode: closeProtocolError,
+			wantErr:  ErrUnmaskedFrame,
+		},
+		{
+			name:     "RSV1 without a negotiated extension",
+			raw:      maskedFrame(opText, true, rsv1Bit, []byte("hi")),
+			wantCode: closeProtocolError,
+			wantErr:  ErrReservedBitsSet,
+		},
+		{
+			name:     "RSV2 with permessage-deflate",
+			raw:      maskedFrame(opText, true, 0x20, []byte("hi")),
+			deflate:  true,
+			wantCode: closeProtocolError,
+			wantErr:  ErrReservedBitsSet,
+		},
+		{
+			name:     "RSV3 with permessage-deflate",
+			raw:      maskedFrame(opText, true, 0x10, []byte("hi")),
+			deflate:  true,
+			wantCode: closeProtocolError,
+			wantErr:  ErrReservedBitsSet,
+		},
+		{
+			name:     "RSV1 on a continuation frame",
+			raw:      maskedFrame(opContinuation, true, rsv1Bit, []byte("hi")),
+			deflate:  true,
+			wantCode: closeProtocolError,
+			wantErr:  ErrReservedBitsSet,
+		},
+		{
+			name:     "RSV1 on a control frame",
+			raw:      maskedFrame(opPing, true, rsv1Bit, nil),
+			deflate:  true,
+			wantCode: closeProtocolError,
+			wantErr:  ErrReservedBitsSet,
+		},
+		{
+			name:     "Fragmented control frame",
+			raw:      maskedFrame(opPing, false, 0, []byte("hi")),
+			wantCode: closeProtocolError,
+			wantErr:  ErrInvalidControlFrame,
+		},
+		{
+			name:     "Control frame over 125 bytes",
+			raw:      maskedFrame(opPing, true, 0, make([]byte, maxControlPayload+1)),
+			wantCode: closeProtocolError,
+			wantErr:  ErrInvalidControlFrame,
+		},
+		{
+			name:     "Frame over the limit with a 16-bit length",
+			raw:      maskedFrame(opText, true, 0, make([]byte, maxPayload+1)),
+			wantCode: closeMessageTooBig,
+			wantErr:  ErrFrameTooBig,
+		},
+		{
+			// only the header is sent, the length must be refused before the payload is allocated or read
+			name:     "Frame over the limit with a 64-bit length",
+			raw:      []byte{0x81, 0x80 | 127, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			wantCode: closeMessageTooBig,
+			wantErr:  ErrFrameTooBig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: reading the frame
+			_, err := readFrame(bufio.NewReader(bytes.NewReader(tt.raw)), maxPayload, tt.deflate)
+
+			// Then: it should be rejected with the status code to close the connection with
+			var closeErr *CloseError
+			require.ErrorAs(t, err, &closeErr)
+			assert.Equal(t, tt.wantCode, closeErr.Code)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReadFrame_Accepts(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            []byte
+		deflate        bool
+		wantPayload    []byte
+		wantCompressed bool
+	}{
+		{
+			name:        "Control frame of 125 bytes",
+			raw:         maskedFrame(opPing, true, 0, bytes.Repeat([]byte("p"), maxControlPayload)),
+			wantPayload: bytes.Repeat([]byte("p"), maxControlPayload),
+		},
+		{
+			name:        "Frame as large as the limit",
+			raw:         maskedFrame(opBinary, true, 0, bytes.Repeat([]byte{7}, 200)),
+			wantPayload: bytes.Repeat([]byte{7}, 200),
+		},
+		{
+			name:           "RSV1 on the first frame of a compressed message",
+			raw:            maskedFrame(opText, false, rsv1Bit, []byte("zip")),
+			deflate:        true,
+			wantPayload:    []byte("zip"),
+			wantCompressed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: reading the frame
+			f, err := readFrame(bufio.NewReader(bytes.NewReader(tt.raw)), 200, tt.deflate)
+
+			// Then: the payload should be unmasked
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPayload, f.payload)
+			assert.Equal(t, tt.wantCompressed, f.compressed)
+		})
+	}
+}
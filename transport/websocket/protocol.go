@@ -0,0 +1,77 @@
+package websocket
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Protocol versions, negotiated through Sec-WebSocket-Protocol.
+const (
+	// protocolVersion1 is the original {action, payload} envelope, errors are plain strings.
+	protocolVersion1 = 1
+	// protocolVersion2 sends errors as objects, so they can grow new fields without breaking clients.
+	protocolVersion2 = 2
+
+	subprotocolV1 = "tictactoe.v1"
+	subprotocolV2 = "tictactoe.v2"
+
+	// msgpackSuffix switches any protocol version to the MessagePack codec, e.g. tictactoe.v2+msgpack.
+	msgpackSuffix = "+msgpack"
+)
+
+// wireProtocol - the protocol version and the codec a connection speaks.
+type wireProtocol struct {
+	version int
+	codec   codec
+}
+
+// subprotocols maps every subprotocol the server speaks to its protocol version and codec.
+var subprotocols = map[string]wireProtocol{
+	subprotocolV1:                 {version: protocolVersion1, codec: jsonCodec{}},
+	subprotocolV2:                 {version: protocolVersion2, codec: jsonCodec{}},
+	subprotocolV1 + msgpackSuffix: {version: protocolVersion1, codec: msgpackCodec{}},
+	subprotocolV2 + msgpackSuffix: {version: protocolVersion2, codec: msgpackCodec{}},
+}
+
+// negotiateSubprotocol - picks the first subprotocol offered by the client that the server speaks.
+// Clients that offer nothing are old app builds and speak JSON version 1, the returned subprotocol is empty for them.
+func negotiateSubprotocol(header http.Header) (string, wireProtocol) {
+	for _, value := range header.Values(headerSecWebSocketProtocol) {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if protocol, ok := subprotocols[name]; ok {
+				return name, protocol
+			}
+		}
+	}
+
+	return "", wireProtocol{version: protocolVersion1, codec: jsonCodec{}}
+}
+
+// ErrorPayload is the error object sent to version 2 clients.
+type ErrorPayload struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// payloadV2 replaces the plain error string and code of Payload with ErrorPayload. The fields come before the
+// embedded Payload so that msgpack inlines only the fields they do not shadow.
+type payloadV2 struct {
+	Error     *ErrorPayload `json:"error,omitempty"`
+	ErrorCode ErrorCode     `json:"error_code,omitempty"` // always empty, it hides Payload.ErrorCode
+	Payload   `msgpack:",inline"`
+}
+
+// versionedPayload - converts the payload into the shape the protocol version puts on the wire.
+func versionedPayload(version int, payload Payload) any {
+	switch version {
+	case protocolVersion2:
+		wire := payloadV2{Payload: payload}
+		if payload.Error != "" {
+			wire.Error = &ErrorPayload{Code: payload.ErrorCode, Message: payload.Error}
+		}
+		return wire
+	default:
+		return payload
+	}
+}
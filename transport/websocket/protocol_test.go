@@ -0,0 +1,147 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateSubprotocol(t *testing.T) {
+	tests := []struct {
+		name            string
+		offers          []string
+		wantSubprotocol string
+		wantVersion     int
+		wantCodec       codec
+	}{
+		{name: "No offer", offers: nil, wantSubprotocol: "", wantVersion: protocolVersion1, wantCodec: jsonCodec{}},
+		{name: "Version 1", offers: []string{"tictactoe.v1"}, wantSubprotocol: "tictactoe.v1", wantVersion: protocolVersion1, wantCodec: jsonCodec{}},
+		{name: "Version 2", offers: []string{"tictactoe.v2"}, wantSubprotocol: "tictactoe.v2", wantVersion: protocolVersion2, wantCodec: jsonCodec{}},
+		{
+			name:            "Version 1 over MessagePack",
+			offers:          []string{"tictactoe.v1+msgpack"},
+			wantSubprotocol: "tictactoe.v1+msgpack",
+			wantVersion:     protocolVersion1,
+			wantCodec:       msgpackCodec{},
+		},
+		{
+			name:            "Version 2 over MessagePack",
+			offers:          []string{"tictactoe.v2+msgpack"},
+			wantSubprotocol: "tictactoe.v2+msgpack",
+			wantVersion:     protocolVersion2,
+			wantCodec:       msgpackCodec{},
+		},
+		{name: "Unknown offer only", offers: []string{"chat.v9"}, wantSubprotocol: "", wantVersion: protocolVersion1, wantCodec: jsonCodec{}},
+		{
+			name:            "First known offer in a list",
+			offers:          []string{"chat.v9, tictactoe.v2+msgpack", "tictactoe.v1"},
+			wantSubprotocol: "tictactoe.v2+msgpack",
+			wantVersion:     protocolVersion2,
+			wantCodec:       msgpackCodec{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: the offers of the client
+			header := http.Header{}
+			for _, offer := range tt.offers {
+				header.Add(headerSecWebSocketProtocol, offer)
+			}
+
+			// When: negotiating the subprotocol
+			subprotocol, protocol := negotiateSubprotocol(header)
+
+			// Then: the first offer the server speaks should be picked, JSON version 1 without one
+			assert.Equal(t, tt.wantSubprotocol, subprotocol)
+			assert.Equal(t, tt.wantVersion, protocol.version)
+			assert.Equal(t, tt.wantCodec, protocol.codec)
+		})
+	}
+}
+
+func TestServer_SpeaksNegotiatedSubprotocol(t *testing.T) {
+	tests := []struct {
+		name            string
+		offer           string
+		wantSubprotocol string
+		wantOpCode      byte
+		wantV2Errors    bool
+	}{
+		{name: "No offer", offer: "", wantSubprotocol: "", wantOpCode: opText},
+		{name: "Unknown offer", offer: "chat.v9", wantSubprotocol: "", wantOpCode: opText},
+		{name: "Version 1", offer: "tictactoe.v1", wantSubprotocol: "tictactoe.v1", wantOpCode: opText},
+		{name: "Version 2", offer: "tictactoe.v2", wantSubprotocol: "tictactoe.v2", wantOpCode: opText, wantV2Errors: true},
+		{name: "Version 1 over MessagePack", offer: "tictactoe.v1+msgpack", wantSubprotocol: "tictactoe.v1+msgpack", wantOpCode: opBinary},
+		{
+			name:            "Version 2 over MessagePack",
+			offer:           "tictactoe.v2+msgpack",
+			wantSubprotocol: "tictactoe.v2+msgpack",
+			wantOpCode:      opBinary,
+			wantV2Errors:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: a client connected with the offer
+			_, httpServer := newTestServer(t, testConfig(), nil)
+
+			header := http.Header{}
+			if tt.offer != "" {
+				header.Set(headerSecWebSocketProtocol, tt.offer)
+			}
+
+			client, response := dialTestServer(t, httpServer, header)
+			assert.Equal(t, tt.wantSubprotocol, response.Header.Get(headerSecWebSocketProtocol))
+
+			var wireCodec codec = jsonCodec{}
+			if tt.wantOpCode == opBinary {
+				wireCodec = msgpackCodec{}
+			}
+
+			request, err := wireCodec.Marshal(Message{ID: "7", Action: "game:unknown"})
+			require.NoError(t, err)
+
+			// When: the client sends an action the server does not know in the negotiated encoding
+			client.writeFrame(tt.wantOpCode, true, request)
+
+			// Then: the error should come back in the same encoding and in the shape of the protocol version
+			f := client.readFrame()
+			require.Equal(t, tt.wantOpCode, f.opCode)
+
+			var message Message
+			require.NoError(t, wireCodec.Unmarshal(f.payload, &message))
+			assert.Equal(t, "7", message.ID)
+			assert.Equal(t, messageTypeError, message.Type)
+			assert.Equal(t, "game:unknown", message.Action)
+
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal(message.Payload, &payload))
+
+			if tt.wantV2Errors {
+				assert.Equal(t, map[string]any{"code": string(CodeUnknownAction), "message": "Unknown action"}, payload["error"])
+				assert.NotContains(t, payload, "error_code")
+				return
+			}
+
+			assert.Equal(t, "Unknown action", payload["error"])
+			assert.Equal(t, string(CodeUnknownAction), payload["error_code"])
+		})
+	}
+}
+
+func TestServer_RefusesDataFrameOfOtherCodec(t *testing.T) {
+	// Given: a client that negotiated JSON
+	_, httpServer := newTestServer(t, testConfig(), nil)
+	client, _ := dialTestServer(t, httpServer, http.Header{})
+
+	// When: it sends a binary message
+	client.writeFrame(opBinary, true, []byte{0x80})
+
+	// Then: the server should close the connection with 1003
+	assert.Equal(t, closeUnsupportedData, client.readClose())
+}
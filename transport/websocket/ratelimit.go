@@ -0,0 +1,300 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+// rateLimitCleanupInterval is how often the buckets of remote IPs that went quiet are dropped.
+const rateLimitCleanupInterval = time.Minute
+
+const (
+	headerXForwardedFor = "X-Forwarded-For"
+	headerXRealIP       = "X-Real-IP"
+)
+
+var ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+// tokenBucket - refills at rate tokens per second up to burst, every allowed message takes a token.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+func (that *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(that.last).Seconds(); elapsed > 0 {
+		that.tokens = min(that.burst, that.tokens+elapsed*that.rate)
+	}
+	that.last = now
+}
+
+// allow - takes a token if there is one.
+func (that *tokenBucket) allow(now time.Time) bool {
+	that.refill(now)
+
+	if that.tokens < 1 {
+		return false
+	}
+	that.tokens--
+
+	return true
+}
+
+// full - reports whether the bucket has refilled completely, such a bucket is the same as a new one.
+func (that *tokenBucket) full(now time.Time) bool {
+	that.refill(now)
+	return that.tokens >= that.burst
+}
+
+// rateLimiter keeps the buckets shared by all connections from a remote IP.
+type rateLimiter struct {
+	config         config.RateLimit
+	trustedProxies []netip.Prefix
+
+	mu  sync.Mutex
+	ips map[string]*tokenBucket
+}
+
+func newRateLimiter(logger *slog.Logger, conf config.RateLimit) *rateLimiter {
+	log := logger.With("method", "newRateLimiter")
+
+	trustedProxies, err := parseTrustedProxies(conf.TrustedProxies)
+	if err != nil {
+		// trusting fewer proxies is safe, the limits of their clients are counted by the proxy address
+		log.Error("ignoring invalid trusted proxies", "error", err)
+	}
+
+	return &rateLimiter{
+		config:         conf,
+		trustedProxies: trustedProxies,
+		ips:            make(map[string]*tokenBucket),
+	}
+}
+
+// parseTrustedProxies - parses the addresses and CIDR ranges of the trusted proxies, the invalid ones are left out and reported.
+func parseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+
+	var errs []error
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid trusted proxy range %q: %w", entry, err))
+				continue
+			}
+			prefixes = append(prefixes, prefix.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid trusted proxy address %q: %w", entry, err))
+			continue
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, errors.Join(errs...)
+}
+
+// clientIP - the IP the limits of the request are counted by.
+// A request from a trusted proxy is counted by the client the proxy reports, otherwise by the peer address.
+func (that *rateLimiter) clientIP(r *http.Request) string {
+	peer := remoteIP(r.RemoteAddr)
+	if !that.trusted(peer) {
+		return peer
+	}
+
+	if hops := r.Header.Values(headerXForwardedFor); len(hops) > 0 {
+		if ip, ok := that.forwardedFor(hops); ok {
+			return ip
+		}
+
+		return peer
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(headerXRealIP))); err == nil {
+		return realIP.Unmap().String()
+	}
+
+	return peer
+}
+
+// forwardedFor - the rightmost X-Forwarded-For hop that is not a trusted proxy, the hops left of it may be forged by the client.
+// A chain of trusted proxies only yields its leftmost hop, a malformed hop makes the whole header untrustworthy.
+func (that *rateLimiter) forwardedFor(values []string) (string, bool) {
+	hops := strings.Split(strings.Join(values, ","), ",")
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return "", false
+		}
+		addr = addr.Unmap()
+
+		if i == 0 || !that.trusted(addr.String()) {
+			return addr.String(), true
+		}
+	}
+
+	return "", false
+}
+
+// trusted - reports whether the IP belongs to a trusted proxy.
+func (that *rateLimiter) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range that.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allow - reports whether the remote IP may send one more message.
+func (that *rateLimiter) allow(ip string, now time.Time) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	bucket, ok := that.ips[ip]
+	if !ok {
+		bucket = newTokenBucket(that.config.IPRate, that.config.IPBurst, now)
+		that.ips[ip] = bucket
+	}
+
+	return bucket.allow(now)
+}
+
+// cleanup - drops the buckets that have refilled, the next message of the IP starts with a new full bucket anyway.
+func (that *rateLimiter) cleanup(now time.Time) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	for ip, bucket := range that.ips {
+		if bucket.full(now) {
+			delete(that.ips, ip)
+		}
+	}
+}
+
+func (that *rateLimiter) run(ctx context.Context) {
+	ticker := time.NewTicker(rateLimitCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			that.cleanup(now)
+		}
+	}
+}
+
+// sessionLimit - the buckets of a single connection and the record of its violations.
+// It is used only by the goroutine reading the connection.
+type sessionLimit struct {
+	// ip - the client IP the shared buckets are taken from, see rateLimiter.clientIP.
+	ip     string
+	bucket *tokenBucket
+	// actions - the buckets of the actions with a configured limit, created by the first message of the action.
+	actions map[string]*tokenBucket
+
+	violations      int
+	violationsSince time.Time
+}
+
+// allowAction - reports whether the connection may send one more message with the action.
+// Actions without a configured limit are only limited by the buckets of the connection and its IP.
+func (that *sessionLimit) allowAction(action string, limits map[string]config.Bucket, now time.Time) bool {
+	limit, ok := limits[action]
+	if !ok {
+		return true
+	}
+
+	if that.actions == nil {
+		that.actions = make(map[string]*tokenBucket)
+	}
+
+	bucket, ok := that.actions[action]
+	if !ok {
+		bucket = newTokenBucket(limit.Rate, limit.Burst, now)
+		that.actions[action] = bucket
+	}
+
+	return bucket.allow(now)
+}
+
+// violate - records a limited message and reports whether the session has reached maxViolations within the window.
+func (that *sessionLimit) violate(now time.Time, maxViolations int, window time.Duration) bool {
+	if now.Sub(that.violationsSince) > window {
+		that.violations = 0
+		that.violationsSince = now
+	}
+	that.violations++
+
+	return that.violations >= maxViolations
+}
+
+// checkRateLimit - reports whether the message of the session may be processed.
+// A session that keeps exceeding the limits gets a CloseError with the policy violation code.
+func (that *Server) checkRateLimit(session *Session, action string) (bool, error) {
+	if !that.config.RateLimit.Enabled {
+		return true, nil
+	}
+
+	now := time.Now()
+	conf := that.config.RateLimit
+
+	// the buckets of the session are taken first so a flooding connection does not drain the bucket of its IP neighbours
+	if session.limit.bucket.allow(now) && session.limit.allowAction(action, conf.Actions, now) && that.limiter.allow(session.limit.ip, now) {
+		return true, nil
+	}
+
+	if session.limit.violate(now, conf.MaxViolations, conf.ViolationWindow) {
+		return false, newCloseError(closePolicyViolation, ErrRateLimitExceeded)
+	}
+
+	return false, nil
+}
+
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
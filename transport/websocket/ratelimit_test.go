@@ -0,0 +1,450 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
+)
+
+// testRateLimit - limits small enough for a test to exceed them, the buckets do not refill on their own.
+func testRateLimit() config.RateLimit {
+	return config.RateLimit{
+		Enabled:         true,
+		SessionBurst:    2,
+		IPBurst:         100,
+		MaxViolations:   3,
+		ViolationWindow: time.Minute,
+	}
+}
+
+// readErrorCode - reads a version 1 JSON error response and returns its error code.
+func readErrorCode(t *testing.T, client *testClient) ErrorCode {
+	t.Helper()
+
+	f := client.readFrame()
+	require.Equal(t, opText, f.opCode)
+
+	var message Message
+	require.NoError(t, json.Unmarshal(f.payload, &message))
+	require.Equal(t, messageTypeError, message.Type)
+
+	var payload struct {
+		Code ErrorCode `json:"error_code"`
+	}
+	require.NoError(t, json.Unmarshal(message.Payload, &payload))
+
+	return payload.Code
+}
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+
+	t.Run("Allows the burst and refuses the next message", func(t *testing.T) {
+		// Given: a full bucket of three tokens
+		bucket := newTokenBucket(1, 3, start)
+
+		// When: taking four tokens at once
+		var allowed []bool
+		for range 4 {
+			allowed = append(allowed, bucket.allow(start))
+		}
+
+		// Then: the burst should be allowed and the fourth message refused
+		assert.Equal(t, []bool{true, true, true, false}, allowed)
+	})
+
+	t.Run("Refills at the rate", func(t *testing.T) {
+		// Given: an empty bucket that refills two tokens per second
+		bucket := newTokenBucket(2, 3, start)
+		for range 3 {
+			require.True(t, bucket.allow(start))
+		}
+
+		// When: half a second passes
+		now := start.Add(500 * time.Millisecond)
+
+		// Then: a single token should be back
+		assert.True(t, bucket.allow(now))
+		assert.False(t, bucket.allow(now))
+	})
+
+	t.Run("Does not refill above the burst", func(t *testing.T) {
+		// Given: a bucket left alone for an hour
+		bucket := newTokenBucket(10, 2, start)
+		now := start.Add(time.Hour)
+
+		// When: taking tokens
+		first, second, third := bucket.allow(now), bucket.allow(now), bucket.allow(now)
+
+		// Then: no more than the burst should be allowed
+		assert.True(t, first)
+		assert.True(t, second)
+		assert.False(t, third)
+	})
+
+	t.Run("Reports a refilled bucket as full", func(t *testing.T) {
+		// Given: a bucket that gave away a token
+		bucket := newTokenBucket(1, 2, start)
+		require.True(t, bucket.allow(start))
+
+		// Then: it should be full only once the token is back
+		assert.False(t, bucket.full(start))
+		assert.True(t, bucket.full(start.Add(time.Second)))
+	})
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	now := time.Now()
+
+	conf := config.RateLimit{IPBurst: 3}
+
+	t.Run("Shares the IP bucket between connections", func(t *testing.T) {
+		// Given: a limiter with an IP burst of three
+		limiter := newRateLimiter(testLogger(), conf)
+
+		// When: the IP sends three messages and one more
+		require.True(t, limiter.allow("203.0.113.1", now))
+		require.True(t, limiter.allow("203.0.113.1", now))
+		require.True(t, limiter.allow("203.0.113.1", now))
+
+		// Then: the fourth message should be refused
+		assert.False(t, limiter.allow("203.0.113.1", now))
+	})
+
+	t.Run("Keeps the buckets of IPs apart", func(t *testing.T) {
+		// Given: an IP that used up its bucket
+		limiter := newRateLimiter(testLogger(), conf)
+		for range 3 {
+			require.True(t, limiter.allow("203.0.113.1", now))
+		}
+
+		// When: another IP sends a message
+		allowed := limiter.allow("203.0.113.2", now)
+
+		// Then: it should be allowed
+		assert.True(t, allowed)
+	})
+}
+
+func TestRateLimiter_Cleanup(t *testing.T) {
+	// Given: two IPs, one of them quiet for long enough to refill its bucket
+	now := time.Now()
+	limiter := newRateLimiter(testLogger(), config.RateLimit{IPRate: 1, IPBurst: 1})
+	require.True(t, limiter.allow("203.0.113.1", now))
+	require.True(t, limiter.allow("203.0.113.2", now.Add(5*time.Second)))
+
+	// When: cleaning up
+	limiter.cleanup(now.Add(5 * time.Second))
+
+	// Then: only the bucket of the IP that is still limited should be kept
+	assert.NotContains(t, limiter.ips, "203.0.113.1")
+	assert.Contains(t, limiter.ips, "203.0.113.2")
+}
+
+func TestSessionLimit_AllowAction(t *testing.T) {
+	now := time.Now()
+	limits := map[string]config.Bucket{"connect": {Burst: 1}}
+
+	t.Run("Limits an action with its own bucket", func(t *testing.T) {
+		// Given: a session that used up its single connect
+		var limit sessionLimit
+		require.True(t, limit.allowAction("connect", limits, now))
+
+		// When: it connects again
+		allowed := limit.allowAction("connect", limits, now)
+
+		// Then: the connect should be refused while other actions are still allowed
+		assert.False(t, allowed)
+		assert.True(t, limit.allowAction("game:turn", limits, now))
+	})
+
+	t.Run("Keeps the buckets of sessions apart", func(t *testing.T) {
+		// Given: a session that used up its single connect
+		var first, second sessionLimit
+		require.True(t, first.allowAction("connect", limits, now))
+
+		// When: another session connects
+		allowed := second.allowAction("connect", limits, now)
+
+		// Then: it should be allowed
+		assert.True(t, allowed)
+	})
+}
+
+func TestSessionLimit_Violate(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Reports the violation that reaches the maximum", func(t *testing.T) {
+		// Given: a session without violations
+		var limit sessionLimit
+
+		// When: it violates the limits three times within the window
+		first := limit.violate(now, 3, time.Minute)
+		second := limit.violate(now.Add(time.Second), 3, time.Minute)
+		third := limit.violate(now.Add(2*time.Second), 3, time.Minute)
+
+		// Then: only the third violation should be reported
+		assert.False(t, first)
+		assert.False(t, second)
+		assert.True(t, third)
+	})
+
+	t.Run("Starts counting again after the window", func(t *testing.T) {
+		// Given: a session with two violations
+		var limit sessionLimit
+		limit.violate(now, 3, time.Minute)
+		limit.violate(now, 3, time.Minute)
+
+		// When: it violates the limits again after the window has passed
+		reached := limit.violate(now.Add(2*time.Minute), 3, time.Minute)
+
+		// Then: the count should start over
+		assert.False(t, reached)
+		assert.Equal(t, 1, limit.violations)
+	})
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	// When: parsing addresses, ranges and garbage
+	prefixes, err := parseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.7 ", "::ffff:198.51.100.1", "proxy.local", "10.0.0.0/99"})
+
+	// Then: the valid entries should be kept and the invalid ones reported
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `"proxy.local"`)
+	assert.ErrorContains(t, err, `"10.0.0.0/99"`)
+	require.Len(t, prefixes, 3)
+	assert.Equal(t, "10.0.0.0/8", prefixes[0].String())
+	assert.Equal(t, "192.0.2.7/32", prefixes[1].String())
+	assert.Equal(t, "198.51.100.1/32", prefixes[2].String())
+}
+
+func TestRateLimiter_ClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		header     map[string][]string
+		want       string
+	}{
+		{
+			name:       "No trusted proxies",
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXForwardedFor: {"203.0.113.1"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "Untrusted peer forging the header",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "198.51.100.9:5000",
+			header:     map[string][]string{headerXForwardedFor: {"203.0.113.1"}, headerXRealIP: {"203.0.113.2"}},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "Trusted proxy",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXForwardedFor: {"203.0.113.1"}},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "Client forging the leftmost hop",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXForwardedFor: {"192.0.2.1, 203.0.113.1"}},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "Chain of trusted proxies",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXForwardedFor: {"203.0.113.1, 10.0.0.3", "10.0.0.2"}},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "Only trusted proxies in the chain",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXForwardedFor: {"10.0.0.3, 10.0.0.2"}},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "Malformed hop",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXForwardedFor: {"203.0.113.1, unknown"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "X-Real-IP",
+			trusted:    []string{"10.0.0.1"},
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXRealIP: {"203.0.113.1"}},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "X-Forwarded-For over X-Real-IP",
+			trusted:    []string{"10.0.0.1"},
+			remoteAddr: "10.0.0.1:5000",
+			header:     map[string][]string{headerXForwardedFor: {"203.0.113.1"}, headerXRealIP: {"203.0.113.2"}},
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "Trusted proxy without headers",
+			trusted:    []string{"10.0.0.1"},
+			remoteAddr: "10.0.0.1:5000",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "IPv6",
+			trusted:    []string{"fd00::/8"},
+			remoteAddr: "[fd00::1]:5000",
+			header:     map[string][]string{headerXForwardedFor: {"2001:db8::1"}},
+			want:       "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: a limiter that trusts the proxies and a request from the peer
+			limiter := newRateLimiter(testLogger(), config.RateLimit{TrustedProxies: tt.trusted})
+
+			request, err := http.NewRequest(http.MethodGet, "/ws", nil)
+			require.NoError(t, err)
+			request.RemoteAddr = tt.remoteAddr
+			for name, values := range tt.header {
+				for _, value := range values {
+					request.Header.Add(name, value)
+				}
+			}
+
+			// When: taking the client IP of the request
+			ip := limiter.clientIP(request)
+
+			// Then: it should be the rightmost address a trusted proxy vouches for
+			assert.Equal(t, tt.want, ip)
+		})
+	}
+}
+
+func TestServer_RateLimits(t *testing.T) {
+	t.Run("Answers a limited message with an error", func(t *testing.T) {
+		// Given: a client with a session burst of two
+		conf := testConfig()
+		conf.RateLimit = testRateLimit()
+		_, httpServer := newTestServer(t, conf, nil)
+		client, _ := dialTestServer(t, httpServer, http.Header{})
+
+		// When: it sends three messages at once
+		for range 3 {
+			client.writeText(`{"action":"game:unknown"}`)
+		}
+
+		// Then: the first two should be handled and the third refused
+		assert.Equal(t, CodeUnknownAction, readErrorCode(t, client))
+		assert.Equal(t, CodeUnknownAction, readErrorCode(t, client))
+		assert.Equal(t, CodeRateLimited, readErrorCode(t, client))
+	})
+
+	t.Run("Closes the connection that keeps exceeding the limits", func(t *testing.T) {
+		// Given: a client that may exceed the limits three times
+		conf := testConfig()
+		conf.RateLimit = testRateLimit()
+		_, httpServer := newTestServer(t, conf, nil)
+		client, _ := dialTestServer(t, httpServer, http.Header{})
+
+		// When: it sends five messages at once
+		for range 5 {
+			client.writeText(`{"action":"game:unknown"}`)
+		}
+
+		// Then: the server should close the connection with a policy violation
+		assert.Equal(t, closePolicyViolation, client.readClose())
+	})
+
+	t.Run("Counts malformed messages", func(t *testing.T) {
+		// Given: a client that may exceed the limits three times
+		conf := testConfig()
+		conf.RateLimit = testRateLimit()
+		_, httpServer := newTestServer(t, conf, nil)
+		client, _ := dialTestServer(t, httpServer, http.Header{})
+
+		// When: it sends five messages the server cannot decode
+		for range 5 {
+			client.writeText("not json")
+		}
+
+		// Then: the server should close the connection with a policy violation
+		assert.Equal(t, closePolicyViolation, client.readClose())
+	})
+
+	t.Run("Does not limit when disabled", func(t *testing.T) {
+		// Given: a client of a server without rate limits
+		_, httpServer := newTestServer(t, testConfig(), nil)
+		client, _ := dialTestServer(t, httpServer, http.Header{})
+
+		// When: it sends more messages than any burst
+		for range 5 {
+			client.writeText(`{"action":"game:unknown"}`)
+		}
+
+		// Then: every message should be handled
+		for range 5 {
+			assert.Equal(t, CodeUnknownAction, readErrorCode(t, client))
+		}
+	})
+}
+
+func TestServer_RateLimitsActionsPerSession(t *testing.T) {
+	// Given: a server that allows a single message of the action per session, two clients share the local IP
+	conf := testConfig()
+	conf.RateLimit = testRateLimit()
+	conf.RateLimit.SessionBurst = 100
+	conf.RateLimit.Actions = map[string]config.Bucket{"game:unknown": {Burst: 1}}
+	_, httpServer := newTestServer(t, conf, nil)
+
+	first, _ := dialTestServer(t, httpServer, http.Header{})
+	second, _ := dialTestServer(t, httpServer, http.Header{})
+
+	// When: the first client uses up the bucket of the action and the second one sends the action
+	for range 2 {
+		first.writeText(`{"action":"game:unknown"}`)
+	}
+	assert.Equal(t, CodeUnknownAction, readErrorCode(t, first))
+	assert.Equal(t, CodeRateLimited, readErrorCode(t, first))
+
+	second.writeText(`{"action":"game:unknown"}`)
+
+	// Then: the second client should have a bucket of its own
+	assert.Equal(t, CodeUnknownAction, readErrorCode(t, second))
+}
+
+func TestServer_RateLimitsClientsBehindTrustedProxy(t *testing.T) {
+	// Given: a server behind a trusted local proxy that allows two messages per client IP
+	conf := testConfig()
+	conf.RateLimit = testRateLimit()
+	conf.RateLimit.SessionBurst = 100
+	conf.RateLimit.IPBurst = 2
+	conf.RateLimit.TrustedProxies = []string{"127.0.0.0/8", "::1"}
+	_, httpServer := newTestServer(t, conf, nil)
+
+	first, _ := dialTestServer(t, httpServer, http.Header{headerXForwardedFor: {"203.0.113.1"}})
+	second, _ := dialTestServer(t, httpServer, http.Header{headerXForwardedFor: {"203.0.113.2"}})
+
+	// When: the first client uses up the bucket of its IP and the second one sends a message
+	for range 3 {
+		first.writeText(`{"action":"game:unknown"}`)
+	}
+	second.writeText(`{"action":"game:unknown"}`)
+
+	// Then: only the first client should be limited, the proxy address is not shared between them
+	assert.Equal(t, CodeUnknownAction, readErrorCode(t, first))
+	assert.Equal(t, CodeUnknownAction, readErrorCode(t, first))
+	assert.Equal(t, CodeRateLimited, readErrorCode(t, first))
+	assert.Equal(t, CodeUnknownAction, readErrorCode(t, second))
+}
@@ -0,0 +1,249 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+const (
+	actionGameReplay = "game:replay"
+
+	replayCommandStart = "start"
+	replayCommandPlay  = "play"
+	replayCommandPause = "pause"
+	replayCommandSeek  = "seek"
+	replayCommandSpeed = "speed"
+	replayCommandStop  = "stop"
+
+	// replayInterval - the time between two positions of a replay played at speed 1.
+	replayInterval = time.Second
+	replayMinSpeed = 0.25
+	replayMaxSpeed = 8
+)
+
+// Replay - the replay of a finished game. The request carries the command and what it needs: start the GameID
+// and an optional Speed, seek the Position, speed the Speed. The server answers and pushes every next position
+// with the state of the replay, Position counts the moves made on the board and Moves the moves of the game.
+type Replay struct {
+	GameID   string  `json:"game_id,omitempty"`
+	Command  string  `json:"command,omitempty"`
+	Position *int    `json:"position,omitempty"`
+	Moves    int     `json:"moves,omitempty"`
+	Speed    float64 `json:"speed,omitempty"`
+	Playing  bool    `json:"playing"`
+}
+
+// gameReplay - the replay a session watches. While it plays, the server moves it one position forward every
+// interval divided by the speed. The generation tells the timer of the current schedule from the stale ones.
+type gameReplay struct {
+	mu sync.Mutex
+
+	gameID     string
+	positions  []*entity.Game
+	position   int
+	speed      float64
+	playing    bool
+	timer      *time.Timer
+	generation int
+}
+
+// handleGameReplay - starts the replay of a finished game or controls the replay the session watches.
+func (that *Server) handleGameReplay(ctx context.Context, msg *Message, session *Session) error {
+	log := that.logger.With("method", "handleGameReplay")
+
+	var payloadReq Payload
+
+	if err := json.Unmarshal(msg.Payload, &payloadReq); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	request := payloadReq.Replay
+	if request == nil {
+		log.Error("Replay is missing in payload")
+		return that.sendErrorResponse(session, msg, CodeReplayRequired)
+	}
+
+	if request.Command == replayCommandStart || (request.Command == "" && request.GameID != "") {
+		return that.startReplay(ctx, msg, session, request)
+	}
+
+	replay := session.currentReplay()
+	if replay == nil {
+		return that.sendErrorResponse(session, msg, CodeNoReplay)
+	}
+
+	if request.Command == replayCommandStop {
+		session.swapReplay(nil)
+		replay.stop()
+
+		return that.reply(session, msg, Payload{Replay: &Replay{GameID: replay.gameID}})
+	}
+
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+
+	if !replay.apply(request) {
+		log.Error("invalid replay command", "command", request.Command)
+		return that.sendErrorResponse(session, msg, CodeInvalidReplay)
+	}
+
+	that.scheduleReplay(session, replay)
+
+	return that.reply(session, msg, replay.payload())
+}
+
+// startReplay - loads the finished game, rebuilds its positions and starts playing them from the empty board.
+// Players replay only the games they played in. A replay the session was watching is stopped.
+func (that *Server) startReplay(ctx context.Context, msg *Message, session *Session, request *Replay) error {
+	log := that.logger.With("method", "startReplay", "gameID", request.GameID)
+
+	speed := request.Speed
+	if speed == 0 {
+		speed = 1
+	}
+
+	if !validReplaySpeed(speed) {
+		return that.sendErrorResponse(session, msg, CodeInvalidReplay)
+	}
+
+	archived, err := that.gameUseCase.GetArchivedGame(ctx, session.PlayerID(), request.GameID)
+	if err != nil {
+		log.Error("failed to get archived game", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
+	}
+
+	positions, err := archived.Positions()
+	if err != nil {
+		log.Error("failed to replay game", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
+	}
+
+	replay := &gameReplay{gameID: archived.ID, positions: positions, speed: speed, playing: true}
+	if previous := session.swapReplay(replay); previous != nil {
+		previous.stop()
+	}
+
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+
+	that.scheduleReplay(session, replay)
+
+	return that.reply(session, msg, replay.payload())
+}
+
+// scheduleReplay - schedules the next position of the replay while it plays, the previous schedule is dropped.
+// The caller holds the lock of the replay.
+func (that *Server) scheduleReplay(session *Session, replay *gameReplay) {
+	replay.generation++
+	if replay.timer != nil {
+		replay.timer.Stop()
+		replay.timer = nil
+	}
+
+	if replay.position >= replay.last() {
+		replay.playing = false
+	}
+
+	if !replay.playing {
+		return
+	}
+
+	generation := replay.generation
+	interval := time.Duration(float64(replayInterval) / replay.speed)
+
+	replay.timer = time.AfterFunc(interval, func() {
+		that.advanceReplay(session, replay, generation)
+	})
+}
+
+// advanceReplay - moves the replay one position forward and pushes the position to the session.
+// The position is sent under the lock, so it never overtakes the answer to a later command.
+func (that *Server) advanceReplay(session *Session, replay *gameReplay, generation int) {
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+
+	if replay.generation != generation || !replay.playing {
+		return
+	}
+
+	replay.position++
+	that.scheduleReplay(session, replay)
+
+	if err := that.sendEvent(session, actionGameReplay, replay.payload()); err != nil {
+		that.logger.Error("failed to send replay position", "method", "advanceReplay", "error", err)
+		replay.playing = false
+	}
+}
+
+// apply - applies the control command to the replay and reports whether the command was valid.
+// The caller holds the lock of the replay.
+func (that *gameReplay) apply(request *Replay) bool {
+	switch request.Command {
+	case replayCommandPlay:
+		// playing a replay that has come to its end plays it again
+		if that.position >= that.last() {
+			that.position = 0
+		}
+		that.playing = true
+	case replayCommandPause:
+		that.playing = false
+	case replayCommandSeek:
+		if request.Position == nil {
+			return false
+		}
+		that.position = min(max(*request.Position, 0), that.last())
+	case replayCommandSpeed:
+		if !validReplaySpeed(request.Speed) {
+			return false
+		}
+		that.speed = request.Speed
+	default:
+		return false
+	}
+
+	return true
+}
+
+// validReplaySpeed - whether the replay can play at the speed, NaN fails every comparison and is refused with the rest.
+func validReplaySpeed(speed float64) bool {
+	return speed >= replayMinSpeed && speed <= replayMaxSpeed
+}
+
+// stop - stops the replay for good.
+func (that *gameReplay) stop() {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	that.playing = false
+	that.generation++
+	if that.timer != nil {
+		that.timer.Stop()
+	}
+}
+
+// last - returns the position after the last move.
+func (that *gameReplay) last() int {
+	return len(that.positions) - 1
+}
+
+// payload - returns the state of the replay with its current position, the caller holds the lock of the replay.
+func (that *gameReplay) payload() Payload {
+	position := that.position
+	game := *that.positions[position]
+
+	return Payload{
+		Game: maskGameDetails(&game),
+		Replay: &Replay{
+			GameID:   that.gameID,
+			Position: &position,
+			Moves:    that.last(),
+			Speed:    that.speed,
+			Playing:  that.playing,
+		},
+	}
+}
@@ -0,0 +1,371 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+// testArchivedGame - a classic game of p1 as X won in five moves, as the archive keeps it.
+func testArchivedGame(t *testing.T) *entity.ArchivedGame {
+	t.Helper()
+
+	game := entity.NewGame("replay-game", entity.PrivateType)
+	game.Players = []*entity.Player{
+		{ID: "p1", PublicID: "pub1", GameID: game.ID, Mark: entity.PlayerX},
+		{ID: "p2", PublicID: "pub2", GameID: game.ID, Mark: entity.PlayerO},
+	}
+	game.Start()
+
+	for _, cell := range []int{0, 3, 1, 4, 2} {
+		require.NoError(t, game.MakeTurn(game.Turn, cell))
+	}
+
+	return game.Archive(time.Now())
+}
+
+// replayMessage - the replay request with the raw replay payload.
+func replayMessage(id, replay string) *Message {
+	return &Message{ID: id, Action: actionGameReplay, Payload: json.RawMessage(`{"replay":` + replay + `}`)}
+}
+
+// replayServer - a server that replays the archived game to its players, the games of others are not found.
+func replayServer(t *testing.T) (*Server, *Session, *testClient) {
+	t.Helper()
+
+	archived := testArchivedGame(t)
+	uc := &stubGameUseCase{
+		getArchivedGame: func(_ context.Context, playerID, gameID string) (*entity.ArchivedGame, error) {
+			if playerID != "p1" || gameID != archived.ID {
+				return nil, apperror.ErrGameNotFound
+			}
+
+			return archived, nil
+		},
+	}
+	server, _ := newTestServer(t, testConfig(), uc)
+	session, client := boundSession(t, server, "p1")
+
+	return server, session, client
+}
+
+// readReplay - reads the next message and returns it with its replay state.
+func readReplay(t *testing.T, client *testClient) (Message, map[string]any) {
+	t.Helper()
+
+	message, payload := client.readMessage()
+	require.Contains(t, payload, "replay")
+
+	return message, payload["replay"].(map[string]any)
+}
+
+// replayPosition - returns the position of the replay the session watches.
+func replayPosition(session *Session) int {
+	replay := session.currentReplay()
+
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+
+	return replay.position
+}
+
+func TestServer_HandleGameReplay_Start(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Plays the game from the empty board", func(t *testing.T) {
+		// Given: a player of the archived game
+		server, session, client := replayServer(t)
+
+		// When: the player starts its replay at the top speed
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"start","game_id":"replay-game","speed":8}`), session))
+
+		// Then: the reply should hold the empty board and the replay should play
+		reply, replay := readReplay(t, client)
+		assert.Equal(t, messageTypeResponse, reply.Type)
+		assert.Equal(t, "1", reply.ID)
+		assert.InDelta(t, 0, replay["position"], 0)
+		assert.InDelta(t, 5, replay["moves"], 0)
+		assert.InDelta(t, 8, replay["speed"], 0)
+		assert.Equal(t, true, replay["playing"])
+
+		// Then: the next position should come as an event with the first move on the board
+		event, payload := client.readMessage()
+		assert.Equal(t, messageTypeEvent, event.Type)
+		assert.Equal(t, actionGameReplay, event.Action)
+		assert.InDelta(t, 1, payload["replay"].(map[string]any)["position"], 0)
+		assert.Equal(t, entity.PlayerX, payload["game"].(map[string]any)["board"].([]any)[0])
+		assert.NotContains(t, payload["game"], "players")
+	})
+
+	t.Run("Refuses the game of other players", func(t *testing.T) {
+		// Given: a player who did not play in the game
+		server, _, _ := replayServer(t)
+		session, client := boundSession(t, server, "p3")
+
+		// When: the player starts the replay of the guessed game
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"start","game_id":"replay-game"}`), session))
+
+		// Then: the game should not be found and no replay should start
+		assert.Equal(t, CodeGameNotFound, readErrorCode(t, client))
+		assert.Nil(t, session.currentReplay())
+	})
+
+	t.Run("Refuses a speed out of range", func(t *testing.T) {
+		// Given: a player of the archived game
+		server, session, client := replayServer(t)
+
+		// When: the player starts the replay too fast
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"start","game_id":"replay-game","speed":16}`), session))
+
+		// Then: the replay should be refused
+		assert.Equal(t, CodeInvalidReplay, readErrorCode(t, client))
+		assert.Nil(t, session.currentReplay())
+	})
+
+	t.Run("Refuses a speed that is not a number", func(t *testing.T) {
+		for _, speed := range []float64{math.NaN(), math.Inf(1)} {
+			// Given: a player of the archived game
+			server, session, client := replayServer(t)
+
+			// When: the player starts the replay at the speed, JSON can not carry it so the request is made here
+			request := &Replay{Command: replayCommandStart, GameID: "replay-game", Speed: speed}
+			require.NoError(t, server.startReplay(ctx, replayMessage("1", `{}`), session, request))
+
+			// Then: the replay should be refused
+			assert.Equal(t, CodeInvalidReplay, readErrorCode(t, client), "speed %v", speed)
+			assert.Nil(t, session.currentReplay(), "speed %v", speed)
+		}
+	})
+
+	t.Run("Asks for the replay", func(t *testing.T) {
+		// Given: a player of the archived game
+		server, session, client := replayServer(t)
+
+		// When: the request has no replay
+		require.NoError(t, server.handleGameReplay(ctx, &Message{ID: "1", Action: actionGameReplay, Payload: json.RawMessage(`{}`)}, session))
+
+		// Then: the replay should be required
+		assert.Equal(t, CodeReplayRequired, readErrorCode(t, client))
+	})
+}
+
+func TestServer_HandleGameReplay_Controls(t *testing.T) {
+	ctx := context.Background()
+
+	// start - starts the slowest replay, no position comes on its own before the test is over
+	start := func(t *testing.T) (*Server, *Session, *testClient) {
+		t.Helper()
+
+		server, session, client := replayServer(t)
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("0", `{"command":"start","game_id":"replay-game","speed":0.25}`), session))
+		readReplay(t, client)
+
+		return server, session, client
+	}
+
+	t.Run("Pause stops the replay at its position", func(t *testing.T) {
+		// Given: a playing replay
+		server, session, client := start(t)
+
+		// When: the player pauses it and speeds it up
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"pause"}`), session))
+		_, replay := readReplay(t, client)
+		assert.Equal(t, false, replay["playing"])
+
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("2", `{"command":"speed","speed":8}`), session))
+		_, replay = readReplay(t, client)
+
+		// Then: the replay should keep the speed and stay at its position
+		assert.InDelta(t, 8, replay["speed"], 0)
+		assert.Equal(t, false, replay["playing"])
+		assert.Never(t, func() bool { return replayPosition(session) != 0 }, 300*time.Millisecond, 10*time.Millisecond)
+	})
+
+	t.Run("Seek moves to the position within the game", func(t *testing.T) {
+		// Given: a playing replay
+		server, session, client := start(t)
+
+		// When: the player seeks the third position
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"seek","position":3}`), session))
+
+		// Then: the reply should hold the board after three moves
+		_, payload := client.readMessage()
+		assert.InDelta(t, 3, payload["replay"].(map[string]any)["position"], 0)
+		assert.Equal(t, []any{"X", "X", "", "O", "", "", "", "", ""}, payload["game"].(map[string]any)["board"])
+
+		// When: the player seeks past both ends of the game
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("2", `{"command":"seek","position":42}`), session))
+		_, replay := readReplay(t, client)
+		assert.InDelta(t, 5, replay["position"], 0)
+		assert.Equal(t, false, replay["playing"], "the replay stops at the end of the game")
+
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("3", `{"command":"seek","position":-1}`), session))
+		_, replay = readReplay(t, client)
+		assert.InDelta(t, 0, replay["position"], 0)
+
+		// When: the player seeks without the position
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("4", `{"command":"seek"}`), session))
+
+		// Then: the command should be refused
+		assert.Equal(t, CodeInvalidReplay, readErrorCode(t, client))
+	})
+
+	t.Run("Speed changes within its range only", func(t *testing.T) {
+		// Given: a playing replay
+		server, session, client := start(t)
+
+		// When: the player doubles the speed
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"speed","speed":2}`), session))
+
+		// Then: the replay should play at the new speed
+		_, replay := readReplay(t, client)
+		assert.InDelta(t, 2, replay["speed"], 0)
+
+		// When: the player asks for a speed out of range
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("2", `{"command":"speed","speed":0.1}`), session))
+
+		// Then: the command should be refused and the speed kept
+		assert.Equal(t, CodeInvalidReplay, readErrorCode(t, client))
+		assert.InDelta(t, 2, session.currentReplay().speed, 0)
+	})
+
+	t.Run("Speed that is not a number is refused", func(t *testing.T) {
+		// Given: a playing replay
+		_, session, _ := start(t)
+		replay := session.currentReplay()
+
+		replay.mu.Lock()
+		defer replay.mu.Unlock()
+
+		for _, speed := range []float64{math.NaN(), math.Inf(1)} {
+			// When: the speed command carries the speed, JSON can not carry it so the command is applied here
+			applied := replay.apply(&Replay{Command: replayCommandSpeed, Speed: speed})
+
+			// Then: the command should be refused and the speed kept
+			assert.False(t, applied, "speed %v", speed)
+			assert.InDelta(t, 0.25, replay.speed, 0, "speed %v", speed)
+		}
+	})
+
+	t.Run("Play at the end plays the game again", func(t *testing.T) {
+		// Given: a replay at its end
+		server, session, client := start(t)
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"seek","position":5}`), session))
+		readReplay(t, client)
+
+		// When: the player plays it
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("2", `{"command":"play"}`), session))
+
+		// Then: the replay should play from the empty board
+		_, replay := readReplay(t, client)
+		assert.InDelta(t, 0, replay["position"], 0)
+		assert.Equal(t, true, replay["playing"])
+	})
+
+	t.Run("Stop ends the replay", func(t *testing.T) {
+		// Given: a playing replay
+		server, session, client := start(t)
+		replay := session.currentReplay()
+
+		// When: the player stops it
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"stop"}`), session))
+
+		// Then: the session should watch no replay any more
+		_, stopped := readReplay(t, client)
+		assert.Equal(t, "replay-game", stopped["game_id"])
+		assert.Equal(t, false, stopped["playing"])
+		assert.Nil(t, session.currentReplay())
+		assert.False(t, replay.playing)
+
+		// When: the player controls the stopped replay
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("2", `{"command":"play"}`), session))
+
+		// Then: there should be no replay to control
+		assert.Equal(t, CodeNoReplay, readErrorCode(t, client))
+	})
+
+	t.Run("Unknown command is refused", func(t *testing.T) {
+		// Given: a playing replay
+		server, session, client := start(t)
+
+		// When: the player sends a command the replay does not know
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"rewind"}`), session))
+
+		// Then: the command should be refused
+		assert.Equal(t, CodeInvalidReplay, readErrorCode(t, client))
+	})
+}
+
+func TestServer_AdvanceReplay_Generation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Stale schedule does not move the replay", func(t *testing.T) {
+		// Given: a replay rescheduled after the timer of its start was set
+		server, session, client := replayServer(t)
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"start","game_id":"replay-game","speed":0.25}`), session))
+		readReplay(t, client)
+
+		replay := session.currentReplay()
+		replay.mu.Lock()
+		stale := replay.generation
+		replay.mu.Unlock()
+
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("2", `{"command":"speed","speed":0.5}`), session))
+		readReplay(t, client)
+
+		// When: the timer of the start fires
+		server.advanceReplay(session, replay, stale)
+
+		// Then: the replay should stay at its position
+		assert.Equal(t, 0, replayPosition(session))
+	})
+
+	t.Run("Stopped replay does not move", func(t *testing.T) {
+		// Given: a replay stopped after its timer was set
+		server, session, client := replayServer(t)
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"start","game_id":"replay-game","speed":0.25}`), session))
+		readReplay(t, client)
+
+		replay := session.currentReplay()
+		replay.mu.Lock()
+		generation := replay.generation
+		replay.mu.Unlock()
+
+		replay.stop()
+
+		// When: the timer fires
+		server.advanceReplay(session, replay, generation)
+
+		// Then: the replay should stay at its position
+		replay.mu.Lock()
+		defer replay.mu.Unlock()
+		assert.Equal(t, 0, replay.position)
+	})
+
+	t.Run("Current schedule moves the replay", func(t *testing.T) {
+		// Given: a playing replay
+		server, session, client := replayServer(t)
+		require.NoError(t, server.handleGameReplay(ctx, replayMessage("1", `{"command":"start","game_id":"replay-game","speed":0.25}`), session))
+		readReplay(t, client)
+
+		replay := session.currentReplay()
+		replay.mu.Lock()
+		generation := replay.generation
+		replay.mu.Unlock()
+
+		// When: its timer fires
+		go server.advanceReplay(session, replay, generation)
+
+		// Then: the player should get the next position
+		_, moved := readReplay(t, client)
+		assert.InDelta(t, 1, moved["position"], 0)
+	})
+}
@@ -1,11 +1,9 @@
 package websocket
 
 import (
-	"bufio"
 	"context"
 	"crypto/sha1" //nolint: gosec // idk how to fix that
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"io"
 	"log/slog"
@@ -13,7 +11,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/rocketscienceinc/tictactoe-backend/internal/config"
 	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/i18n"
 )
 
 // Static GUID defined in RFC 6455 for WebSocket.
@@ -26,6 +26,16 @@ const (
 	headerSecWebSocketKey    = "Sec-WebSocket-Key"
 	headerSecWebSocketAccept = "Sec-WebSocket-Accept"
 
+	headerSecWebSocketVersion    = "Sec-WebSocket-Version"
+	headerSecWebSocketExtensions = "Sec-WebSocket-Extensions"
+	headerSecWebSocketProtocol   = "Sec-WebSocket-Protocol"
+	headerOrigin                 = "Origin"
+	headerAcceptLanguage         = "Accept-Language"
+
+	actionConnect     = "connect"
+	actionAuthRefresh = "auth:refresh"
+	actionGameTurn    = "game:turn"
+
 	checkInterval     = 500 * time.Millisecond
 	disconnectTimeout = 10 * time.Second
 )
@@ -33,14 +43,27 @@ const (
 type gameUseCase interface {
 	GetOrCreatePlayer(ctx context.Context, playerID string) (*entity.Player, error)
 
-	GetOrCreateGame(ctx context.Context, playerID, gameType, difficulty string) (*entity.Game, error)
+	GetOrCreateGame(
+		ctx context.Context, playerID, gameType, difficulty string, strength int, settings entity.GameSettings,
+	) (*entity.Game, error)
 	GetGameByPlayerID(ctx context.Context, playerID string) (*entity.Game, error)
-	CreateOrJoinToPublicGame(ctx context.Context, playerID, gameType string) (*entity.Game, error)
-	CreatePrivateGameWithTwoPlayers(ctx context.Context, player1, player2 *entity.Player) (*entity.Game, error)
+	GetGameByID(ctx context.Context, gameID string) (*entity.Game, error)
+	CreateOrJoinToPublicGame(ctx context.Context, playerID, gameType string, settings entity.GameSettings) (*entity.Game, error)
+	CreatePrivateGameWithTwoPlayers(ctx context.Context, player1, player2 *entity.Player, settings entity.GameSettings) (*entity.Game, error)
 	JoinGameByID(ctx context.Context, gameID, playerID string) (*entity.Game, error)
 	EndGame(ctx context.Context, game *entity.Game) error
+	GetHistory(ctx context.Context, playerID string, offset, limit int) ([]*entity.ArchivedGame, int, error)
+	GetArchivedGame(ctx context.Context, playerID, gameID string) (*entity.ArchivedGame, error)
+	TimeOutGames(ctx context.Context, now time.Time) ([]*entity.Game, error)
+
+	MakeTurn(ctx context.Context, playerID string, cell int, piece string) (*entity.Game, error)
+	MakeBotTurn(ctx context.Context, gameID string) (*entity.Game, error)
+}
 
-	MakeTurn(ctx context.Context, playerID string, cell int) (*entity.Game, error)
+// tokenManager issues the session tokens that prove a player identity across connections.
+type tokenManager interface {
+	Issue(playerID string) (string, error)
+	Verify(token string) (string, error)
 }
 
 type RematchRequest struct {
@@ -51,38 +74,55 @@ type RematchRequest struct {
 
 type Server struct {
 	logger      *slog.Logger
+	config      config.Websocket
 	gameUseCase gameUseCase
+	tokens      tokenManager
+	limiter     *rateLimiter
 
-	messageHandlers map[string]func(ctx context.Context, message *Message, w *bufio.ReadWriter) error
+	messageHandlers map[string]func(ctx context.Context, message *Message, session *Session) error
 
-	connections         map[string]*bufio.ReadWriter
-	connectionsMutex    sync.RWMutex
+	sessions            map[string]*Session
+	sessionsMutex       sync.RWMutex
 	disconnectedPlayers map[string]time.Time
 	disconnectedMutex   sync.RWMutex
 
 	rematchRequests      map[string]*RematchRequest
 	rematchRequestsMutex sync.Mutex
+
+	// spectators - the sessions that watch each game, by the game ID.
+	spectators      map[string]map[*Session]struct{}
+	spectatorsMutex sync.RWMutex
 }
 
-func New(ctx context.Context, logger *slog.Logger, gameUseCase gameUseCase) *Server {
+func New(ctx context.Context, logger *slog.Logger, conf config.Websocket, gameUseCase gameUseCase, tokens tokenManager) *Server {
 	server := &Server{
 		logger:      logger,
+		config:      conf,
 		gameUseCase: gameUseCase,
+		tokens:      tokens,
+		limiter:     newRateLimiter(logger, conf.RateLimit),
 
-		messageHandlers:     make(map[string]func(context.Context, *Message, *bufio.ReadWriter) error),
-		connections:         make(map[string]*bufio.ReadWriter),
+		messageHandlers:     make(map[string]func(context.Context, *Message, *Session) error),
+		sessions:            make(map[string]*Session),
 		disconnectedPlayers: make(map[string]time.Time),
 		rematchRequests:     make(map[string]*RematchRequest),
+		spectators:          make(map[string]map[*Session]struct{}),
 	}
 
-	server.messageHandlers["connect"] = server.handleConnect
+	server.messageHandlers[actionConnect] = server.handleConnect
+	server.messageHandlers[actionAuthRefresh] = server.handleAuthRefresh
 	server.messageHandlers["game:new"] = server.handleNewGame
 	server.messageHandlers["game:join"] = server.handleJoinGame
-	server.messageHandlers["game:turn"] = server.handleGameTurn
+	server.messageHandlers[actionGameTurn] = server.handleGameTurn
 	server.messageHandlers["game:leave"] = server.handleGameLeave
 	server.messageHandlers["game:rematch"] = server.handleRematch
+	server.messageHandlers["game:history"] = server.handleGameHistory
+	server.messageHandlers[actionGameReplay] = server.handleGameReplay
+	server.messageHandlers[actionGameSpectate] = server.handleSpectate
 
 	go server.monitorDisconnectedPlayers(ctx)
+	go server.monitorClocks(ctx)
+	go server.limiter.run(ctx)
 
 	return server
 }
@@ -122,18 +162,40 @@ func (that *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (that *Server) upgradeToWebSocket(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	log := that.logger.With("method", "upgradeToWebSocket")
 
-	if r.Header.Get(headerUpgrade) != headerWebSocket {
-		log.Error("not upgrade to websocket")
-		http.Error(w, "not a websocket upgrade", http.StatusBadRequest)
+	if handshakeErr := that.validateHandshake(r); handshakeErr != nil {
+		log.Error("invalid websocket handshake", "error", handshakeErr, "remoteAddr", r.RemoteAddr)
+
+		switch handshakeErr.status {
+		case http.StatusUpgradeRequired:
+			w.Header().Set(headerSecWebSocketVersion, supportedWebSocketVersion)
+		case http.StatusMethodNotAllowed:
+			w.Header().Set("Allow", http.MethodGet)
+		}
+
+		http.Error(w, handshakeErr.Error(), handshakeErr.status)
 		return
 	}
 
 	wsKey := r.Header.Get(headerSecWebSocketKey)
 	acceptKey := that.generateAcceptKey(wsKey)
 
+	deflate, err := negotiateDeflate(r.Header, that.config.Compression)
+	if err != nil {
+		// compression is optional, the connection goes on without it
+		log.Error("failed to negotiate compression", "error", err)
+	}
+
+	subprotocol, protocol := negotiateSubprotocol(r.Header)
+
 	w.Header().Set(headerUpgrade, headerWebSocket)
 	w.Header().Set(headerConnection, headerUpgrade)
 	w.Header().Set(headerSecWebSocketAccept, acceptKey)
+	if deflate != nil {
+		w.Header().Set(headerSecWebSocketExtensions, deflate.params.String())
+	}
+	if subprotocol != "" {
+		w.Header().Set(headerSecWebSocketProtocol, subprotocol)
+	}
 	w.WriteHeader(http.StatusSwitchingProtocols)
 
 	hijacker, ok := w.(http.Hijacker)
@@ -150,36 +212,88 @@ func (that *Server) upgradeToWebSocket(ctx context.Context, w http.ResponseWrite
 
 	defer conn.Close()
 
-	log.Info("WebSocket connection established")
+	// the http server deadlines are still set on the hijacked connection, the reader manages its own ones
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		log.Error("failed to reset connection deadlines", "error", err)
+		return
+	}
+
+	log.Info("WebSocket connection established", "subprotocol", subprotocol, "protocolVersion", protocol.version)
 
-	if err = that.handleMessages(ctx, bufRW); err != nil {
+	client := newConnection(that.logger, that.config, conn, bufRW, deflate)
+	locale := i18n.Negotiate(i18n.ParseAcceptLanguage(r.Header.Get(headerAcceptLanguage))...)
+	session := newSession(client, conn.RemoteAddr().String(), protocol, locale)
+	session.limit.ip = that.limiter.clientIP(r)
+	session.limit.bucket = newTokenBucket(that.config.RateLimit.SessionRate, that.config.RateLimit.SessionBurst, time.Now())
+
+	err = that.handleMessages(ctx, session)
+	if !errors.Is(err, io.EOF) {
 		log.Error("error handling messages", "error", err)
 	}
 
-	if errors.Is(err, io.EOF) {
-		that.handleDisconnect(bufRW)
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		if !errors.Is(err, io.EOF) {
+			log.Warn("closing connection after protocol violation",
+				"playerID", session.PlayerID(),
+				"remoteAddr", session.RemoteAddr,
+				"code", closeErr.Code,
+				"reason", closeErr.Reason,
+			)
+		}
+
+		client.close(closeErr.Code, closeErr.Reason)
+	} else {
+		client.terminate()
 	}
+
+	client.wait()
+
+	that.handleDisconnect(ctx, session)
 }
 
 // handleMessages - processes messages from the client.
-func (that *Server) handleMessages(ctx context.Context, bufRW *bufio.ReadWriter) error {
+func (that *Server) handleMessages(ctx context.Context, session *Session) error {
 	log := that.logger.With("method", "HandleMessages")
 
 	for {
-		reqBody, err := that.readRequest(bufRW)
+		reqBody, err := that.readRequest(session)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				log.Info("Client closed the connection")
-				return io.EOF
+				return err
 			}
 
 			log.Error("Error reading message", "error", err)
 			return err
 		}
 
+		session.touch()
+
 		var message Message
-		if err = json.Unmarshal(reqBody, &message); err != nil {
-			log.Error("failed to unmarshal message", "error", err)
+		unmarshalErr := session.codec.Unmarshal(reqBody, &message)
+
+		// malformed messages are limited as well, they cost the server as much to read
+		allowed, err := that.checkRateLimit(session, message.Action)
+		if err != nil {
+			log.Warn("closing connection of abusive client",
+				"remoteAddr", session.RemoteAddr, "clientIP", session.limit.ip, "playerID", session.PlayerID())
+			return err
+		}
+
+		if unmarshalErr != nil {
+			log.Error("failed to unmarshal message", "error", unmarshalErr)
+			continue
+		}
+
+		if !allowed {
+			log.Warn("rate limit exceeded", "action", message.Action, "remoteAddr", session.RemoteAddr, "clientIP", session.limit.ip)
+
+			err = that.sendErrorResponse(session, &message, CodeRateLimited)
+			if err != nil {
+				log.Error("failed to send message", "error", err)
+			}
+
 			continue
 		}
 
@@ -187,7 +301,19 @@ func (that *Server) handleMessages(ctx context.Context, bufRW *bufio.ReadWriter)
 		if !ok {
 			log.Error("action handler not found")
 
-			err = that.sendErrorResponse(bufRW, message.Action, "action handler not found")
+			err = that.sendErrorResponse(session, &message, CodeUnknownAction)
+			if err != nil {
+				log.Error("failed to send message", "error", err)
+			}
+
+			continue
+		}
+
+		// every action but connect is performed on behalf of the player bound to the session
+		if message.Action != actionConnect && !session.IsBound() {
+			log.Error("action before connect", "action", message.Action)
+
+			err = that.sendErrorResponse(session, &message, CodeNotConnected)
 			if err != nil {
 				log.Error("failed to send message", "error", err)
 			}
@@ -195,7 +321,7 @@ func (that *Server) handleMessages(ctx context.Context, bufRW *bufio.ReadWriter)
 			continue
 		}
 
-		if err = handler(ctx, &message, bufRW); err != nil {
+		if err = handler(ctx, &message, session); err != nil {
 			log.Error("invalid handle message", "error", err)
 
 			continue
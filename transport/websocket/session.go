@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/i18n"
+)
+
+// Session is a websocket connection together with the player it is bound to.
+// A session is bound once, by the connect action, every other action is performed on behalf of that player.
+type Session struct {
+	conn  *connection
+	codec codec
+
+	RemoteAddr      string
+	ConnectedAt     time.Time
+	ProtocolVersion int
+
+	mu       sync.RWMutex
+	playerID string
+	locale   string
+	replay   *gameReplay
+	// spectating - the ID of the game the session watches, empty when it watches none.
+	spectating string
+
+	lastActivity atomic.Int64
+
+	limit sessionLimit
+}
+
+func newSession(conn *connection, remoteAddr string, protocol wireProtocol, locale string) *Session {
+	now := time.Now()
+
+	session := &Session{
+		conn:            conn,
+		codec:           protocol.codec,
+		RemoteAddr:      remoteAddr,
+		ConnectedAt:     now,
+		ProtocolVersion: protocol.version,
+		locale:          locale,
+	}
+	session.lastActivity.Store(now.UnixNano())
+
+	return session
+}
+
+// PlayerID returns the ID of the bound player, or an empty string before connect.
+func (that *Session) PlayerID() string {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+
+	return that.playerID
+}
+
+// IsBound reports whether the session has been bound to a player.
+func (that *Session) IsBound() bool {
+	return that.PlayerID() != ""
+}
+
+// LastActivity returns the time the last message was received on the session.
+func (that *Session) LastActivity() time.Time {
+	return time.Unix(0, that.lastActivity.Load())
+}
+
+func (that *Session) touch() {
+	that.lastActivity.Store(time.Now().UnixNano())
+}
+
+// bind - binds the session to the player, a session that is already bound to somebody else is not rebound.
+func (that *Session) bind(playerID string) bool {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	if that.playerID != "" && that.playerID != playerID {
+		return false
+	}
+
+	that.playerID = playerID
+
+	return true
+}
+
+// Locale returns the language the session receives server messages in.
+func (that *Session) Locale() string {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+
+	return that.locale
+}
+
+func (that *Session) setLocale(locale string) {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	that.locale = locale
+}
+
+// translate - returns the message of the key in the session locale.
+func (that *Session) translate(key string, params i18n.Params) string {
+	return i18n.Translate(that.Locale(), key, params)
+}
+
+// currentReplay - returns the replay the session watches, nil when it watches none.
+func (that *Session) currentReplay() *gameReplay {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+
+	return that.replay
+}
+
+// swapReplay - makes the replay the one the session watches and returns the previous one.
+func (that *Session) swapReplay(replay *gameReplay) *gameReplay {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	previous := that.replay
+	that.replay = replay
+
+	return previous
+}
+
+// Spectating - returns the ID of the game the session watches, an empty string when it watches none.
+func (that *Session) Spectating() string {
+	that.mu.RLock()
+	defer that.mu.RUnlock()
+
+	return that.spectating
+}
+
+// swapSpectating - makes the game the one the session watches and returns the previous one.
+func (that *Session) swapSpectating(gameID string) string {
+	that.mu.Lock()
+	defer that.mu.Unlock()
+
+	previous := that.spectating
+	that.spectating = gameID
+
+	return previous
+}
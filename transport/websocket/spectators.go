@@ -0,0 +1,229 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+const (
+	actionGameSpectate   = "game:spectate"
+	actionGameSpectators = "game:spectators"
+)
+
+// handleSpectate - subscribes the session to the live updates of the game, read-only.
+// A player who is in a game of their own can not watch another one, a session watches one game at a time.
+func (that *Server) handleSpectate(ctx context.Context, msg *Message, session *Session) error {
+	log := that.logger.With("method", "handleSpectate")
+
+	var payloadReq Payload
+
+	if err := json.Unmarshal(msg.Payload, &payloadReq); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	if payloadReq.Game == nil {
+		log.Error("Game is missing in payload")
+		return that.sendErrorResponse(session, msg, CodeGameRequired)
+	}
+
+	player, err := that.gameUseCase.GetOrCreatePlayer(ctx, session.PlayerID())
+	if err != nil {
+		log.Error("failed to get player", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
+	}
+
+	if player.GameID != "" {
+		return that.sendErrorResponse(session, msg, CodeAlreadyInGame)
+	}
+
+	game, err := that.gameUseCase.GetGameByID(ctx, payloadReq.Game.ID)
+	if err != nil {
+		log.Error("failed to get game", "error", err)
+		return that.sendErrorResponse(session, msg, errorCodeOf(err))
+	}
+
+	if game.IsFinished() {
+		return that.sendErrorResponse(session, msg, CodeGameFinished)
+	}
+
+	spectators, ok := that.subscribeSpectator(session, game.ID)
+	if !ok {
+		return that.sendErrorResponse(session, msg, CodeSpectatorsFull)
+	}
+
+	log.Info("spectator joined", "gameID", game.ID, "spectators", spectators)
+
+	players := game.Players
+
+	if err = that.reply(session, msg, Payload{Game: maskGameDetails(game), Spectators: spectators}); err != nil {
+		return fmt.Errorf("failed to send response: %w", err)
+	}
+
+	that.notifySpectatorCount(game.ID, players, session)
+
+	return nil
+}
+
+// stopSpectating - unsubscribes the spectating session, game:leave of a spectator stops watching the game.
+func (that *Server) stopSpectating(ctx context.Context, msg *Message, session *Session) error {
+	gameID := that.leaveSpectating(ctx, session)
+
+	if err := that.reply(session, msg, Payload{Game: &entity.Game{ID: gameID, Status: gameStatusLeave}}); err != nil {
+		return fmt.Errorf("failed to send response: %w", err)
+	}
+
+	return nil
+}
+
+// leaveSpectating - unsubscribes the session from the game it watches and tells the others how many watch it now.
+// Returns the ID of the game, an empty string when the session watched none.
+func (that *Server) leaveSpectating(ctx context.Context, session *Session) string {
+	log := that.logger.With("method", "leaveSpectating")
+
+	gameID, spectators := that.unsubscribeSpectator(session)
+	if gameID == "" {
+		return ""
+	}
+
+	log.Info("spectator left", "gameID", gameID, "spectators", spectators)
+
+	// the players get the count too, the game may be gone by now and then only the spectators are told
+	var players []*entity.Player
+	if game, err := that.gameUseCase.GetGameByID(ctx, gameID); err == nil {
+		players = game.Players
+	}
+
+	that.notifySpectatorCount(gameID, players, nil)
+
+	return gameID
+}
+
+// subscribeSpectator - makes the session a spectator of the game and returns the number of its spectators.
+// It reports false when the game already has as many spectators as the config lets it have.
+func (that *Server) subscribeSpectator(session *Session, gameID string) (int, bool) {
+	that.spectatorsMutex.Lock()
+	defer that.spectatorsMutex.Unlock()
+
+	if previous := session.swapSpectating(""); previous != "" {
+		delete(that.spectators[previous], session)
+	}
+
+	if len(that.spectators[gameID]) >= that.config.MaxSpectators {
+		return len(that.spectators[gameID]), false
+	}
+
+	if that.spectators[gameID] == nil {
+		that.spectators[gameID] = make(map[*Session]struct{})
+	}
+
+	that.spectators[gameID][session] = struct{}{}
+	session.swapSpectating(gameID)
+
+	return len(that.spectators[gameID]), true
+}
+
+// unsubscribeSpectator - stops the session watching its game, returns the game and the spectators left.
+func (that *Server) unsubscribeSpectator(session *Session) (string, int) {
+	that.spectatorsMutex.Lock()
+	defer that.spectatorsMutex.Unlock()
+
+	gameID := session.swapSpectating("")
+	if gameID == "" {
+		return "", 0
+	}
+
+	delete(that.spectators[gameID], session)
+	if len(that.spectators[gameID]) == 0 {
+		delete(that.spectators, gameID)
+	}
+
+	return gameID, len(that.spectators[gameID])
+}
+
+// spectatorCount - returns the number of sessions that watch the game.
+func (that *Server) spectatorCount(gameID string) int {
+	that.spectatorsMutex.RLock()
+	defer that.spectatorsMutex.RUnlock()
+
+	return len(that.spectators[gameID])
+}
+
+// spectatorSessions - returns the sessions that watch the game.
+func (that *Server) spectatorSessions(gameID string) []*Session {
+	that.spectatorsMutex.RLock()
+	defer that.spectatorsMutex.RUnlock()
+
+	sessions := make([]*Session, 0, len(that.spectators[gameID]))
+	for session := range that.spectators[gameID] {
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
+
+// notifySpectators - pushes the masked state of the game to its spectators.
+// The status overrides the status of the game, as the leave and opponent out updates do for players.
+func (that *Server) notifySpectators(action string, game *entity.Game, status string) {
+	log := that.logger.With("method", "notifySpectators", "gameID", game.ID)
+
+	sessions := that.spectatorSessions(game.ID)
+	if len(sessions) == 0 {
+		return
+	}
+
+	masked := *game
+	maskGameDetails(&masked)
+	if status != "" {
+		masked.Status = status
+	}
+
+	for _, spectator := range sessions {
+		if err := that.sendEvent(spectator, action, Payload{Game: &masked, Spectators: len(sessions)}); err != nil {
+			log.Error("failed to send game update to spectator", "error", err)
+		}
+	}
+}
+
+// endSpectating - pushes the last state of the game to its spectators and unsubscribes them, the game is over.
+func (that *Server) endSpectating(action string, game *entity.Game, status string) {
+	that.notifySpectators(action, game, status)
+
+	that.spectatorsMutex.Lock()
+	defer that.spectatorsMutex.Unlock()
+
+	for session := range that.spectators[game.ID] {
+		session.swapSpectating("")
+	}
+	delete(that.spectators, game.ID)
+}
+
+// notifySpectatorCount - tells the players and the spectators of the game, but the session, how many watch it.
+func (that *Server) notifySpectatorCount(gameID string, players []*entity.Player, except *Session) {
+	log := that.logger.With("method", "notifySpectatorCount", "gameID", gameID)
+
+	payload := Payload{Game: &entity.Game{ID: gameID}, Spectators: that.spectatorCount(gameID)}
+
+	recipients := that.spectatorSessions(gameID)
+	for _, player := range players {
+		if player.IsBot() {
+			continue
+		}
+
+		if playerSession, ok := that.sessionByPlayerID(player.ID); ok {
+			recipients = append(recipients, playerSession)
+		}
+	}
+
+	for _, recipient := range recipients {
+		if recipient == except {
+			continue
+		}
+
+		if err := that.sendEvent(recipient, actionGameSpectators, payload); err != nil {
+			log.Error("failed to send spectator count", "error", err)
+		}
+	}
+}
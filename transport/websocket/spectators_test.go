@@ -0,0 +1,316 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketscienceinc/tictactoe-backend/internal/apperror"
+	"github.com/rocketscienceinc/tictactoe-backend/internal/entity"
+)
+
+// testWatchedGame - an ongoing game of p1 and p2 with the first move made, the game the spectators watch.
+func testWatchedGame(t *testing.T) *entity.Game {
+	t.Helper()
+
+	game := entity.NewGame("watched", entity.PrivateType)
+	game.Players = []*entity.Player{
+		{ID: "p1", PublicID: "pub1", GameID: game.ID, Mark: entity.PlayerX},
+		{ID: "p2", PublicID: "pub2", GameID: game.ID, Mark: entity.PlayerO},
+	}
+	game.Start()
+	require.NoError(t, game.MakeTurn(entity.PlayerX, 4))
+
+	return game
+}
+
+// spectatorUseCase - a use case that knows the watched game, its players are in it and everyone else is in no game.
+func spectatorUseCase(t *testing.T) *stubGameUseCase {
+	t.Helper()
+
+	return &stubGameUseCase{
+		getOrCreatePlayer: func(_ context.Context, playerID string) (*entity.Player, error) {
+			player := &entity.Player{ID: playerID, PublicID: "pub-" + playerID}
+			if playerID == "p1" || playerID == "p2" {
+				player.GameID = "watched"
+			}
+
+			return player, nil
+		},
+		getGameByID: func(_ context.Context, gameID string) (*entity.Game, error) {
+			if gameID != "watched" {
+				return nil, apperror.ErrGameNotFound
+			}
+
+			return testWatchedGame(t), nil
+		},
+	}
+}
+
+// spectateMessage - the request to watch the game.
+func spectateMessage(gameID string) *Message {
+	return &Message{ID: "1", Action: actionGameSpectate, Payload: json.RawMessage(`{"game":{"id":"` + gameID + `"}}`)}
+}
+
+// spectate - makes the player a spectator of the watched game and reads the reply.
+func spectate(t *testing.T, server *Server, playerID string) (*Session, *testClient) {
+	t.Helper()
+
+	session, client := boundSession(t, server, playerID)
+	require.NoError(t, server.handleSpectate(context.Background(), spectateMessage("watched"), session))
+
+	reply, _ := client.readMessage()
+	require.Equal(t, messageTypeResponse, reply.Type)
+
+	return session, client
+}
+
+// readSpectatorCount - reads the next message, it must be the spectator count of the watched game.
+func readSpectatorCount(t *testing.T, client *testClient) any {
+	t.Helper()
+
+	event, payload := client.readMessage()
+	require.Equal(t, messageTypeEvent, event.Type)
+	require.Equal(t, actionGameSpectators, event.Action)
+	require.Equal(t, "watched", payload["game"].(map[string]any)["id"])
+
+	return payload["spectators"]
+}
+
+func TestServer_HandleSpectate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Spectator gets the masked game and the players get the count", func(t *testing.T) {
+		// Given: both players of the game are connected
+		conf := testConfig()
+		conf.MaxSpectators = 2
+		server, _ := newTestServer(t, conf, spectatorUseCase(t))
+		_, player1 := boundSession(t, server, "p1")
+		_, player2 := boundSession(t, server, "p2")
+
+		// When: a player out of the game watches it
+		session, client := boundSession(t, server, "s1")
+		require.NoError(t, server.handleSpectate(ctx, spectateMessage("watched"), session))
+
+		// Then: the spectator should get the game without its players
+		reply, payload := client.readMessage()
+		assert.Equal(t, messageTypeResponse, reply.Type)
+		assert.InDelta(t, 1, payload["spectators"], 0)
+
+		game := payload["game"].(map[string]any)
+		assert.Equal(t, "watched", game["id"])
+		assert.Equal(t, entity.PlayerX, game["board"].([]any)[4])
+		assert.NotContains(t, game, "players")
+
+		// Then: both players should be told one spectator watches them
+		assert.InDelta(t, 1, readSpectatorCount(t, player1), 0)
+		assert.InDelta(t, 1, readSpectatorCount(t, player2), 0)
+	})
+
+	t.Run("Spectators are capped", func(t *testing.T) {
+		// Given: a game with as many spectators as the config lets it have
+		conf := testConfig()
+		conf.MaxSpectators = 1
+		server, _ := newTestServer(t, conf, spectatorUseCase(t))
+		spectate(t, server, "s1")
+
+		// When: one more player watches it
+		session, client := boundSession(t, server, "s2")
+		require.NoError(t, server.handleSpectate(ctx, spectateMessage("watched"), session))
+
+		// Then: the player should be refused and the game keep its spectator
+		assert.Equal(t, CodeSpectatorsFull, readErrorCode(t, client))
+		assert.Empty(t, session.swapSpectating(""))
+		assert.Equal(t, 1, server.spectatorCount("watched"))
+	})
+
+	t.Run("Player in a game can not watch another one", func(t *testing.T) {
+		// Given: a player of the game
+		conf := testConfig()
+		conf.MaxSpectators = 1
+		server, _ := newTestServer(t, conf, spectatorUseCase(t))
+		session, client := boundSession(t, server, "p1")
+
+		// When: the player watches a game
+		require.NoError(t, server.handleSpectate(ctx, spectateMessage("watched"), session))
+
+		// Then: the player should be refused
+		assert.Equal(t, CodeAlreadyInGame, readErrorCode(t, client))
+		assert.Zero(t, server.spectatorCount("watched"))
+	})
+
+	t.Run("Finished game can not be watched", func(t *testing.T) {
+		// Given: a game that is over
+		uc := spectatorUseCase(t)
+		uc.getGameByID = func(context.Context, string) (*entity.Game, error) {
+			game := testWatchedGame(t)
+			game.Status = entity.StatusFinished
+
+			return game, nil
+		}
+
+		conf := testConfig()
+		conf.MaxSpectators = 1
+		server, _ := newTestServer(t, conf, uc)
+		session, client := boundSession(t, server, "s1")
+
+		// When: a player watches it
+		require.NoError(t, server.handleSpectate(ctx, spectateMessage("watched"), session))
+
+		// Then: the player should be refused
+		assert.Equal(t, CodeGameFinished, readErrorCode(t, client))
+	})
+
+	t.Run("Leaving spectator is counted out", func(t *testing.T) {
+		// Given: two spectators of the game
+		conf := testConfig()
+		conf.MaxSpectators = 2
+		server, _ := newTestServer(t, conf, spectatorUseCase(t))
+		session, client := spectate(t, server, "s1")
+		_, other := spectate(t, server, "s2")
+		assert.InDelta(t, 2, readSpectatorCount(t, client), 0, "the first spectator is told of the second")
+
+		// When: the first one leaves the game
+		leave := &Message{ID: "2", Action: payloadActionGameLeave, Payload: json.RawMessage(`{}`)}
+		require.NoError(t, server.stopSpectating(ctx, leave, session))
+
+		// Then: the other spectator should be told one watches the game now
+		assert.InDelta(t, 1, readSpectatorCount(t, other), 0)
+		assert.Equal(t, 1, server.spectatorCount("watched"))
+	})
+}
+
+func TestServer_NotifySpectators(t *testing.T) {
+	// Given: a spectator of the game
+	conf := testConfig()
+	conf.MaxSpectators = 1
+	server, _ := newTestServer(t, conf, spectatorUseCase(t))
+	_, client := spectate(t, server, "s1")
+
+	game := testWatchedGame(t)
+	game.Difficulty = entity.HardDifficulty
+
+	// When: the game is pushed to the spectators
+	server.notifySpectators(actionGameTurn, game, "")
+
+	// Then: the spectator should get the game without the details of the players
+	event, payload := client.readMessage()
+	assert.Equal(t, messageTypeEvent, event.Type)
+	assert.Equal(t, actionGameTurn, event.Action)
+	assert.InDelta(t, 1, payload["spectators"], 0)
+
+	masked := payload["game"].(map[string]any)
+	assert.NotContains(t, masked, "players")
+	assert.NotContains(t, masked, "difficulty")
+	assert.Equal(t, entity.PlayerX, masked["board"].([]any)[4])
+
+	// Then: the game of the players should stay as it was
+	assert.Len(t, game.Players, 2)
+	assert.Equal(t, entity.HardDifficulty, game.Difficulty)
+}
+
+func TestServer_EnteringGameStopsSpectating(t *testing.T) {
+	ctx := context.Background()
+
+	// newGame - the game the spectators enter, s1 plays s2 in it
+	newGame := func() *entity.Game {
+		game := entity.NewGame("entered", entity.PrivateType)
+		game.Players = []*entity.Player{
+			{ID: "s1", PublicID: "pub-s1", GameID: game.ID, Mark: entity.PlayerX},
+			{ID: "s2", PublicID: "pub-s2", GameID: game.ID, Mark: entity.PlayerO},
+		}
+
+		return game
+	}
+
+	tests := []struct {
+		name  string
+		enter func(t *testing.T, server *Server, session *Session) error
+		setup func(uc *stubGameUseCase)
+	}{
+		{
+			name: "New game",
+			setup: func(uc *stubGameUseCase) {
+				uc.getOrCreateGame = func(context.Context, string, string, string, int, entity.GameSettings) (*entity.Game, error) {
+					return newGame(), nil
+				}
+			},
+			enter: func(_ *testing.T, server *Server, session *Session) error {
+				msg := &Message{ID: "2", Action: "game:new", Payload: json.RawMessage(`{"game":{"type":"private"}}`)}
+				return server.handleNewGame(ctx, msg, session)
+			},
+		},
+		{
+			name: "Joined game",
+			setup: func(uc *stubGameUseCase) {
+				uc.joinGameByID = func(context.Context, string, string) (*entity.Game, error) {
+					return newGame(), nil
+				}
+			},
+			enter: func(_ *testing.T, server *Server, session *Session) error {
+				msg := &Message{ID: "2", Action: "game:join", Payload: json.RawMessage(`{"game":{"id":"entered"}}`)}
+				return server.handleJoinGame(ctx, msg, session)
+			},
+		},
+		{
+			name: "Rematch",
+			setup: func(uc *stubGameUseCase) {
+				uc.createPrivateGameWithTwoPlayers = func(context.Context, *entity.Player, *entity.Player, entity.GameSettings) (*entity.Game, error) {
+					game := newGame()
+					game.Start()
+
+					return game, nil
+				}
+			},
+			enter: func(t *testing.T, server *Server, session *Session) error {
+				t.Helper()
+
+				// s2 has already said yes to the rematch
+				server.rematchRequests[makeRematchKey("s1", "s2")] = &RematchRequest{
+					Players:   sortPair("s1", "s2"),
+					ExpiresAt: time.Now().Add(time.Minute),
+					Responses: map[string]bool{"s2": true},
+				}
+
+				msg := &Message{ID: "2", Action: "game:rematch"}
+				player := &entity.Player{ID: "s1", LastOpponentID: "s2"}
+				opponent := &entity.Player{ID: "s2", LastOpponentID: "s1"}
+
+				return server.processRematchYes(ctx, msg, session, player, opponent)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: both players of the new game watch another one, a player of the watched game is connected
+			uc := spectatorUseCase(t)
+			tt.setup(uc)
+
+			conf := testConfig()
+			conf.MaxSpectators = 2
+			server, _ := newTestServer(t, conf, uc)
+			_, player1 := boundSession(t, server, "p1")
+
+			session1, _ := spectate(t, server, "s1")
+			session2, _ := spectate(t, server, "s2")
+			readSpectatorCount(t, player1)
+			readSpectatorCount(t, player1)
+
+			// When: s1 enters the game with s2
+			require.NoError(t, tt.enter(t, server, session1))
+
+			// Then: neither should watch the other game any more and its player should be told
+			assert.Empty(t, session1.swapSpectating(""))
+			assert.Empty(t, session2.swapSpectating(""))
+			assert.Zero(t, server.spectatorCount("watched"))
+
+			assert.InDelta(t, 1, readSpectatorCount(t, player1), 0)
+			assert.Nil(t, readSpectatorCount(t, player1), "no spectator is left")
+		})
+	}
+}